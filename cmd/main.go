@@ -1,86 +1,600 @@
 package main
 
 import (
-	"context" // Для управления таймаутами и отменой задач
-	"fmt"     // Для форматирования строк и вывода ошибок
-	"log"     // Для логирования сообщений
-	"time"    // Для работы с датой и временем
+	"context"   // Для управления таймаутами и отменой задач
+	"errors"    // Для определения причины остановки
+	"flag"      // Для разбора аргументов командной строки
+	"fmt"       // Для форматирования строк и вывода ошибок
+	"io"        // Для функций записи отчётов
+	"log"       // Для логирования сообщений
+	"log/slog"  // Для журнала диагностики
+	"net"       // Для адреса сервера метрик
+	"net/http"  // Для сервера метрик
+	"os"        // Для сигналов операционной системы и файлов
+	"os/signal" // Для перехвата SIGINT/SIGTERM
+	"regexp"    // Для шаблона маршрутов входа
+	"syscall"   // Для константы SIGTERM
+	"time"      // Для работы с датой и временем
 
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/alert"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/detect"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/export"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/geoip"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/logging"
 	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"
 	"github.com/Evgenymoshrage/Go-Log-Processor/internal/processor"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/query"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/slo"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/tui"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/useragent"
 	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"
 )
 
 func main() {
+	i18n.SetDefault(i18n.FromEnv()) // Язык справки по флагам; -lang действует после разбора флагов
+	filePath := flag.String("file", "internal/testdata/logs.csv", i18n.T("main.flag.file"))
+	timeout := flag.Duration("timeout", 10*time.Second, i18n.T("main.flag.timeout"))
+	follow := flag.Bool("follow", false, i18n.T("main.flag.follow"))
+	fromEnd := flag.Bool("from-end", false, i18n.T("main.flag.from_end"))
+	poll := flag.Duration("poll", 500*time.Millisecond, i18n.T("main.flag.poll"))
+	tuiMode := flag.Bool("tui", false, i18n.T("main.flag.tui"))
+	numWorkers := flag.Int("workers", 5, i18n.T("main.flag.workers"))
+	maxWorkers := flag.Int("max-workers", 0, i18n.T("main.flag.max_workers"))
+	queryText := flag.String("query", "", i18n.T("main.flag.query"))
+	sqlText := flag.String("sql", "", i18n.T("main.flag.sql"))
+	allowFile := flag.String("allow", "", i18n.T("main.flag.allow"))
+	denyFile := flag.String("deny", "", i18n.T("main.flag.deny"))
+	trustedFile := flag.String("trusted-proxies", "", i18n.T("main.flag.trusted_proxies"))
+	geoipFile := flag.String("geoip", "", i18n.T("main.flag.geoip"))
+	asnFile := flag.String("asn", "", i18n.T("main.flag.asn"))
+	loginRoutes := flag.String("login-routes", detect.DefaultLoginRoutes.String(), i18n.T("main.flag.login_routes"))
+	bfWindow := flag.Duration("bf-window", 5*time.Minute, i18n.T("main.flag.bf_window"))
+	bfIPThreshold := flag.Int("bf-ip-threshold", 5, i18n.T("main.flag.bf_ip_threshold"))
+	bfSubnetThreshold := flag.Int("bf-subnet-threshold", 20, i18n.T("main.flag.bf_subnet_threshold"))
+	scanWindow := flag.Duration("scan-window", 10*time.Minute, i18n.T("main.flag.scan_window"))
+	scanPaths := flag.Int("scan-paths", 10, i18n.T("main.flag.scan_paths"))
+	scanRatio := flag.Float64("scan-404-ratio", 0.5, i18n.T("main.flag.scan_404_ratio"))
+	probesFile := flag.String("probes", "", i18n.T("main.flag.probes"))
+	anomalyBucket := flag.Duration("anomaly-bucket", time.Minute, i18n.T("main.flag.anomaly_bucket"))
+	anomalyBaseline := flag.Int("anomaly-baseline", 30, i18n.T("main.flag.anomaly_baseline"))
+	anomalyThreshold := flag.Float64("anomaly-threshold", 3.5, i18n.T("main.flag.anomaly_threshold"))
+	anomalyMinRequests := flag.Int("anomaly-min-requests", 10, i18n.T("main.flag.anomaly_min_requests"))
+	apdexT := flag.Int("apdex-t", model.DefaultApdexT, i18n.T("main.flag.apdex_t"))
+	apdexBucket := flag.Duration("apdex-bucket", model.DefaultApdexBucket, i18n.T("main.flag.apdex_bucket"))
+	sloFile := flag.String("slo", "", i18n.T("main.flag.slo"))
+	alertsFile := flag.String("alerts", "", i18n.T("main.flag.alerts"))
+	subnetV4 := flag.Int("subnet-v4", 0, i18n.T("main.flag.subnet_v4"))
+	metricsAddr := flag.String("metrics-addr", "", i18n.T("main.flag.metrics_addr"))
+	exportFile := flag.String("export", "", i18n.T("main.flag.export"))
+	exportFormat := flag.String("export-format", "", i18n.T("main.flag.export_format"))
+	htmlFile := flag.String("html", "", i18n.T("main.flag.html"))
+	jsonFile := flag.String("json", "", i18n.T("main.flag.json"))
+	subnetV6 := flag.Int("subnet-v6", 0, i18n.T("main.flag.subnet_v6"))
+	langName := flag.String("lang", "", i18n.T("main.flag.lang"))
+	logLevel := flag.String("log-level", "info", i18n.T("main.flag.log_level"))
+	logFormat := flag.String("log-format", logging.FormatText, i18n.T("main.flag.log_format"))
+	logFile := flag.String("log-file", "", i18n.T("main.flag.log_file"))
+	flag.Parse()
 
-	// ================================================  Загрузка логов ================================================
+	var err error
+	lang := i18n.Default().Lang()
+	if *langName != "" {
+		if lang, err = i18n.Parse(*langName); err != nil {
+			log.Fatal(err)
+		}
+	}
+	i18n.SetDefault(lang) // Язык сводки, ошибок загрузки и сообщений воркеров
+	level, err := logging.ParseLevel(*logLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := logging.ParseFormat(*logFormat); err != nil {
+		log.Fatal(err)
+	}
+
+	if *tuiMode && !*follow {
+		log.Fatal(i18n.T("main.error.tui_follow"))
+	}
+	if *tuiMode && *jsonFile == "-" {
+		log.Fatal(i18n.T("main.error.tui_json"))
+	}
+
+	jsonOut := os.Stdout
+	if *jsonFile == "-" { // В stdout идёт только JSON, остальной вывод — в stderr
+		os.Stdout = os.Stderr
+	}
+
+	var filter *query.Query
+	if *queryText != "" { // Проверяем запрос до загрузки логов, чтобы сразу показать ошибку
+		filter, err = query.Compile(*queryText)
+		if err != nil {
+			var qerr *query.Error
+			if errors.As(err, &qerr) {
+				log.Fatal(i18n.T("main.error.query_pretty", qerr.Pretty()))
+			}
+			log.Fatal(i18n.T("main.error.query", err))
+		}
+	}
 
-	utilits.PrintCentered("Загружаем логи!", 120)
-	// Загружаем логи
-	logs, err := processor.LoadLogs("internal/testdata/logs.csv")
+	var acl netaddr.AccessList
+	if *allowFile != "" {
+		if acl.Allow, err = netaddr.LoadCIDRFile(*allowFile); err != nil {
+			log.Fatal(i18n.T("main.error.allow", err))
+		}
+	}
+	if *denyFile != "" {
+		if acl.Deny, err = netaddr.LoadCIDRFile(*denyFile); err != nil {
+			log.Fatal(i18n.T("main.error.deny", err))
+		}
+	}
+
+	var resolver netaddr.ProxyResolver
+	if *trustedFile != "" {
+		if resolver.Trusted, err = netaddr.LoadCIDRFile(*trustedFile); err != nil {
+			log.Fatal(i18n.T("main.error.trusted", err))
+		}
+	}
+
+	var geoPaths []string
+	for _, path := range []string{*geoipFile, *asnFile} {
+		if path != "" {
+			geoPaths = append(geoPaths, path)
+		}
+	}
+	var geoDB *geoip.DB
+	if len(geoPaths) > 0 {
+		if geoDB, err = geoip.OpenDB(geoPaths...); err != nil {
+			log.Fatal(i18n.T("main.error.geoip", err))
+		}
+	}
+
+	loginRe, err := regexp.Compile(*loginRoutes)
 	if err != nil {
-		log.Fatalf("Ошибка загрузки логов: %v", err)
+		log.Fatal(i18n.T("main.error.login_routes", err))
+	}
+	printAlert := func(a detect.Alert) { // Тревоги печатаются сразу, как только порог превышен
+		fmt.Println(i18n.T("main.live_alert", time.Now().Format("2006-01-02 15:04:05"), a.Message))
+	}
+	bruteForce := detect.NewBruteForceDetector(detect.BruteForceConfig{
+		Routes:          loginRe,
+		Window:          *bfWindow,
+		IPThreshold:     *bfIPThreshold,
+		SubnetThreshold: *bfSubnetThreshold,
+		SubnetV4Bits:    *subnetV4,
+		SubnetV6Bits:    *subnetV6,
+		OnAlert:         printAlert,
+	})
+
+	signatures := detect.DefaultSignatures()
+	if *probesFile != "" {
+		f, err := os.Open(*probesFile)
+		if err != nil {
+			log.Fatal(i18n.T("main.error.probes", err))
+		}
+		signatures, err = detect.LoadSignatures(f)
+		f.Close()
+		if err != nil {
+			log.Fatal(i18n.T("main.error.probes", err))
+		}
+	}
+	scanners := detect.NewScannerDetector(detect.ScannerConfig{
+		Window:        *scanWindow,
+		DistinctPaths: *scanPaths,
+		NotFoundRatio: *scanRatio,
+		Signatures:    signatures,
+		OnAlert:       printAlert,
+	})
+
+	anomalies := detect.NewAnomalyDetector(detect.AnomalyConfig{
+		Bucket:      *anomalyBucket,
+		Baseline:    *anomalyBaseline,
+		Threshold:   *anomalyThreshold,
+		MinRequests: *anomalyMinRequests,
+		OnAnomaly: func(a detect.Anomaly) {
+			fmt.Println(i18n.T("main.live_anomaly", time.Now().Format("2006-01-02 15:04:05"), a.Time.Format("2006-01-02 15:04"), a.Message))
+		},
+	})
+
+	var sloEval *slo.Evaluator
+	if *sloFile != "" {
+		sloConfig, err := slo.Load(*sloFile)
+		if err != nil {
+			log.Fatal(i18n.T("main.error.slo", err))
+		}
+		sloEval = slo.NewEvaluator(sloConfig, func(a slo.Alert) {
+			fmt.Println(i18n.T("main.live_alert", time.Now().Format("2006-01-02 15:04:05"), a.Message))
+		})
+	}
+
+	var alertConfig *alert.Config
+	if *alertsFile != "" {
+		if alertConfig, err = alert.Load(*alertsFile); err != nil {
+			log.Fatal(i18n.T("main.error.alerts", err))
+		}
 	}
 
-	fmt.Printf("Успешно загружено %d записей\n", len(logs))
-	for i, l := range logs[:2] { // покажем первые 2
-		fmt.Printf("%d: %+v\n", i+1, utilits.LogEntryToString(l))
+	var aggregator *query.Aggregator
+	if *sqlText != "" {
+		sel, err := query.ParseSelect(*sqlText)
+		if err != nil {
+			var qerr *query.Error
+			if errors.As(err, &qerr) {
+				log.Fatal(i18n.T("main.error.sql_pretty", qerr.Pretty()))
+			}
+			log.Fatal(i18n.T("main.error.sql", err))
+		}
+		aggregator = sel.NewAggregator()
 	}
-	fmt.Println("...")
-	for i, l := range logs[len(logs)-2:] { // покажем последние  2
-		fmt.Printf("%d: %+v\n", len(logs)-2+i+1, utilits.LogEntryToString(l))
+
+	var exporter export.Writer
+	if *exportFile != "" { // Файл создаётся до обработки, чтобы ошибка в имени или формате была видна сразу
+		exporter, err = export.Create(*exportFile, *exportFormat, export.Options{ApdexT: *apdexT})
+		if err != nil {
+			log.Fatal(i18n.T("main.error.export", err))
+		}
+	}
+
+	// ================================================  Загрузка логов ================================================
+
+	var logs []model.LogEntry
+	if !*follow { // В режиме слежения файл читается по мере роста, а не целиком
+		utilits.PrintCentered(i18n.T("main.title.loading"), 120)
+		// Загружаем логи
+		logs, err = processor.LoadLogs(*filePath)
+		if err != nil {
+			log.Fatal(i18n.T("main.error.load", err))
+		}
+
+		fmt.Println(i18n.T("main.loaded", len(logs)))
+		for i, l := range logs[:2] { // покажем первые 2
+			fmt.Printf("%d: %+v\n", i+1, utilits.LogEntryToString(l))
+		}
+		fmt.Println("...")
+		for i, l := range logs[len(logs)-2:] { // покажем последние  2
+			fmt.Printf("%d: %+v\n", len(logs)-2+i+1, utilits.LogEntryToString(l))
+		}
 	}
 
 	// ================================================ Обработка логов ================================================
-	utilits.PrintCentered("Воркеры начинают работу!", 120)
-	// Общий таймаут на всю систему — 10 секунд
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	utilits.PrintCentered(i18n.T("main.title.workers_started"), 120)
+	// SIGINT/SIGTERM или истечение таймаута останавливают чтение входных данных:
+	// воркеры дообрабатывают записи, которые уже взяли, и статистика печатается как частичная.
+	// Повторный сигнал завершает программу сразу (stop возвращает стандартную обработку сигналов)
+	intakeCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 && !*follow {
+		var cancel context.CancelFunc
+		intakeCtx, cancel = context.WithTimeout(intakeCtx, *timeout)
+		defer cancel()
+	}
+	go func() {
+		<-intakeCtx.Done()
+		stop()
+	}()
+
+	// Контекст воркеров не отменяется по сигналу, чтобы они успели дообработать взятые записи
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	numWorkers := 5
-	inputChan := make(chan model.LogEntry) // Канал для воркеров
-	stats := &model.Statistics{            // Создаём объект статистики
+	inputChan := make(chan model.LogEntry, 100) // Буферизованный канал для воркеров: его заполненность — сигнал для масштабирования
+	stats := &model.Statistics{                 // Создаём объект статистики
 		RequestsByIP: make(map[string]int),
+		ApdexT:       *apdexT,
+		ApdexBucket:  *apdexBucket,
 	}
 
-	outputChan := processor.ProcessLogs(ctx, inputChan, numWorkers, stats)
+	var dashboard *tui.Dashboard
+	terminal := os.Stdout
+	var messages *os.File // Куда программа пишет сообщения, пока открыта панель
+	messagesDone := make(chan struct{})
+	if *tuiMode { // Сообщения (тревоги, оповещения) перехватываются до создания получателей и показываются на панели
+		dashboard = tui.New(tui.Options{Stats: stats, QueueDepth: func() int { return len(inputChan) }, Title: *filePath})
+		r, w, err := os.Pipe()
+		if err != nil {
+			log.Fatal(i18n.T("main.error.tui", err))
+		}
+		os.Stdout, messages = w, w
+		go func() {
+			defer close(messagesDone)
+			io.Copy(dashboard, r)
+		}()
+	}
 
+	// Журнал диагностики идёт в stderr или файл, чтобы в stdout оставались только результаты.
+	// Панель занимает терминал, поэтому без файла журнал показывается на ней среди сообщений
+	logOut := io.Writer(os.Stderr)
+	if messages != nil {
+		logOut = messages
+	}
+	logger, closeLog, err := logging.New(logging.Options{Level: level, Format: *logFormat, File: *logFile, Output: logOut})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeLog()
+
+	if *metricsAddr != "" { // Метрики доступны, пока идёт обработка; в режиме -follow — до Ctrl+C
+		server, err := serveMetrics(*metricsAddr, stats, func() int { return len(inputChan) }, logger)
+		if err != nil {
+			log.Fatal(i18n.T("main.error.metrics", err))
+		}
+		defer server.Close()
+	}
+
+	var alerts *alert.Engine
+	if alertConfig != nil { // Правила проверяются по мере обработки и видят живую статистику (очередь, воркеры)
+		alerts = alert.NewEngine(alertConfig, alert.Options{
+			Stats:      stats,
+			QueueDepth: func() int { return len(inputChan) },
+			OnError: func(err error) {
+				logger.Warn(i18n.T("main.delivery_failed"), slog.Any("error", err))
+			},
+		})
+	}
+
+	opts := processor.Options{
+		NumWorkers: *numWorkers,
+		Stages:     []processor.Stage{processor.SimulateWork(10 * time.Millisecond)}, // Имитация обработки
+		Retry:      processor.RetryPolicy{MaxAttempts: 3, Backoff: 50 * time.Millisecond, MaxBackoff: time.Second},
+		Logger:     logger,
+	}
+	if filter != nil { // Фильтр идёт первым этапом, чтобы не тратить время на лишние записи
+		opts.Stages = append([]processor.Stage{processor.FilterStage(filter.Match)}, opts.Stages...)
+	}
+	// User-Agent разбирается всегда: без колонки user_agent этап ничего не делает
+	opts.Stages = append([]processor.Stage{processor.UserAgentStage(useragent.Default())}, opts.Stages...)
+	if geoDB != nil { // Геоданные нужны фильтру запроса, поэтому этап идёт перед ним
+		opts.Stages = append([]processor.Stage{processor.GeoIPStage(geoDB)}, opts.Stages...)
+	}
+	if acl.Allow.Len() > 0 || acl.Deny.Len() > 0 { // Списки подсетей проверяются раньше всех остальных этапов
+		opts.Stages = append([]processor.Stage{processor.FilterStage(processor.Access(acl))}, opts.Stages...)
+	}
+	if resolver.Trusted.Len() > 0 { // IP клиента определяется до всех фильтров, чтобы они работали с ним, а не с адресом прокси
+		opts.Stages = append([]processor.Stage{processor.ResolveClientIP(resolver)}, opts.Stages...)
+	}
+	observers := []processor.Observer{bruteForce, scanners, anomalies}
+	if sloEval != nil {
+		observers = append(observers, sloEval)
+	}
+	tickers := []interface{ Tick() }{anomalies} // Сдвигают время по часам, когда при слежении нет новых строк
+	if alerts != nil {
+		observers = append(observers, alerts)
+		tickers = append(tickers, alerts)
+	}
+	if dashboard != nil {
+		observers = append(observers, dashboard)
+	}
+	opts.Stages = append(opts.Stages, processor.ObserveStage(observers...)) // Детекторы видят уже дополненные записи
+	if aggregator != nil {                                                  // Агрегация идёт последним этапом, по мере обработки записей
+		opts.Stages = append(opts.Stages, func(ctx context.Context, entry *model.LogEntry) error {
+			aggregator.Add(*entry)
+			return nil
+		})
+	}
+	if *maxWorkers > *numWorkers { // Адаптивный пул: от -workers до -max-workers воркеров
+		opts.Scaling = &processor.ScalingPolicy{MinWorkers: *numWorkers, MaxWorkers: *maxWorkers, Interval: 100 * time.Millisecond}
+	}
+	outputChan, failedChan := processor.ProcessLogsWithErrors(ctx, inputChan, opts, stats)
+
+	sent := 0 // Сколько записей передано воркерам; читается только после закрытия outputChan
 	go func() {
+		defer close(inputChan) // Закрываем канал, чтобы воркеры знали, что задач больше нет
+		if *follow {
+			followLogs(intakeCtx, stop, *filePath, processor.FollowOptions{Poll: *poll, FromEnd: *fromEnd}, inputChan, stats, tickers, logger)
+			return
+		}
 		for _, logEntry := range logs {
-			inputChan <- logEntry
+			select {
+			case inputChan <- logEntry:
+				sent++
+			case <-intakeCtx.Done(): // Остановка: новые записи больше не выдаём
+				return
+			}
 		}
-		close(inputChan) // Закрываем канал, чтобы воркеры знали, что задач больше нет
 	}()
+	dashboardDone := make(chan struct{})
+	go func() { // Панель работает, пока не нажата q или Ctrl+C; выход из панели останавливает слежение
+		defer close(dashboardDone)
+		if dashboard == nil {
+			return
+		}
+		if err := dashboard.Run(intakeCtx, os.Stdin, terminal); err != nil {
+			logger.Error(i18n.T("main.dashboard_failed"), slog.Any("error", err))
+		}
+		stop()
+	}()
+	var failedLogs []model.FailedEntry
+	failedDone := make(chan struct{})
+	go func() { // Собираем записи, которые не удалось обработать, параллельно с результатами
+		defer close(failedDone)
+		for failed := range failedChan {
+			failedLogs = append(failedLogs, failed)
+		}
+	}()
+
 	var processedLogs []model.LogEntry
+	exported := 0
 	for log := range outputChan {
-		processedLogs = append(processedLogs, log)
+		if exporter != nil { // Выгружаем по мере обработки, не дожидаясь конца
+			if err := exporter.Write(log); err != nil {
+				logger.Error(i18n.T("main.export_stopped"), slog.String("file", *exportFile), slog.Any("error", err))
+				exporter.Close()
+				exporter = nil
+			} else {
+				exported++
+			}
+		}
+		if !*follow { // При слежении записи не копятся: поток бесконечный
+			processedLogs = append(processedLogs, log)
+		}
+	}
+	<-failedDone
+	<-dashboardDone
+	os.Stdout = terminal
+	if exporter != nil {
+		if err := exporter.Close(); err != nil {
+			logger.Error(i18n.T("main.export_failed"), slog.String("file", *exportFile), slog.Any("error", err))
+		} else {
+			fmt.Println(i18n.T("main.exported", *exportFile, exported))
+		}
+	}
+	anomalies.Flush() // Закрываем последние интервалы
+	if alerts != nil {
+		alerts.Close() // Проверяем правила до последней записи и дожидаемся доставки оповещений
+	}
+	if messages != nil { // Последние перехваченные сообщения панель уже передаёт в терминал
+		messages.Close()
+		<-messagesDone
+	}
+
+	for _, failed := range failedLogs {
+		fmt.Println(i18n.T("main.failed_entry", failed.Attempts, utilits.LogEntryToString(failed.Entry), failed.Err))
 	}
 
 	// Сообщение о завершении всех воркеров
-	utilits.PrintCentered("Все воркеры завершили работу!", 120)
+	utilits.PrintCentered(i18n.T("main.title.workers_finished"), 120)
+
+	if !*follow && sent < len(logs) { // Обработаны не все записи — значит, работу остановили
+		processor.MarkPartial(stats)
+		reason := i18n.T("main.reason.signal")
+		if errors.Is(intakeCtx.Err(), context.DeadlineExceeded) {
+			reason = i18n.T("main.reason.timeout")
+		}
+		fmt.Println(i18n.T("main.interrupted", reason, sent, len(logs)))
+	}
 
 	// ================================================ Фильтрация логов ================================================
 
-	// Фильтруем уже после завершения воркеров
-	logs2xx, logs4xx, logs5xx := processor.FilterLogs(processedLogs, 200)
-	utilits.PrintCentered("Запускается фильтрация!", 120)
-	fmt.Println("=== 2xx ===")
-	for log := range logs2xx {
-		fmt.Printf("%d %s\n", log.StatusCode, log.URL)
+	if !*follow { // Фильтруем уже после завершения воркеров: раскладываем по всем классам статусов
+		router := processor.StatusClassRouter()
+		outputs := router.Route(processedLogs)
+		utilits.PrintCentered(i18n.T("main.title.filtering"), 120)
+		for _, name := range router.Names() {
+			fmt.Printf("=== %s ===\n", name)
+			for log := range outputs[name] {
+				fmt.Printf("%d %s\n", log.StatusCode, log.URL)
+			}
+		}
+		utilits.PrintCentered(i18n.T("main.title.filtering_done"), 120)
+	}
+
+	// ================================================ Вывод статистики ================================================
+	if aggregator != nil {
+		utilits.PrintCentered(i18n.T("main.title.query_result"), 120)
+		fmt.Println(aggregator.Result())
+		return
+	}
+	reportOpts := processor.ReportOptions{
+		TopN:         5,
+		SubnetV4Bits: *subnetV4,
+		SubnetV6Bits: *subnetV6,
+		BruteForce:   bruteForce,
+		Scanners:     scanners,
+		Anomalies:    anomalies,
+		SLO:          sloEval,
+		Alerts:       alerts,
+	}
+	if *htmlFile != "" {
+		if err := writeHTMLReport(*htmlFile, stats, reportOpts); err != nil {
+			log.Fatal(i18n.T("main.error.html", err))
+		}
+		fmt.Println(i18n.T("main.html_written", *htmlFile))
+	}
+	if *jsonFile == "-" { // JSON вместо текстовой статистики
+		if err := processor.WriteJSONReport(jsonOut, stats, reportOpts); err != nil {
+			log.Fatal(i18n.T("main.error.json", err))
+		}
+		return
+	}
+	if *jsonFile != "" {
+		if err := writeJSONReport(*jsonFile, stats, reportOpts); err != nil {
+			log.Fatal(i18n.T("main.error.json", err))
+		}
+		fmt.Println(i18n.T("main.json_written", *jsonFile))
+	}
+	utilits.PrintCentered(i18n.T("main.title.statistics"), 120)
+	fmt.Println(processor.SummaryReport(stats, reportOpts)) // Печатаем статистику
+}
+
+// serveMetrics запускает HTTP-сервер с метриками Prometheus на /metrics. Порт занимается сразу,
+// поэтому ошибка в адресе видна до начала обработки
+func serveMetrics(addr string, stats *model.Statistics, queueDepth func() int, logger *slog.Logger) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
 	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", processor.MetricsHandler(stats, processor.MetricsOptions{QueueDepth: queueDepth}))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(i18n.T("main.metrics_stopped"), slog.Any("error", err))
+		}
+	}()
+	logger.Info(i18n.T("main.metrics_listening"), slog.String("url", "http://"+ln.Addr().String()+"/metrics"))
+	return server, nil
+}
+
+// writeJSONReport записывает JSON-отчёт в файл
+func writeJSONReport(path string, stats *model.Statistics, opts processor.ReportOptions) error {
+	return writeReportFile(path, stats, opts, processor.WriteJSONReport)
+}
 
-	fmt.Println("=== 4xx ===")
-	for log := range logs4xx {
-		fmt.Printf("%d %s\n", log.StatusCode, log.URL)
+// writeHTMLReport записывает HTML-отчёт в файл
+func writeHTMLReport(path string, stats *model.Statistics, opts processor.ReportOptions) error {
+	return writeReportFile(path, stats, opts, processor.WriteHTMLReport)
+}
+
+// writeReportFile создаёт файл и записывает в него отчёт функцией write
+func writeReportFile(path string, stats *model.Statistics, opts processor.ReportOptions,
+	write func(io.Writer, *model.Statistics, processor.ReportOptions) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
 	}
+	if err := write(f, stats, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
 
-	fmt.Println("=== 5xx ===")
-	for log := range logs5xx {
-		fmt.Printf("%d %s\n", log.StatusCode, log.URL)
+// followLogs передаёт воркерам новые строки файла, пока не отменён ctx. Раз в секунду сдвигает время
+// детектора аномалий и правил оповещений, чтобы провал трафика был заметен, а оповещения снимались,
+// даже когда строк нет. Пропущенные строки учитываются в stats и пишутся в журнал. При ошибке чтения вызывает stop
+func followLogs(ctx context.Context, stop func(), path string, opts processor.FollowOptions, out chan<- model.LogEntry,
+	stats *model.Statistics, tickers []interface{ Tick() }, logger *slog.Logger) {
+	opts.OnError = func(err error) {
+		processor.RecordParseError(stats)
+		logger.Warn(i18n.T("main.line_skipped"), slog.Any("error", err))
 	}
-	utilits.PrintCentered("Фильтрация окончена!", 120)
+	entries, errs := processor.Follow(ctx, path, opts)
+	fmt.Println(i18n.T("main.follow_started", path))
 
-	// ================================================ Вывод статистики ================================================
-	utilits.PrintCentered("Статистика:", 120)
-	fmt.Println(processor.SummaryStatistics(stats, 5)) // Печатаем статистику
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				if err := <-errs; err != nil {
+					logger.Error(i18n.T("main.follow_stopped"), slog.Any("error", err))
+					stop()
+				}
+				return
+			}
+			select {
+			case out <- entry:
+			case <-ctx.Done():
+				return
+			}
+		case <-ticker.C:
+			for _, t := range tickers {
+				t.Tick()
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
 }
@@ -0,0 +1,403 @@
+package alert
+
+import (
+	"context"           // Для вызова получателей
+	"encoding/json"     // Для разбора тела webhook и файла
+	"fmt"               // Для сборки описания правил
+	"math"              // Для проверки NaN
+	"net/http"          // Для тестового webhook
+	"net/http/httptest" // Для локального HTTP-сервера
+	"os"                // Для чтения файлов получателей
+	"path/filepath"     // Для путей во временном каталоге
+	"strings"           // Для проверки отчёта и чтения описаний
+	"sync"              // Для записи оповещений из горутины доставки
+	"testing"           // Cтандартная библиотека для тестов Go
+	"time"              // Для работы с датой и временем
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+var base = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+// recorder — получатель, который запоминает оповещения
+type recorder struct {
+	mu  sync.Mutex
+	got []Notification
+}
+
+func (r *recorder) Send(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.got = append(r.got, n)
+	return nil
+}
+
+// summary — оповещения в виде «правило состояние чч:мм»
+func (r *recorder) summary() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var lines []string
+	for _, n := range r.got {
+		at := n.StartsAt
+		if n.EndsAt != nil {
+			at = *n.EndsAt
+		}
+		line := fmt.Sprintf("%s %s %s", n.Rule, n.State, at.Format("15:04"))
+		if n.Repeat {
+			line += " repeat"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "; ")
+}
+
+// traffic отправляет n запросов за минуту minute, из них bad с ошибкой 500 и временем ответа 2000 мс
+func traffic(e *Engine, url string, minute, n, bad int) {
+	for i := 0; i < n; i++ {
+		status, latency := 200, 100
+		if i < bad {
+			status, latency = 500, 2000
+		}
+		at := base.Add(time.Duration(minute)*time.Minute + time.Duration(i)*time.Minute/time.Duration(n))
+		e.Observe(model.LogEntry{Timestamp: at, Method: "GET", URL: url, StatusCode: status, ResponseTime: latency})
+	}
+}
+
+func mustParse(t *testing.T, text string) *Config {
+	t.Helper()
+	cfg, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	return cfg
+}
+
+// ================================================ Тесты описания правил ================================================
+
+func TestParseConfig(t *testing.T) {
+	cfg := mustParse(t, `{"rules": [{"name": "errors", "expr": "error_rate", "threshold": 0.05}]}`)
+	r := cfg.Rules[0]
+	if r.Op != ">" || r.Severity != DefaultSeverity || time.Duration(r.Window) != DefaultWindow ||
+		time.Duration(cfg.Interval) != DefaultInterval || cfg.ApdexT != 0 {
+		t.Errorf("Неверно заполнены значения по умолчанию: %+v %+v", cfg, r)
+	}
+
+	for text, want := range map[string]string{
+		`{"rules": []}`: "нет ни одного правила",
+		`{"rules": [{"expr": "errors", "threshold": 1}]}`:                                                             "не задано имя",
+		`{"rules": [{"name": "a", "expr": "errors"}, {"name": "a", "expr": "errors"}]}`:                               "повторяется",
+		`{"rules": [{"name": "a", "expr": "latency > 1"}]}`:                                                           "неизвестная метрика",
+		`{"rules": [{"name": "a", "expr": "errors", "op": "=>"}]}`:                                                    "неизвестный оператор",
+		`{"rules": [{"name": "a", "expr": "errors", "filter": "status >"}]}`:                                          "ошибка в фильтре",
+		`{"rules": [{"name": "a", "expr": "errors", "window": "10s"}]}`:                                               "короче шага проверки",
+		`{"rules": [{"name": "a", "expr": "errors", "fr": "1m"}]}`:                                                    "unknown field",
+		`{"rules": [{"name": "a", "expr": "errors"}], "sinks": [{"type": "webhook", "url": "ftp://host"}]}`:           "нужен адрес http(s)",
+		`{"rules": [{"name": "a", "expr": "errors"}], "sinks": [{"type": "exec"}]}`:                                   "не задана command",
+		`{"rules": [{"name": "a", "expr": "errors"}], "sinks": [{"type": "email", "url": "mailto:ops@example.com"}]}`: "неизвестный тип",
+		`{"rules": [{"name": "a", "expr": "errors"}], "sinks": [{"type": "file"}]}`:                                   "не задан path",
+		`{"rules": [{"name": "a", "expr": "errors", "for": "1w"}]}`:                                                   "неверная длительность",
+		`{"rules": [{"name": "a", "expr": "errors", "min_requests": -1}]}`:                                            "отрицательные",
+		`{"rules": [{"name": "a", "expr": "(errors"}]}`:                                                               "нет закрывающей скобки",
+	} {
+		if _, err := Parse(strings.NewReader(text)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Для %s ожидалась ошибка с %q, получили %v", text, want, err)
+		}
+	}
+
+	if _, err := Load("../testdata/alerts.json"); err != nil {
+		t.Errorf("Пример правил не читается: %v", err)
+	}
+}
+
+func TestExpr(t *testing.T) {
+	metrics := map[string]float64{"errors": 6, "requests": 40, "p95_latency": 900}
+	get := func(name string) float64 { return metrics[name] }
+	for src, want := range map[string]float64{
+		"errors / requests * 100":  15,
+		"p95_latency - 100 * 2":    700,
+		"-(errors - requests) / 2": 17,
+		"(1 + 2) * 3":              9,
+		"0.5":                      0.5,
+	} {
+		e, _, err := parseExpr(src)
+		if err != nil {
+			t.Fatalf("Ошибка разбора %q: %v", src, err)
+		}
+		if got := e.eval(get); got != want {
+			t.Errorf("%s = %v, ожидалось %v", src, got, want)
+		}
+	}
+
+	e, used, _ := parseExpr("errors / (requests - 40)")
+	if got := e.eval(get); !math.IsNaN(got) {
+		t.Errorf("Деление на ноль должно давать NaN, получили %v", got)
+	}
+	if !used["errors"] || !used["requests"] || len(used) != 2 {
+		t.Errorf("Неверный список метрик: %v", used)
+	}
+	for _, src := range []string{"", "errors +", "errors requests", "1..2", "Errors"} {
+		if _, _, err := parseExpr(src); err == nil {
+			t.Errorf("Ожидалась ошибка для %q", src)
+		}
+	}
+}
+
+// ================================================ Тесты состояний правил ================================================
+
+func TestEngineTransitions(t *testing.T) {
+	cfg := mustParse(t, `{"evaluation_interval": "1m", "rules": [
+		{"name": "errors", "expr": "error_rate", "threshold": 0.1, "window": "2m", "for": "2m", "severity": "critical"},
+		{"name": "repeat", "expr": "errors_5xx", "op": ">=", "threshold": 1, "window": "2m", "repeat": "2m"},
+		{"name": "long", "expr": "error_rate", "threshold": 0.1, "window": "2m", "for": "10m"},
+		{"name": "orders", "expr": "p95_latency", "threshold": 500, "window": "2m", "filter": "url ~ \"^/api/orders\""},
+		{"name": "quiet", "expr": "apdex", "op": "<", "threshold": 0.9, "window": "2m", "min_requests": 100}
+	]}`)
+	rec := &recorder{}
+	e := NewEngine(cfg, Options{Sinks: []Sink{rec}})
+
+	for minute := 0; minute < 5; minute++ {
+		traffic(e, "/api/users", minute, 20, 0)
+	}
+	for minute := 5; minute < 10; minute++ { // 5 минут половина запросов с ошибкой
+		traffic(e, "/api/users", minute, 20, 10)
+	}
+	for minute := 10; minute < 15; minute++ {
+		traffic(e, "/api/users", minute, 20, 0)
+	}
+	e.Close()
+
+	// Окно на границе 10:06 — минуты 4 и 5: условие выполняется, но оповещение ждёт For = 2m до 10:08.
+	// На границе 10:12 в окне только минуты без ошибок — оповещение снято. Каждое состояние отправлено один раз
+	want := "repeat firing 10:06; errors firing 10:06; repeat firing 10:06 repeat; repeat firing 10:06 repeat; " +
+		"errors resolved 10:12; repeat resolved 10:12"
+	if got := rec.summary(); got != want {
+		t.Errorf("Неверные оповещения:\nполучили %s\nожидали  %s", got, want)
+	}
+
+	statuses := e.Statuses()
+	if s := statuses[0]; s.State != StateResolved || s.Fired != 1 || !s.ResolvedAt.Equal(base.Add(12*time.Minute)) {
+		t.Errorf("Неверное состояние правила errors: %+v", s)
+	}
+	if s := statuses[2]; s.State != StateInactive || s.Fired != 0 {
+		t.Errorf("Правило long не должно было сработать: %+v", s)
+	}
+	if s := statuses[3]; s.Value != 0 || s.Fired != 0 {
+		t.Errorf("Фильтр правила orders не должен пропускать другие маршруты: %+v", s)
+	}
+	if s := statuses[4]; s.Fired != 0 || !s.Evaluated {
+		t.Errorf("Правило quiet не должно срабатывать при малом числе запросов: %+v", s)
+	}
+
+	report := e.Report(nil)
+	for _, want := range []string{
+		"Оповещения (проверка каждые 1m по времени записей):",
+		"1. errors [critical] error_rate > 0.1 за 2m: снято в 2024-01-15 10:12:00, значение 0; срабатываний: 1",
+		"3. long [warning] error_rate > 0.1 за 2m: в норме, значение 0; срабатываний: 0",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("В отчёте нет %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestEngineTickAndStats(t *testing.T) {
+	cfg := mustParse(t, `{"evaluation_interval": "1m", "rules": [
+		{"name": "no-traffic", "expr": "requests", "op": "<", "threshold": 1, "window": "2m"},
+		{"name": "backlog", "expr": "queue_depth", "threshold": 100, "window": "1m"}
+	]}`)
+	now := base
+	stats := &model.Statistics{QueueDepth: 500}
+	rec := &recorder{}
+	e := NewEngine(cfg, Options{Sinks: []Sink{rec}, Stats: stats, Now: func() time.Time { return now }})
+
+	for minute := 0; minute < 3; minute++ {
+		traffic(e, "/", minute, 10, 0)
+	}
+	// Записей больше нет: по часам прошло 5 минут — окна опустели, и трафик пропал
+	now = now.Add(5 * time.Minute)
+	e.Tick()
+	e.Close()
+
+	if got := rec.summary(); got != "backlog firing 10:01; no-traffic firing 10:05" {
+		t.Errorf("Неверные оповещения: %s", got)
+	}
+}
+
+// Отчёт движка запрашивают под Stats.Mu, а проверка правил читает статистику: движок не должен держать
+// свою блокировку, пока ждёт Stats.Mu
+func TestEngineReportUnderStatsLock(t *testing.T) {
+	cfg := mustParse(t, `{"evaluation_interval": "1m", "rules": [{"name": "backlog", "expr": "queue_depth", "threshold": 100, "window": "1m"}]}`)
+	stats := &model.Statistics{}
+	e := NewEngine(cfg, Options{Stats: stats})
+	defer e.Close()
+	traffic(e, "/", 0, 10, 0)
+
+	stats.Mu.Lock()
+	defer stats.Mu.Unlock()
+	go e.Observe(model.LogEntry{Timestamp: base.Add(5 * time.Minute), URL: "/", StatusCode: 200}) // Переходит границу шага
+	time.Sleep(50 * time.Millisecond)
+
+	reported := make(chan struct{})
+	go func() {
+		defer close(reported)
+		e.Report(nil)
+	}()
+	select {
+	case <-reported:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Отчёт движка ждёт проверку правил, которая ждёт статистику")
+	}
+}
+
+// ================================================ Тесты получателей ================================================
+
+func TestSinks(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []Notification
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Неверный запрос webhook: %s %v", r.Method, r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("Тело webhook не JSON: %v", err)
+		}
+		mu.Lock()
+		received = append(received, n)
+		mu.Unlock()
+	}))
+	defer server.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "недоступен", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	dir := t.TempDir()
+	file, execOut := filepath.Join(dir, "alerts.jsonl"), filepath.Join(dir, "exec.txt")
+	sinks, _ := json.Marshal([]SinkConfig{
+		{Type: "webhook", URL: server.URL, Headers: map[string]string{"Authorization": "Bearer secret"}},
+		{Type: "webhook", URL: failing.URL},
+		{Type: "file", Path: file},
+		{Type: "exec", Command: []string{"sh", "-c", `echo "$ALERT_RULE $ALERT_STATE $ALERT_SEVERITY" >> "$0"; cat > /dev/null`, execOut}},
+	})
+	cfg := mustParse(t, `{"evaluation_interval": "1m", "sinks": `+string(sinks)+`, "rules": [
+		{"name": "errors", "expr": "errors", "threshold": 0, "window": "1m", "severity": "critical", "summary": "Проверьте базу"}
+	]}`)
+	var errs []error
+	e := NewEngine(cfg, Options{OnError: func(err error) { errs = append(errs, err) }})
+	traffic(e, "/", 0, 10, 5)
+	traffic(e, "/", 1, 10, 0)
+	traffic(e, "/", 2, 10, 0)
+	e.Close()
+
+	if len(received) != 2 || received[0].State != StateFiring || received[1].State != StateResolved || received[1].EndsAt == nil {
+		t.Fatalf("Webhook должен получить срабатывание и снятие, получили %+v", received)
+	}
+	if n := received[0]; n.Rule != "errors" || n.Value != 5 || n.Condition != "errors > 0" || n.Window != "1m" ||
+		!strings.Contains(n.Message, "Проверьте базу") || !n.StartsAt.Equal(base.Add(time.Minute)) {
+		t.Errorf("Неверное оповещение: %+v", n)
+	}
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), "503") {
+		t.Errorf("Ожидались две ошибки доставки в недоступный webhook, получили %v", errs)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 || !strings.Contains(lines[1], `"state":"resolved"`) {
+		t.Errorf("Неверное содержимое файла оповещений:\n%s", data)
+	}
+	if data, err := os.ReadFile(execOut); err != nil || string(data) != "errors firing critical\nerrors resolved critical\n" {
+		t.Errorf("Команда получила неверные переменные: %q, %v", data, err)
+	}
+
+	var out strings.Builder
+	(&WriterSink{W: &out}).Send(context.Background(), received[1])
+	if !strings.Contains(out.String(), "ОПОВЕЩЕНИЕ СНЯТО: errors [critical]: условие errors > 0 больше не выполняется") {
+		t.Errorf("Неверный вывод в консоль: %s", out.String())
+	}
+	defer i18n.SetDefault(i18n.Default().Lang())
+	i18n.SetDefault(i18n.EN)
+	out.Reset()
+	(&WriterSink{W: &out}).Send(context.Background(), received[1])
+	if !strings.Contains(out.String(), "] ALERT RESOLVED: errors [critical]") {
+		t.Errorf("Подпись в консоли должна переводиться: %s", out.String())
+	}
+}
+
+// Очередь задаётся функцией: в фиксированном пуле статистика замеряет её только при запуске
+func TestEngineLiveQueueDepth(t *testing.T) {
+	cfg := mustParse(t, `{"evaluation_interval": "1m", "rules": [
+		{"name": "backlog", "expr": "queue_depth", "threshold": 50, "window": "1m"}
+	]}`)
+	queue := make(chan model.LogEntry, 100)
+	stats := &model.Statistics{QueueDepth: 0, ActiveWorkers: 4} // Замер при запуске пула
+	rec := &recorder{}
+	e := NewEngine(cfg, Options{Sinks: []Sink{rec}, Stats: stats, QueueDepth: func() int { return len(queue) }})
+
+	traffic(e, "/", 0, 10, 0)
+	traffic(e, "/", 1, 10, 0)
+	for i := 0; i < 80; i++ { // Воркеры не успевают — очередь растёт
+		queue <- model.LogEntry{}
+	}
+	traffic(e, "/", 2, 10, 0)
+	traffic(e, "/", 3, 10, 0)
+	e.Close()
+
+	if got := rec.summary(); got != "backlog firing 10:01" { // Граница 10:01 проверяется с приходом записей 10:02
+		t.Errorf("Оповещение о росте очереди должно сработать, получили: %q", got)
+	}
+	if s := e.Statuses()[0]; s.Value != 80 {
+		t.Errorf("Ожидалась глубина очереди 80, получили %v", s.Value)
+	}
+}
+
+// Медленный получатель не задерживает воркеры: когда очередь доставки заполнена, оповещения теряются
+func TestEngineSlowSink(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release // Получатель завис
+	}))
+	defer server.Close()
+
+	sinks, _ := json.Marshal([]SinkConfig{{Type: "webhook", URL: server.URL}})
+	cfg := mustParse(t, `{"evaluation_interval": "1m", "sinks": `+string(sinks)+`, "rules": [
+		{"name": "errors", "expr": "errors", "threshold": 0, "window": "1m"}
+	]}`)
+	var mu sync.Mutex
+	var errs []error
+	e := NewEngine(cfg, Options{OnError: func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}})
+	defer e.Close()
+	defer close(release) // Сначала отпускаем получателя, потом дожидаемся доставки
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for minute := 0; minute < 200; minute++ { // Правило срабатывает и снимается каждую минуту
+			traffic(e, "/", minute, 2, minute%2)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Воркеры заблокированы медленным получателем")
+	}
+
+	mu.Lock()
+	dropped := len(errs)
+	mu.Unlock()
+	if dropped < 100 || !strings.Contains(errs[0].Error(), "очередь доставки заполнена") {
+		t.Errorf("Ожидались потерянные оповещения, получили %d: %v", dropped, errs)
+	}
+	if report := e.Report(nil); !strings.Contains(report, fmt.Sprintf("Оповещений потеряно (очередь доставки заполнена): %d", dropped)) {
+		t.Errorf("В отчёте нет потерянных оповещений:\n%s", report)
+	}
+}
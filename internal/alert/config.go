@@ -0,0 +1,189 @@
+// Оповещения: правила над метриками скользящего окна (частота ошибок, задержки, Apdex, запросы в секунду),
+// которые проверяются по мере обработки записей, и доставка оповещений в консоль, файл, команду или webhook.
+
+package alert
+
+import (
+	"encoding/json" // Для разбора файла правил
+	"fmt"           // Для описания условия правила
+	"io"            // Для чтения правил из произвольного источника
+	"net/url"       // Для проверки адреса webhook
+	"os"            // Для открытия файла
+	"time"          // Для значений по умолчанию
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/query"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"
+)
+
+// ================================================ Описание правил ================================================
+
+// Значения по умолчанию
+const (
+	DefaultSeverity = "warning"
+	DefaultWindow   = 5 * time.Minute
+	DefaultInterval = 30 * time.Second
+)
+
+// operators — операторы сравнения значения выражения с порогом
+var operators = map[string]func(v, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// Rule — правило оповещения: условие «Expr Op Threshold» над окном Window, которое должно держаться
+// не меньше For, прежде чем оповещение сработает
+type Rule struct {
+	Name        string           `json:"name"`
+	Expr        string           `json:"expr"`                   // выражение над метриками, например "error_rate * 100"
+	Filter      string           `json:"filter,omitempty"`       // какие записи учитывать, на языке -query (пусто — все)
+	Op          string           `json:"op,omitempty"`           // >, >=, <, <=, ==, != (по умолчанию >)
+	Threshold   float64          `json:"threshold"`              // порог
+	Window      utilits.Duration `json:"window,omitempty"`       // окно метрик (по умолчанию 5m)
+	For         utilits.Duration `json:"for,omitempty"`          // сколько условие должно держаться до срабатывания (0 — сразу)
+	Severity    string           `json:"severity,omitempty"`     // например critical или warning (по умолчанию warning)
+	Summary     string           `json:"summary,omitempty"`      // пояснение для получателя
+	MinRequests int              `json:"min_requests,omitempty"` // при меньшем числе запросов в окне условие не проверяется
+	Repeat      utilits.Duration `json:"repeat,omitempty"`       // повторять оповещение, пока оно горит (0 — не повторять)
+}
+
+// Condition — условие правила в виде текста, например "error_rate > 0.05"
+func (r Rule) Condition() string {
+	return fmt.Sprintf("%s %s %g", r.Expr, r.Op, r.Threshold)
+}
+
+// SinkConfig — куда доставлять оповещения
+type SinkConfig struct {
+	Type    string            `json:"type"`              // stdout, file, exec или webhook
+	Path    string            `json:"path,omitempty"`    // file: файл, куда дописываются оповещения в формате JSON Lines
+	Command []string          `json:"command,omitempty"` // exec: команда и аргументы, оповещение — в stdin и переменных ALERT_*
+	URL     string            `json:"url,omitempty"`     // webhook: адрес для POST-запроса с оповещением в JSON
+	Headers map[string]string `json:"headers,omitempty"` // webhook: дополнительные заголовки, например Authorization
+	Timeout utilits.Duration  `json:"timeout,omitempty"` // exec и webhook: лимит времени на доставку (по умолчанию 10s)
+}
+
+// Config — файл правил оповещений
+type Config struct {
+	Interval utilits.Duration `json:"evaluation_interval,omitempty"` // шаг проверки правил по времени записей (по умолчанию 30s)
+	ApdexT   int              `json:"apdex_t,omitempty"`             // порог Apdex T в мс для метрики apdex (0 — как в общей статистике)
+	Rules    []Rule           `json:"rules"`
+	Sinks    []SinkConfig     `json:"sinks,omitempty"` // по умолчанию — только консоль
+	compiled []compiledRule
+}
+
+// compiledRule — правило с разобранными выражением и фильтром
+type compiledRule struct {
+	expr    expr
+	metrics map[string]bool
+	filter  *query.Query
+	compare func(v, threshold float64) bool
+}
+
+// Load читает правила из файла
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, i18n.Errorf("alert.config.open", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse читает правила в формате JSON, проверяет их и заполняет значения по умолчанию
+func Parse(r io.Reader) (*Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields() // Опечатка в названии поля не должна молча отключать настройку
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, i18n.Errorf("alert.config.read", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// validate проверяет правила, разбирает выражения и заполняет значения по умолчанию
+func (c *Config) validate() error {
+	if len(c.Rules) == 0 {
+		return i18n.Errorf("alert.config.empty")
+	}
+	if c.Interval <= 0 {
+		c.Interval = utilits.Duration(DefaultInterval)
+	}
+	if c.ApdexT < 0 {
+		return i18n.Errorf("alert.config.apdex_t")
+	}
+
+	names := make(map[string]bool)
+	c.compiled = make([]compiledRule, len(c.Rules))
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		switch {
+		case r.Name == "":
+			return i18n.Errorf("alert.config.no_name", i+1)
+		case names[r.Name]:
+			return i18n.Errorf("alert.config.duplicate", r.Name)
+		case r.Window < 0 || r.For < 0 || r.Repeat < 0 || r.MinRequests < 0:
+			return i18n.Errorf("alert.config.negative", r.Name)
+		}
+		names[r.Name] = true
+		if r.Op == "" {
+			r.Op = ">"
+		}
+		if r.Window == 0 {
+			r.Window = utilits.Duration(DefaultWindow)
+		}
+		if r.Window < c.Interval {
+			return i18n.Errorf("alert.config.window", r.Name, time.Duration(r.Window), time.Duration(c.Interval))
+		}
+		if r.Severity == "" {
+			r.Severity = DefaultSeverity
+		}
+
+		compiled := &c.compiled[i]
+		var ok bool
+		if compiled.compare, ok = operators[r.Op]; !ok {
+			return i18n.Errorf("alert.config.op", r.Name, r.Op)
+		}
+		e, metrics, err := parseExpr(r.Expr)
+		if err != nil {
+			return i18n.Errorf("alert.config.expr", r.Name, r.Expr, err)
+		}
+		compiled.expr, compiled.metrics = e, metrics
+		if r.Filter != "" {
+			if compiled.filter, err = query.Compile(r.Filter); err != nil {
+				return i18n.Errorf("alert.config.filter", r.Name, err)
+			}
+		}
+	}
+
+	for i := range c.Sinks {
+		s := &c.Sinks[i]
+		switch s.Type {
+		case "stdout":
+		case "file":
+			if s.Path == "" {
+				return i18n.Errorf("alert.config.file_path", i+1)
+			}
+		case "exec":
+			if len(s.Command) == 0 {
+				return i18n.Errorf("alert.config.exec_command", i+1)
+			}
+		case "webhook":
+			if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return i18n.Errorf("alert.config.webhook_url", i+1, s.URL)
+			}
+		default:
+			return i18n.Errorf("alert.config.sink_type", i+1, s.Type)
+		}
+		if s.Timeout <= 0 {
+			s.Timeout = utilits.Duration(10 * time.Second)
+		}
+	}
+	return nil
+}
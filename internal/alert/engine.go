@@ -0,0 +1,490 @@
+package alert
+
+import (
+	"context" // Для доставки оповещений
+	"math"    // Для проверки NaN
+	"sort"    // Для перцентилей
+	"strings" // Для сборки отчёта
+	"sync"    // Для параллельных вызовов Observe
+	"time"    // Для окон и состояний
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"
+)
+
+// ================================================ Проверка правил ================================================
+
+// Options — дополнительные настройки движка оповещений
+type Options struct {
+	Sinks      []Sink            // получатели в дополнение к описанным в файле правил
+	Stats      *model.Statistics // общая статистика для метрик queue_depth, workers, failed и retries и порог Apdex T
+	QueueDepth func() int        // текущая глубина входной очереди; nil — последний замер пула из статистики
+	OnError    func(error)       // вызывается, если оповещение не удалось доставить или поставить в очередь; может вызываться из разных горутин
+	Now        func() time.Time  // часы для Tick (по умолчанию time.Now)
+}
+
+// bucket — метрики записей одного шага проверки
+type bucket struct {
+	requests, errors, errors4xx, errors5xx int
+	latencySum, latencyMax                 int
+	latencies                              []int // только если правилу нужны перцентили
+	apdex                                  model.ApdexCounter
+}
+
+// merge добавляет метрики другого шага
+func (b *bucket) merge(o *bucket) {
+	b.requests += o.requests
+	b.errors += o.errors
+	b.errors4xx += o.errors4xx
+	b.errors5xx += o.errors5xx
+	b.latencySum += o.latencySum
+	b.latencyMax = max(b.latencyMax, o.latencyMax)
+	b.latencies = append(b.latencies, o.latencies...)
+	b.apdex.Satisfied += o.apdex.Satisfied
+	b.apdex.Tolerating += o.apdex.Tolerating
+	b.apdex.Frustrated += o.apdex.Frustrated
+}
+
+// ruleState — правило и его текущее состояние
+type ruleState struct {
+	rule        Rule
+	compiled    compiledRule
+	percentiles bool                  // выражению нужны перцентили задержки
+	buckets     map[time.Time]*bucket // шаги окна по времени начала
+	state       string                // inactive, pending, firing или resolved
+	since       time.Time             // когда условие начало выполняться
+	resolvedAt  time.Time             // когда горевшее оповещение снято
+	lastSent    time.Time             // когда оповещение отправлено последний раз
+	value       float64               // значение выражения при последней проверке
+	evaluated   bool                  // правило хотя бы раз проверено
+	fired       int                   // сколько раз оповещение срабатывало
+}
+
+// Status — состояние правила для отчёта
+type Status struct {
+	Rule       Rule
+	State      string    // inactive, pending, firing или resolved
+	Since      time.Time // когда условие начало выполняться (pending и firing)
+	ResolvedAt time.Time // когда снято (resolved)
+	Value      float64   // значение выражения при последней проверке
+	Evaluated  bool      // правило хотя бы раз проверено
+	Fired      int       // сколько раз срабатывало
+}
+
+// Engine проверяет правила по мере обработки записей. Время идёт по записям лога: правила проверяются
+// на границах шагов Interval, когда записи ушли за границу ещё на один шаг (так перемешанные воркерами
+// записи успевают попасть в окно). Оповещение отправляется только при смене состояния: сработало
+// (условие держалось For) и снято, — и, если задан Repeat, повторяется, пока горит. Доставка идёт
+// в отдельной горутине через очередь, чтобы медленный получатель не задерживал воркеры: если очередь
+// заполнена, оповещение теряется. Безопасен для вызова из нескольких воркеров
+type Engine struct {
+	cfg      *Config
+	interval time.Duration
+	apdexT   int
+	opts     Options
+	sinks    []Sink
+
+	mu        sync.Mutex
+	rules     []*ruleState
+	next      time.Time // следующая граница проверки
+	watermark time.Time // самое позднее время записи (или сдвинутое Tick)
+	lastSeen  time.Time // когда по часам пришла последняя запись
+	late      int       // записи, пришедшие, когда их шаг уже вышел из всех окон
+	dropped   int       // оповещения, не поместившиеся в очередь доставки
+
+	queue     chan Notification
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewEngine создаёт движок и запускает доставку оповещений. Если получатели не заданы ни в файле,
+// ни в opts, оповещения печатаются в консоль. После обработки нужно вызвать Close
+func NewEngine(cfg *Config, opts Options) *Engine {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	e := &Engine{
+		cfg:      cfg,
+		interval: time.Duration(cfg.Interval),
+		opts:     opts,
+		queue:    make(chan Notification, 64),
+		done:     make(chan struct{}),
+	}
+	switch {
+	case cfg.ApdexT > 0:
+		e.apdexT = cfg.ApdexT
+	case opts.Stats != nil && opts.Stats.ApdexT > 0:
+		e.apdexT = opts.Stats.ApdexT
+	default:
+		e.apdexT = model.DefaultApdexT
+	}
+	for _, s := range cfg.Sinks {
+		e.sinks = append(e.sinks, NewSink(s))
+	}
+	e.sinks = append(e.sinks, opts.Sinks...)
+	if len(e.sinks) == 0 {
+		e.sinks = []Sink{NewSink(SinkConfig{Type: "stdout"})}
+	}
+	for i, r := range cfg.Rules {
+		c := cfg.compiled[i]
+		e.rules = append(e.rules, &ruleState{
+			rule:        r,
+			compiled:    c,
+			percentiles: c.metrics["p50_latency"] || c.metrics["p90_latency"] || c.metrics["p95_latency"] || c.metrics["p99_latency"],
+			buckets:     make(map[time.Time]*bucket),
+			state:       StateInactive,
+		})
+	}
+	go e.deliver()
+	return e
+}
+
+// Observe учитывает запись и проверяет правила, если время записей перешло границу шага
+func (e *Engine) Observe(l model.LogEntry) {
+	if l.Timestamp.IsZero() {
+		return
+	}
+	matched := make([]bool, len(e.rules)) // Фильтры не зависят от состояния — проверяем без блокировки
+	for i, rs := range e.rules {
+		matched[i] = rs.compiled.filter == nil || rs.compiled.filter.Match(l)
+	}
+	start := l.Timestamp.Truncate(e.interval)
+	gauges := e.gauges()
+
+	e.mu.Lock()
+	e.lastSeen = e.opts.Now()
+	if e.next.IsZero() {
+		e.next = start.Add(e.interval)
+	}
+	dropped := false
+	for i, rs := range e.rules {
+		if !matched[i] {
+			continue
+		}
+		if start.Before(e.next.Add(-time.Duration(rs.rule.Window))) { // Шаг уже не попадёт ни в одно окно
+			dropped = true
+			continue
+		}
+		b, ok := rs.buckets[start]
+		if !ok {
+			b = &bucket{}
+			rs.buckets[start] = b
+		}
+		b.requests++
+		switch {
+		case l.StatusCode >= 500:
+			b.errors++
+			b.errors5xx++
+		case l.StatusCode >= 400:
+			b.errors++
+			b.errors4xx++
+		}
+		b.latencySum += l.ResponseTime
+		b.latencyMax = max(b.latencyMax, l.ResponseTime)
+		if rs.percentiles {
+			b.latencies = append(b.latencies, l.ResponseTime)
+		}
+		b.apdex.Add(l, e.apdexT)
+	}
+	if dropped {
+		e.late++
+	}
+	if l.Timestamp.After(e.watermark) {
+		e.watermark = l.Timestamp
+	}
+	found := e.evaluateReady(gauges)
+	e.mu.Unlock()
+
+	e.send(found)
+}
+
+// Tick сдвигает время вперёд на столько, сколько прошло по часам с последней записи. В режиме слежения
+// вызывается периодически, чтобы правила проверялись (и оповещения снимались), даже когда записей нет
+func (e *Engine) Tick() {
+	gauges := e.gauges()
+	e.mu.Lock()
+	var found []Notification
+	if !e.lastSeen.IsZero() {
+		now := e.opts.Now()
+		e.watermark = e.watermark.Add(now.Sub(e.lastSeen))
+		e.lastSeen = now
+		found = e.evaluateReady(gauges)
+	}
+	e.mu.Unlock()
+
+	e.send(found)
+}
+
+// Close проверяет правила на оставшихся границах до последней записи, дожидается доставки всех
+// оповещений и останавливает доставку. Observe и Tick после Close не вызываются
+func (e *Engine) Close() {
+	e.closeOnce.Do(func() {
+		gauges := e.gauges()
+		e.mu.Lock()
+		var found []Notification
+		if !e.next.IsZero() {
+			last := e.watermark.Truncate(e.interval).Add(e.interval) // Граница, закрывающая шаг последней записи
+			for ; !e.next.After(last); e.next = e.next.Add(e.interval) {
+				found = append(found, e.evaluate(e.next, gauges)...)
+			}
+		}
+		e.mu.Unlock()
+
+		for _, n := range found { // Воркеры уже остановлены — здесь можно дождаться места в очереди
+			e.queue <- n
+		}
+		close(e.queue)
+		<-e.done
+	})
+}
+
+// evaluateReady проверяет правила на всех границах, за которые время записей ушло на шаг. Вызывается под e.mu
+func (e *Engine) evaluateReady(gauges map[string]float64) []Notification {
+	var found []Notification
+	for !e.next.IsZero() && !e.watermark.Before(e.next.Add(e.interval)) {
+		found = append(found, e.evaluate(e.next, gauges)...)
+		e.next = e.next.Add(e.interval)
+	}
+	return found
+}
+
+// evaluate проверяет все правила на границе at по окнам, заканчивающимся в at; показатели пула берутся
+// из снимка gauges. Вызывается под e.mu
+func (e *Engine) evaluate(at time.Time, gauges map[string]float64) []Notification {
+	var found []Notification
+	for _, rs := range e.rules {
+		window := time.Duration(rs.rule.Window)
+		from := at.Add(-window)
+		var w bucket
+		for start, b := range rs.buckets {
+			switch {
+			case start.Before(from): // Вышел из окна и в следующие окна не попадёт
+				delete(rs.buckets, start)
+			case start.Before(at):
+				w.merge(b)
+			}
+		}
+		if rs.percentiles {
+			sort.Ints(w.latencies)
+		}
+
+		value := rs.compiled.expr.eval(func(name string) float64 {
+			if v, ok := gauges[name]; ok {
+				return v
+			}
+			return windowMetric(name, &w, window)
+		})
+		holds := w.requests >= rs.rule.MinRequests && !math.IsNaN(value) && rs.compiled.compare(value, rs.rule.Threshold)
+		if n, ok := rs.transition(at, holds, value); ok {
+			found = append(found, n)
+		}
+	}
+	return found
+}
+
+// gauges снимает текущие значения общей статистики. Вызывается до e.mu: отчёт движка (Report) могут
+// запрашивать под Stats.Mu, поэтому брать Stats.Mu под e.mu нельзя
+func (e *Engine) gauges() map[string]float64 {
+	gauges := map[string]float64{"queue_depth": 0, "workers": 0, "failed": 0, "retries": 0}
+	if s := e.opts.Stats; s != nil {
+		s.Mu.Lock()
+		gauges["queue_depth"] = float64(s.QueueDepth)
+		gauges["workers"] = float64(s.ActiveWorkers)
+		gauges["failed"] = float64(s.FailedCount)
+		gauges["retries"] = float64(s.RetryCount)
+		s.Mu.Unlock()
+	}
+	if e.opts.QueueDepth != nil { // Фиксированный пул замеряет очередь только при запуске
+		gauges["queue_depth"] = float64(e.opts.QueueDepth())
+	}
+	return gauges
+}
+
+// windowMetric — значение метрики окна
+func windowMetric(name string, w *bucket, window time.Duration) float64 {
+	ratio := func(n int) float64 {
+		if w.requests == 0 {
+			return 0
+		}
+		return float64(n) / float64(w.requests)
+	}
+	switch name {
+	case "requests":
+		return float64(w.requests)
+	case "errors":
+		return float64(w.errors)
+	case "errors_4xx":
+		return float64(w.errors4xx)
+	case "errors_5xx":
+		return float64(w.errors5xx)
+	case "error_rate":
+		return ratio(w.errors)
+	case "rps":
+		return float64(w.requests) / window.Seconds()
+	case "avg_latency":
+		return ratio(w.latencySum)
+	case "max_latency":
+		return float64(w.latencyMax)
+	case "p50_latency":
+		return utilits.Percentile(w.latencies, 50)
+	case "p90_latency":
+		return utilits.Percentile(w.latencies, 90)
+	case "p95_latency":
+		return utilits.Percentile(w.latencies, 95)
+	case "p99_latency":
+		return utilits.Percentile(w.latencies, 99)
+	case "apdex":
+		return w.apdex.Score()
+	}
+	return math.NaN()
+}
+
+// transition меняет состояние правила по результату проверки и возвращает оповещение, если его нужно отправить
+func (rs *ruleState) transition(at time.Time, holds bool, value float64) (Notification, bool) {
+	rs.value, rs.evaluated = value, true
+	r := rs.rule
+	if holds {
+		if rs.state != StatePending && rs.state != StateFiring {
+			rs.state, rs.since = StatePending, at
+		}
+		switch {
+		case rs.state == StatePending && at.Sub(rs.since) >= time.Duration(r.For):
+			rs.state, rs.lastSent = StateFiring, at
+			rs.fired++
+			return rs.notification(StateFiring, false), true
+		case rs.state == StateFiring && r.Repeat > 0 && at.Sub(rs.lastSent) >= time.Duration(r.Repeat):
+			rs.lastSent = at
+			return rs.notification(StateFiring, true), true
+		}
+		return Notification{}, false
+	}
+
+	switch rs.state {
+	case StatePending: // Условие не продержалось For — оповещения не было
+		rs.state = StateInactive
+	case StateFiring:
+		rs.state, rs.resolvedAt = StateResolved, at
+		return rs.notification(StateResolved, false), true
+	}
+	return Notification{}, false
+}
+
+// notification собирает оповещение о текущем состоянии правила
+func (rs *ruleState) notification(state string, repeat bool) Notification {
+	r := rs.rule
+	window := utilits.FormatDuration(time.Duration(r.Window))
+	n := Notification{
+		Rule:      r.Name,
+		State:     state,
+		Severity:  r.Severity,
+		Condition: r.Condition(),
+		Value:     rs.value,
+		Window:    window,
+		Summary:   r.Summary,
+		StartsAt:  rs.since,
+		Repeat:    repeat,
+	}
+	if state == StateResolved {
+		ends := rs.resolvedAt
+		n.EndsAt = &ends
+		n.Message = i18n.T("alert.message_resolved",
+			r.Name, r.Severity, n.Condition, rs.value, window, rs.since.Format("2006-01-02 15:04:05"), ends.Format("2006-01-02 15:04:05"))
+		return n
+	}
+	n.Message = i18n.T("alert.message", r.Name, r.Severity, n.Condition, rs.value, window, rs.since.Format("2006-01-02 15:04:05"))
+	if repeat {
+		n.Message += i18n.T("alert.repeat")
+	}
+	if r.Summary != "" {
+		n.Message += ". " + r.Summary
+	}
+	return n
+}
+
+// ================================================ Доставка ================================================
+
+// send ставит оповещения в очередь доставки, не дожидаясь места в ней: send вызывают воркеры,
+// и медленный получатель не должен останавливать обработку. Не поместившееся оповещение теряется —
+// об этом сообщается в OnError и в отчёте. Вызывается без e.mu
+func (e *Engine) send(found []Notification) {
+	for _, n := range found {
+		select {
+		case e.queue <- n:
+			continue
+		default:
+		}
+		e.mu.Lock()
+		e.dropped++
+		e.mu.Unlock()
+		if e.opts.OnError != nil {
+			e.opts.OnError(i18n.Errorf("alert.queue_full", n.Rule, n.State))
+		}
+	}
+}
+
+// deliver отправляет оповещения всем получателям по очереди
+func (e *Engine) deliver() {
+	defer close(e.done)
+	for n := range e.queue {
+		for _, s := range e.sinks {
+			if err := s.Send(context.Background(), n); err != nil && e.opts.OnError != nil {
+				e.opts.OnError(i18n.Errorf("alert.not_delivered", n.Rule, err))
+			}
+		}
+	}
+}
+
+// ================================================ Отчёт ================================================
+
+// Statuses возвращает состояние правил в порядке описания
+func (e *Engine) Statuses() []Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	statuses := make([]Status, len(e.rules))
+	for i, rs := range e.rules {
+		statuses[i] = Status{
+			Rule:       rs.rule,
+			State:      rs.state,
+			Since:      rs.since,
+			ResolvedAt: rs.resolvedAt,
+			Value:      rs.value,
+			Evaluated:  rs.evaluated,
+			Fired:      rs.fired,
+		}
+	}
+	return statuses
+}
+
+// Report формирует раздел отчёта о правилах оповещений на языке p (nil — язык по умолчанию)
+func (e *Engine) Report(p *i18n.Printer) string {
+	var sb strings.Builder
+	sb.WriteString(p.Sprintf("alert.title", utilits.FormatDuration(e.interval)) + "\n")
+	for i, s := range e.Statuses() {
+		r := s.Rule
+		sb.WriteString(p.Sprintf("alert.rule", i+1, r.Name, r.Severity, r.Condition(), utilits.FormatDuration(time.Duration(r.Window))))
+		switch s.State {
+		case StatePending:
+			sb.WriteString(p.Sprintf("alert.pending", s.Since.Format("2006-01-02 15:04:05"), utilits.FormatDuration(time.Duration(r.For))))
+		case StateFiring:
+			sb.WriteString(p.Sprintf("alert.firing", s.Since.Format("2006-01-02 15:04:05")))
+		case StateResolved:
+			sb.WriteString(p.Sprintf("alert.resolved", s.ResolvedAt.Format("2006-01-02 15:04:05")))
+		default:
+			sb.WriteString(p.Sprintf("alert.ok"))
+		}
+		if s.Evaluated {
+			sb.WriteString(p.Sprintf("alert.value", s.Value))
+		}
+		sb.WriteString(p.Sprintf("alert.fired", s.Fired) + "\n")
+	}
+	e.mu.Lock()
+	if e.late > 0 {
+		sb.WriteString(p.Sprintf("alert.late", e.late) + "\n")
+	}
+	if e.dropped > 0 {
+		sb.WriteString(p.Sprintf("alert.dropped", e.dropped) + "\n")
+	}
+	e.mu.Unlock()
+	return sb.String()
+}
@@ -0,0 +1,180 @@
+package alert
+
+import (
+	"math"    // Для NaN при делении на ноль
+	"sort"    // Для списка метрик в сообщении об ошибке
+	"strconv" // Для чисел в выражении
+	"strings" // Для сборки списка метрик
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+)
+
+// ================================================ Выражения над метриками ================================================
+
+// Метрики окна, доступные в выражениях. Задержки — в миллисекундах, error_rate и apdex — доли от 0 до 1
+var windowMetrics = map[string]bool{
+	"requests": true, "errors": true, "errors_4xx": true, "errors_5xx": true, "error_rate": true, "rps": true,
+	"avg_latency": true, "max_latency": true, "p50_latency": true, "p90_latency": true, "p95_latency": true, "p99_latency": true,
+	"apdex": true,
+}
+
+// Текущие значения общей статистики: не зависят от окна и фильтра правила
+var gaugeMetrics = map[string]bool{
+	"queue_depth": true, "workers": true, "failed": true, "retries": true,
+}
+
+// expr — разобранное арифметическое выражение
+type expr interface {
+	eval(get func(name string) float64) float64
+}
+
+type numberExpr float64
+type metricExpr string
+type negExpr struct{ operand expr }
+type binaryExpr struct {
+	op          byte
+	left, right expr
+}
+
+func (e numberExpr) eval(func(string) float64) float64     { return float64(e) }
+func (e metricExpr) eval(get func(string) float64) float64 { return get(string(e)) }
+func (e negExpr) eval(get func(string) float64) float64    { return -e.operand.eval(get) }
+
+func (e binaryExpr) eval(get func(string) float64) float64 {
+	l, r := e.left.eval(get), e.right.eval(get)
+	switch e.op {
+	case '+':
+		return l + r
+	case '-':
+		return l - r
+	case '*':
+		return l * r
+	}
+	if r == 0 { // Делить не на что — значения нет, условие не выполняется
+		return math.NaN()
+	}
+	return l / r
+}
+
+// exprParser — разбор выражения рекурсивным спуском:
+//
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/") unary }
+//	unary   = "-" unary | number | metric | "(" sum ")"
+type exprParser struct {
+	src     string
+	pos     int
+	metrics map[string]bool // встреченные метрики
+}
+
+// parseExpr разбирает выражение и возвращает его вместе со списком использованных метрик
+func parseExpr(src string) (expr, map[string]bool, error) {
+	p := &exprParser{src: src, metrics: make(map[string]bool)}
+	e, err := p.sum()
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.skipSpaces(); p.pos < len(p.src) {
+		return nil, nil, i18n.Errorf("alert.expr.extra", p.src[p.pos], p.pos+1)
+	}
+	return e, p.metrics, nil
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// peek возвращает следующий значимый символ (0 — конец выражения)
+func (p *exprParser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *exprParser) sum() (expr, error) {
+	left, err := p.product()
+	for err == nil && (p.peek() == '+' || p.peek() == '-') {
+		op := p.src[p.pos]
+		p.pos++
+		var right expr
+		if right, err = p.product(); err == nil {
+			left = binaryExpr{op: op, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *exprParser) product() (expr, error) {
+	left, err := p.unary()
+	for err == nil && (p.peek() == '*' || p.peek() == '/') {
+		op := p.src[p.pos]
+		p.pos++
+		var right expr
+		if right, err = p.unary(); err == nil {
+			left = binaryExpr{op: op, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *exprParser) unary() (expr, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, i18n.Errorf("alert.expr.truncated")
+	case c == '-':
+		p.pos++
+		operand, err := p.unary()
+		return negExpr{operand}, err
+	case c == '(':
+		p.pos++
+		e, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, i18n.Errorf("alert.expr.unclosed_paren", p.pos+1)
+		}
+		p.pos++
+		return e, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, i18n.Errorf("alert.expr.bad_number", p.src[start:p.pos])
+		}
+		return numberExpr(n), nil
+	case c >= 'a' && c <= 'z' || c == '_':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= 'a' && p.src[p.pos] <= 'z' || p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '_') {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if !windowMetrics[name] && !gaugeMetrics[name] {
+			return nil, i18n.Errorf("alert.expr.unknown_metric", name, metricNames())
+		}
+		p.metrics[name] = true
+		return metricExpr(name), nil
+	}
+	return nil, i18n.Errorf("alert.expr.unexpected", c, p.pos+1)
+}
+
+// metricNames перечисляет доступные метрики для сообщения об ошибке
+func metricNames() string {
+	var names []string
+	for name := range windowMetrics {
+		names = append(names, name)
+	}
+	for name := range gaugeMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,194 @@
+package alert
+
+import (
+	"bytes"         // Для тела запроса и вывода команды
+	"context"       // Для лимита времени доставки
+	"encoding/json" // Для оповещения в JSON
+	"fmt"           // Для форматирования сообщений
+	"io"            // Для консольного получателя
+	"net/http"      // Для webhook
+	"os"            // Для файла и окружения команды
+	"os/exec"       // Для запуска команды
+	"strconv"       // Для значения в переменной окружения
+	"sync"          // Для последовательной записи в файл и консоль
+	"time"          // Для времени в сообщении
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+)
+
+// ================================================ Оповещение ================================================
+
+// Состояния правила
+const (
+	StateInactive = "inactive" // условие не выполняется
+	StatePending  = "pending"  // условие выполняется, но меньше For
+	StateFiring   = "firing"   // оповещение горит
+	StateResolved = "resolved" // горевшее оповещение снято
+)
+
+// Notification — оповещение о срабатывании или снятии правила. Время — по записям лога
+type Notification struct {
+	Rule      string     `json:"rule"`
+	State     string     `json:"state"` // firing или resolved
+	Severity  string     `json:"severity"`
+	Condition string     `json:"condition"` // например "error_rate > 0.05"
+	Value     float64    `json:"value"`     // значение выражения при последней проверке
+	Window    string     `json:"window"`
+	Summary   string     `json:"summary,omitempty"`
+	StartsAt  time.Time  `json:"starts_at"`         // когда условие начало выполняться
+	EndsAt    *time.Time `json:"ends_at,omitempty"` // когда снято (только для resolved)
+	Repeat    bool       `json:"repeat,omitempty"`  // повтор горящего оповещения
+	Message   string     `json:"message"`
+}
+
+// Sink — получатель оповещений
+type Sink interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// NewSink создаёт получателя по описанию из файла правил
+func NewSink(cfg SinkConfig) Sink {
+	timeout := time.Duration(cfg.Timeout)
+	switch cfg.Type {
+	case "file":
+		return &FileSink{Path: cfg.Path}
+	case "exec":
+		return &ExecSink{Command: cfg.Command, Timeout: timeout}
+	case "webhook":
+		return &WebhookSink{URL: cfg.URL, Headers: cfg.Headers, Timeout: timeout}
+	}
+	return &WriterSink{W: os.Stdout}
+}
+
+// encode кодирует оповещение в JSON с переводом строки в конце, не экранируя <, > и &: условия правил читаемы как есть
+func encode(n Notification) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ================================================ Получатели ================================================
+
+// WriterSink печатает оповещение строкой, например в консоль
+type WriterSink struct {
+	W  io.Writer
+	mu sync.Mutex
+}
+
+// Send печатает оповещение
+func (s *WriterSink) Send(ctx context.Context, n Notification) error {
+	label := i18n.T("alert.sink.firing")
+	if n.State == StateResolved {
+		label = i18n.T("alert.sink.resolved")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintf(s.W, "[%s] %s: %s\n", time.Now().Format("2006-01-02 15:04:05"), label, n.Message)
+	return err
+}
+
+// FileSink дописывает оповещения в файл по одному JSON на строку. Файл открывается на каждое оповещение,
+// поэтому его можно ротировать, не останавливая программу
+type FileSink struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Send дописывает оповещение в файл
+func (s *FileSink) Send(ctx context.Context, n Notification) error {
+	data, err := encode(n)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ExecSink запускает команду на каждое оповещение. Оповещение в JSON передаётся в stdin, основные поля —
+// в переменных окружения ALERT_RULE, ALERT_STATE, ALERT_SEVERITY, ALERT_VALUE и ALERT_MESSAGE
+type ExecSink struct {
+	Command []string
+	Timeout time.Duration // 0 — без ограничения
+}
+
+// Send запускает команду и ждёт её завершения
+func (s *ExecSink) Send(ctx context.Context, n Notification) error {
+	data, err := encode(n)
+	if err != nil {
+		return err
+	}
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, s.Command[0], s.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"ALERT_RULE="+n.Rule,
+		"ALERT_STATE="+n.State,
+		"ALERT_SEVERITY="+n.Severity,
+		"ALERT_VALUE="+strconv.FormatFloat(n.Value, 'g', -1, 64),
+		"ALERT_MESSAGE="+n.Message,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return i18n.Errorf("alert.sink.exec_failed", s.Command[0], err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// WebhookSink отправляет оповещение POST-запросом с телом в JSON
+type WebhookSink struct {
+	URL     string
+	Headers map[string]string
+	Timeout time.Duration // 0 — без ограничения
+	Client  *http.Client  // nil — http.DefaultClient
+}
+
+// Send отправляет оповещение. Ответ со статусом не из 2xx считается ошибкой
+func (s *WebhookSink) Send(ctx context.Context, n Notification) error {
+	data, err := encode(n)
+	if err != nil {
+		return err
+	}
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) // Дочитываем тело, чтобы соединение вернулось в пул
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return i18n.Errorf("alert.sink.webhook_status", s.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,416 @@
+package detect
+
+import (
+	"fmt"     // Для форматирования отчёта
+	"math"    // Для модуля отклонения
+	"sort"    // Для медианы и порядка интервалов
+	"strings" // Для сборки отчёта
+	"sync"    // Для параллельных вызовов Observe
+	"time"    // Для интервалов
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"
+)
+
+// ================================================ Аномалии трафика ================================================
+
+// Виды аномалий
+const (
+	AnomalySpike      = "spike"       // всплеск запросов
+	AnomalyDrop       = "drop"        // провал запросов
+	AnomalyErrorSurge = "error_surge" // рост доли ошибок
+)
+
+// AnomalyConfig — настройки детектора аномалий. Нулевые поля заменяются значениями по умолчанию
+type AnomalyConfig struct {
+	Bucket      time.Duration    // длина интервала (по умолчанию 1 минута)
+	Baseline    int              // сколько прошлых интервалов составляют норму (по умолчанию 30)
+	MinHistory  int              // сколько интервалов нужно накопить, прежде чем искать аномалии (по умолчанию 5)
+	Threshold   float64          // порог отклонения от нормы в робастных z-оценках (по умолчанию 3.5)
+	MinRequests int              // интервалы с меньшим числом запросов не считаются всплеском или ростом ошибок (по умолчанию 10)
+	Lateness    time.Duration    // сколько ждать опоздавшие записи, прежде чем закрыть интервал (по умолчанию равно Bucket)
+	OnAnomaly   func(Anomaly)    // вызывается для каждой найденной аномалии
+	Now         func() time.Time // часы для Tick (по умолчанию time.Now)
+}
+
+// withDefaults заполняет нулевые поля
+func (c AnomalyConfig) withDefaults() AnomalyConfig {
+	if c.Bucket <= 0 {
+		c.Bucket = time.Minute
+	}
+	if c.Baseline <= 0 {
+		c.Baseline = 30
+	}
+	if c.MinHistory <= 0 {
+		c.MinHistory = 5
+	}
+	if c.MinHistory > c.Baseline {
+		c.MinHistory = c.Baseline
+	}
+	if c.Threshold <= 0 {
+		c.Threshold = 3.5
+	}
+	if c.MinRequests <= 0 {
+		c.MinRequests = 10
+	}
+	if c.Lateness <= 0 {
+		c.Lateness = c.Bucket
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	return c
+}
+
+// Anomaly — интервал, в котором метрика сильно отклонилась от нормы
+type Anomaly struct {
+	Time     time.Time // начало интервала
+	Route    string    // маршрут (пусто — весь трафик)
+	Kind     string    // spike, drop или error_surge
+	Value    float64   // значение в интервале: запросы или доля ошибок
+	Baseline float64   // норма — медиана прошлых интервалов
+	Score    float64   // отклонение в робастных z-оценках
+	Message  string    // описание для человека на языке по умолчанию
+}
+
+// Describe описывает аномалию на языке p (nil — язык по умолчанию)
+func (a Anomaly) Describe(p *i18n.Printer) string {
+	where := p.Sprintf("anomaly.all_traffic")
+	if a.Route != "" {
+		where = a.Route
+	}
+	var what string
+	switch a.Kind {
+	case AnomalySpike:
+		what = p.Sprintf("anomaly.spike", a.Value, a.Baseline)
+	case AnomalyDrop:
+		what = p.Sprintf("anomaly.drop", a.Value, a.Baseline)
+	case AnomalyErrorSurge:
+		what = p.Sprintf("anomaly.error_surge", 100*a.Value, 100*a.Baseline)
+	}
+	return p.Sprintf("anomaly.message", where, what, a.Score)
+}
+
+// bucketCounts — счётчики одного интервала
+type bucketCounts struct {
+	requests int
+	errors   int
+}
+
+// history — прошлые интервалы одного маршрута
+type history struct {
+	requests []float64 // запросов в интервале
+	rates    []float64 // доля ошибок (только интервалы с запросами)
+}
+
+// idle — в последних baseline интервалах маршрута не было ни одного запроса
+func (h *history) idle(baseline int) bool {
+	if len(h.requests) < baseline {
+		return false
+	}
+	for _, n := range h.requests {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// AnomalyDetector ищет всплески и провалы трафика и рост доли ошибок — для всего трафика и для каждого маршрута.
+// Норма — медиана последних интервалов, разброс — медианное абсолютное отклонение (MAD), поэтому прошлые
+// всплески почти не сдвигают норму. Интервал закрывается, когда время записей ушло вперёд на Bucket+Lateness,
+// или при Flush. Безопасен для вызова из нескольких воркеров
+type AnomalyDetector struct {
+	cfg AnomalyConfig
+
+	mu        sync.Mutex
+	open      map[time.Time]map[string]*bucketCounts // незакрытые интервалы по маршрутам
+	routes    map[string]*history                    // история маршрутов, получавших запросы за последние Baseline интервалов
+	closed    time.Time                              // начало последнего закрытого интервала
+	watermark time.Time                              // самое позднее время записи (или сдвинутое Tick)
+	lastSeen  time.Time                              // когда по часам пришла последняя запись
+	late      int                                    // записи, пришедшие после закрытия их интервала
+	anomalies []Anomaly
+}
+
+// NewAnomalyDetector создаёт детектор
+func NewAnomalyDetector(cfg AnomalyConfig) *AnomalyDetector {
+	return &AnomalyDetector{
+		cfg:    cfg.withDefaults(),
+		open:   make(map[time.Time]map[string]*bucketCounts),
+		routes: make(map[string]*history),
+	}
+}
+
+// Observe учитывает запись
+func (d *AnomalyDetector) Observe(l model.LogEntry) {
+	start := l.Timestamp.Truncate(d.cfg.Bucket)
+	route := utilits.NormalizeRoute(l.URL)
+
+	d.mu.Lock()
+	d.lastSeen = d.cfg.Now()
+	if !d.closed.IsZero() && !start.After(d.closed) { // Интервал уже закрыт и оценён
+		d.late++
+		d.mu.Unlock()
+		return
+	}
+	bucket, ok := d.open[start]
+	if !ok {
+		bucket = make(map[string]*bucketCounts)
+		d.open[start] = bucket
+	}
+	for _, key := range []string{"", route} {
+		c, ok := bucket[key]
+		if !ok {
+			c = &bucketCounts{}
+			bucket[key] = c
+		}
+		c.requests++
+		if l.StatusCode >= 400 {
+			c.errors++
+		}
+	}
+	if l.Timestamp.After(d.watermark) {
+		d.watermark = l.Timestamp
+	}
+	found := d.closeReady()
+	d.mu.Unlock()
+
+	d.notify(found)
+}
+
+// Tick сдвигает время вперёд на столько, сколько прошло по часам с последней записи. В режиме слежения
+// вызывается периодически, чтобы интервалы закрывались (и провалы находились), даже когда записей нет
+func (d *AnomalyDetector) Tick() {
+	d.mu.Lock()
+	var found []Anomaly
+	if !d.lastSeen.IsZero() {
+		now := d.cfg.Now()
+		d.watermark = d.watermark.Add(now.Sub(d.lastSeen))
+		d.lastSeen = now
+		found = d.closeReady()
+	}
+	d.mu.Unlock()
+
+	d.notify(found)
+}
+
+// Flush закрывает все интервалы. Вызывается, когда записей больше не будет
+func (d *AnomalyDetector) Flush() {
+	d.mu.Lock()
+	var found []Anomaly
+	if len(d.open) > 0 {
+		last := d.closed
+		for start := range d.open {
+			if start.After(last) {
+				last = start
+			}
+		}
+		found = d.closeUntil(last)
+	}
+	d.mu.Unlock()
+
+	d.notify(found)
+}
+
+// notify вызывает обработчик без блокировки: он может быть медленным
+func (d *AnomalyDetector) notify(found []Anomaly) {
+	if d.cfg.OnAnomaly != nil {
+		for _, a := range found {
+			d.cfg.OnAnomaly(a)
+		}
+	}
+}
+
+// closeReady закрывает интервалы, которые уже не получат записей: прошедшие целиком, с запасом Lateness. Вызывается под d.mu
+func (d *AnomalyDetector) closeReady() []Anomaly {
+	ready := d.watermark.Add(-d.cfg.Bucket - d.cfg.Lateness).Truncate(d.cfg.Bucket) // Последний интервал, который можно закрыть
+	if d.closed.IsZero() {
+		for start := range d.open {
+			if !start.After(ready) {
+				return d.closeUntil(ready)
+			}
+		}
+		return nil
+	}
+	if !ready.After(d.closed) {
+		return nil
+	}
+	return d.closeUntil(ready)
+}
+
+// closeUntil закрывает все интервалы до last включительно, заполняя пропуски нулями. Вызывается под d.mu
+func (d *AnomalyDetector) closeUntil(last time.Time) []Anomaly {
+	var first time.Time // самый ранний незакрытый интервал с записями
+	for t := range d.open {
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+	}
+	start := d.closed.Add(d.cfg.Bucket)
+	if d.closed.IsZero() {
+		start = first
+	}
+	// Долгий перерыв без записей: норму определяют только последние Baseline интервалов
+	if skip := last.Add(-time.Duration(d.cfg.Baseline) * d.cfg.Bucket); skip.After(start) {
+		start = skip
+		if !first.IsZero() && first.Before(start) {
+			start = first
+		}
+	}
+
+	var found []Anomaly
+	for t := start; !t.After(last); t = t.Add(d.cfg.Bucket) {
+		bucket := d.open[t]
+		for route := range bucket {
+			if _, ok := d.routes[route]; !ok {
+				d.routes[route] = &history{}
+			}
+		}
+		for route, h := range d.routes {
+			var c bucketCounts
+			if b, ok := bucket[route]; ok {
+				c = *b
+			}
+			found = append(found, d.evaluate(h, route, t, c)...)
+			// Маршрут без запросов за всю норму больше ничего не найдёт: в режиме слежения иначе память
+			// и время закрытия интервала росли бы с каждым новым путём, например от сканеров
+			if route != "" && h.idle(d.cfg.Baseline) {
+				delete(d.routes, route)
+			}
+		}
+		d.closed = t
+	}
+	for t := range d.open {
+		if !t.After(last) {
+			delete(d.open, t)
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { // Маршруты перебираются в случайном порядке
+		if !found[i].Time.Equal(found[j].Time) {
+			return found[i].Time.Before(found[j].Time)
+		}
+		return found[i].Route < found[j].Route
+	})
+	d.anomalies = append(d.anomalies, found...)
+	return found
+}
+
+// evaluate сравнивает интервал с нормой и добавляет его в историю маршрута. Вызывается под d.mu
+func (d *AnomalyDetector) evaluate(h *history, route string, t time.Time, c bucketCounts) []Anomaly {
+	var found []Anomaly
+	requests := float64(c.requests)
+
+	if len(h.requests) >= d.cfg.MinHistory {
+		baseline, score := robustScore(h.requests, requests, math.Max(1, median(h.requests)*0.1))
+		switch {
+		case score >= d.cfg.Threshold && c.requests >= d.cfg.MinRequests:
+			found = append(found, d.anomaly(t, route, AnomalySpike, requests, baseline, score))
+		case score <= -d.cfg.Threshold && baseline >= float64(d.cfg.MinRequests):
+			found = append(found, d.anomaly(t, route, AnomalyDrop, requests, baseline, score))
+		}
+	}
+	h.requests = appendLimited(h.requests, requests, d.cfg.Baseline)
+
+	if c.requests > 0 {
+		rate := float64(c.errors) / requests
+		if len(h.rates) >= d.cfg.MinHistory && c.requests >= d.cfg.MinRequests {
+			baseline, score := robustScore(h.rates, rate, 0.05)
+			if score >= d.cfg.Threshold {
+				found = append(found, d.anomaly(t, route, AnomalyErrorSurge, rate, baseline, score))
+			}
+		}
+		h.rates = appendLimited(h.rates, rate, d.cfg.Baseline)
+	}
+	return found
+}
+
+// anomaly собирает описание аномалии
+func (d *AnomalyDetector) anomaly(t time.Time, route, kind string, value, baseline, score float64) Anomaly {
+	a := Anomaly{Time: t, Route: route, Kind: kind, Value: value, Baseline: baseline, Score: score}
+	a.Message = a.Describe(nil)
+	return a
+}
+
+// Anomalies возвращает найденные аномалии в порядке времени
+func (d *AnomalyDetector) Anomalies() []Anomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Anomaly(nil), d.anomalies...)
+}
+
+// Report форматирует хронологию аномалий на языке p (nil — язык по умолчанию);
+// limit > 0 оставляет только последние limit строк
+func (d *AnomalyDetector) Report(p *i18n.Printer, limit int) string {
+	anomalies := d.Anomalies()
+	d.mu.Lock()
+	late := d.late
+	d.mu.Unlock()
+
+	var sb strings.Builder
+	if len(anomalies) == 0 {
+		sb.WriteString(p.Sprintf("anomaly.none") + "\n")
+	} else {
+		sb.WriteString(p.Sprintf("anomaly.title", d.cfg.Bucket, d.cfg.Threshold) + "\n")
+		if limit > 0 && len(anomalies) > limit {
+			sb.WriteString(p.Sprintf("anomaly.earlier", len(anomalies)-limit) + "\n")
+			anomalies = anomalies[len(anomalies)-limit:]
+		}
+		for _, a := range anomalies {
+			fmt.Fprintf(&sb, "  %s %s\n", a.Time.Format("2006-01-02 15:04"), a.Describe(p))
+		}
+	}
+	if late > 0 {
+		sb.WriteString(p.Sprintf("anomaly.late", late) + "\n")
+	}
+	return sb.String()
+}
+
+// ================================================ Робастная статистика ================================================
+
+// robustScore возвращает медиану истории и отклонение value от неё в робастных z-оценках:
+// (value − медиана) / (1.4826 × MAD). Если MAD равно нулю (история почти постоянна),
+// берётся среднее абсолютное отклонение, а если и оно ноль — minScale
+func robustScore(values []float64, value, minScale float64) (float64, float64) {
+	m := median(values)
+	deviations := make([]float64, len(values))
+	sum := 0.0
+	for i, v := range values {
+		deviations[i] = math.Abs(v - m)
+		sum += deviations[i]
+	}
+
+	scale := 1.4826 * median(deviations)
+	if scale == 0 {
+		scale = 1.2533 * sum / float64(len(values))
+	}
+	if scale < minScale {
+		scale = minScale
+	}
+	return m, (value - m) / scale
+}
+
+// median — медиана среза (исходный срез не меняется)
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// appendLimited добавляет значение, оставляя не больше limit последних
+func appendLimited(values []float64, v float64, limit int) []float64 {
+	values = append(values, v)
+	if len(values) > limit {
+		values = append(values[:0], values[len(values)-limit:]...)
+	}
+	return values
+}
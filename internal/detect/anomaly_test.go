@@ -0,0 +1,166 @@
+package detect
+
+import (
+	"fmt"     // Для генерации маршрутов
+	"strings" // Для проверки отчёта
+	"testing" // Cтандартная библиотека для тестов Go
+	"time"    // Для работы с датой и временем
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// traffic отправляет в детектор n запросов за минуту minute, из них errors с ошибкой
+func traffic(d *AnomalyDetector, url string, minute, n, errors int) {
+	for i := 0; i < n; i++ {
+		status := 200
+		if i < errors {
+			status = 500
+		}
+		at := base.Add(time.Duration(minute)*time.Minute + time.Duration(i)*time.Minute/time.Duration(n))
+		d.Observe(model.LogEntry{Timestamp: at, IP: "10.0.0.1", Method: "GET", URL: url, StatusCode: status})
+	}
+}
+
+// ================================================ Тесты робастной статистики ================================================
+
+func TestRobustScore(t *testing.T) {
+	baseline, score := robustScore([]float64{10, 12, 11, 9, 10, 100}, 50, 1) // Выброс 100 почти не влияет на норму
+	if baseline != 10.5 || score < 10 {
+		t.Errorf("Ожидалась норма 10.5 и большое отклонение, получили %.2f и %.2f", baseline, score)
+	}
+
+	if _, score := robustScore([]float64{20, 20, 20, 20}, 21, 2); score != 0.5 { // Постоянная история: масштаб не меньше minScale
+		t.Errorf("Ожидалось отклонение 0.5, получили %.2f", score)
+	}
+}
+
+// ================================================ Тесты детектора аномалий ================================================
+
+func TestAnomalySpikeDropAndErrors(t *testing.T) {
+	var live []Anomaly
+	d := NewAnomalyDetector(AnomalyConfig{OnAnomaly: func(a Anomaly) { live = append(live, a) }})
+
+	for minute := 0; minute < 10; minute++ { // Ровный трафик: 20–22 запроса в минуту, 1 ошибка
+		traffic(d, "/api/users/1", minute, 20+minute%3, 1)
+	}
+	traffic(d, "/api/users/2", 10, 100, 1) // Всплеск
+	traffic(d, "/api/users/3", 11, 2, 0)   // Провал
+	traffic(d, "/api/users/4", 12, 20, 15) // Рост ошибок
+	traffic(d, "/api/users/5", 13, 21, 1)  // Снова норма
+	traffic(d, "/api/users/7", 14, 20, 1)
+	traffic(d, "/api/orders", 14, 3, 3)                             // Новый маршрут: мало запросов и нет истории — не аномалия
+	traffic(d, "/api/users/6", 15, 21, 1)                           // Закрывает интервал 13
+	d.Observe(model.LogEntry{Timestamp: base, URL: "/api/users/1"}) // Опоздавшая запись
+	d.Flush()
+
+	anomalies := d.Anomalies()
+	var got []string
+	for _, a := range anomalies {
+		got = append(got, fmt.Sprintf("%s %s %s", a.Time.Format("15:04"), a.Route, a.Kind))
+	}
+	want := []string{
+		"10:40  spike", "10:40 /api/users/:id spike",
+		"10:41  drop", "10:41 /api/users/:id drop",
+		"10:42  error_surge", "10:42 /api/users/:id error_surge",
+	}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Fatalf("Неверные аномалии:\nполучили %q\nожидали  %q", got, want)
+	}
+	if len(live) != len(anomalies) {
+		t.Errorf("Обработчик получил %d аномалий из %d", len(live), len(anomalies))
+	}
+	if a := anomalies[0]; a.Value != 100 || a.Baseline != 21 {
+		t.Errorf("Неверные значения всплеска: %+v", a)
+	}
+
+	report := d.Report(nil, 0)
+	for _, want := range []string{
+		"Аномалии трафика (интервал 1\u00a0мин, порог 3,5):",
+		"2024-01-15 10:40 весь трафик — всплеск запросов: 100 при норме 21",
+		"2024-01-15 10:42 /api/users/:id — рост ошибок: 75% при норме 5%",
+		"Записей, опоздавших к закрытию интервала: 1",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("В отчёте нет %q:\n%s", want, report)
+		}
+	}
+	if report := d.Report(nil, 2); !strings.Contains(report, "… ещё 4 ранее") {
+		t.Errorf("Отчёт не сокращён:\n%s", report)
+	}
+}
+
+func TestAnomalyOutOfOrderAndGaps(t *testing.T) {
+	d := NewAnomalyDetector(AnomalyConfig{Baseline: 10})
+
+	// Записи двух соседних минут перемешаны, как после нескольких воркеров: интервал ждёт опоздавших
+	for minute := 0; minute < 8; minute += 2 {
+		for i := 0; i < 20; i++ {
+			for _, m := range []int{minute, minute + 1} {
+				d.Observe(model.LogEntry{Timestamp: base.Add(time.Duration(m)*time.Minute + time.Duration(i)*time.Second), URL: "/"})
+			}
+		}
+	}
+	// Трафик пропал на 3 часа: пропуск заполняется нулями, но не длиннее нормы,
+	// поэтому после возвращения трафика норма — ноль, и это всплеск
+	traffic(d, "/", 8+180, 20, 0)
+	d.Flush()
+
+	var drops int
+	recovery := base.Add(188 * time.Minute)
+	for _, a := range d.Anomalies() {
+		switch {
+		case a.Kind == AnomalyDrop && a.Time.Before(recovery):
+			drops++
+		case a.Kind == AnomalySpike && a.Time.Equal(recovery):
+		default:
+			t.Errorf("Неожиданная аномалия: %+v", a)
+		}
+	}
+	if drops == 0 || drops > 10 {
+		t.Errorf("Ожидались провалы в пределах нормы, получили %d", drops)
+	}
+	if d.late != 0 {
+		t.Errorf("Перемешанные записи соседних минут не должны опаздывать: %d", d.late)
+	}
+}
+
+// Маршруты, затихшие на всю норму, удаляются: сканер с уникальными путями не раздувает память
+func TestAnomalyRoutesBounded(t *testing.T) {
+	d := NewAnomalyDetector(AnomalyConfig{Baseline: 10})
+	for minute := 0; minute < 200; minute++ {
+		traffic(d, "/", minute, 20, 0)
+		for i := 0; i < 5; i++ {
+			traffic(d, fmt.Sprintf("/wp-%d.php", minute*5+i), minute, 1, 1)
+		}
+	}
+
+	d.mu.Lock()
+	routes := len(d.routes)
+	_, steady := d.routes["/"]
+	d.mu.Unlock()
+	if routes > 2+5*12 { // Весь трафик, «/» и пути за норму с запасом на незакрытые интервалы
+		t.Errorf("Старые маршруты не удаляются: %d", routes)
+	}
+	if !steady {
+		t.Error("Маршрут с постоянным трафиком не должен удаляться")
+	}
+}
+
+func TestAnomalyTick(t *testing.T) {
+	now := base
+	var live []Anomaly
+	d := NewAnomalyDetector(AnomalyConfig{
+		Now:       func() time.Time { return now },
+		OnAnomaly: func(a Anomaly) { live = append(live, a) },
+	})
+	for minute := 0; minute < 10; minute++ {
+		traffic(d, "/", minute, 20, 0)
+	}
+
+	// Записей больше нет: по часам прошло 5 минут — закрытые интервалы пустые, это провал
+	now = now.Add(5 * time.Minute)
+	d.Tick()
+	if len(live) == 0 || live[0].Kind != AnomalyDrop || live[0].Value != 0 {
+		t.Errorf("Ожидался провал после простоя, получили %+v", live)
+	}
+}
@@ -0,0 +1,271 @@
+package detect
+
+import (
+	"regexp"  // Для шаблона маршрутов входа
+	"sort"    // Для ранжирования нарушителей
+	"strings" // Для сборки отчёта
+	"sync"    // Для параллельных вызовов Observe
+	"time"    // Для окон
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"
+)
+
+// ================================================ Перебор паролей ================================================
+
+// DefaultLoginRoutes — маршруты входа по умолчанию
+var DefaultLoginRoutes = regexp.MustCompile(`(?i)/(login|signin|sign-in|auth|authenticate|session|oauth/token)(/|$)`)
+
+// BruteForceConfig — настройки детектора перебора паролей. Нулевые поля заменяются значениями по умолчанию
+type BruteForceConfig struct {
+	Routes          *regexp.Regexp // маршруты входа (по умолчанию DefaultLoginRoutes)
+	Statuses        []int          // неудачные ответы (по умолчанию 400, 401, 403)
+	Window          time.Duration  // скользящее окно (по умолчанию 5 минут)
+	IPThreshold     int            // неудачных попыток с одного IP в окне (по умолчанию 5)
+	SubnetThreshold int            // неудачных попыток с одной подсети в окне (по умолчанию 20)
+	SubnetV4Bits    int            // длина префикса подсети IPv4 (по умолчанию 24)
+	SubnetV6Bits    int            // длина префикса подсети IPv6 (по умолчанию 64)
+	TimelineBucket  time.Duration  // шаг хронологии в отчёте (по умолчанию 1 минута)
+	OnAlert         func(Alert)    // вызывается, когда IP или подсеть впервые превышает порог
+}
+
+// withDefaults заполняет нулевые поля
+func (c BruteForceConfig) withDefaults() BruteForceConfig {
+	if c.Routes == nil {
+		c.Routes = DefaultLoginRoutes
+	}
+	if len(c.Statuses) == 0 {
+		c.Statuses = []int{400, 401, 403}
+	}
+	if c.Window <= 0 {
+		c.Window = 5 * time.Minute
+	}
+	if c.IPThreshold <= 0 {
+		c.IPThreshold = 5
+	}
+	if c.SubnetThreshold <= 0 {
+		c.SubnetThreshold = 20
+	}
+	if c.SubnetV4Bits <= 0 {
+		c.SubnetV4Bits = 24
+	}
+	if c.SubnetV6Bits <= 0 {
+		c.SubnetV6Bits = 64
+	}
+	if c.TimelineBucket <= 0 {
+		c.TimelineBucket = time.Minute
+	}
+	return c
+}
+
+// tracker — неудачные попытки одного IP или подсети
+type tracker struct {
+	series
+	peak      int // максимум попыток в окне
+	peakStart time.Time
+	peakEnd   time.Time
+	alerted   bool
+	successes int             // успешные входы (для IP)
+	sourceIPs map[string]bool // разные IP (для подсети)
+	last      time.Time       // время последнего события: попытки или успешного входа
+}
+
+// seen отмечает событие в момент at
+func (t *tracker) seen(at time.Time) {
+	if at.After(t.last) {
+		t.last = at
+	}
+}
+
+// Offender — IP или подсеть, превысившие порог
+type Offender struct {
+	Kind      string          // ip или subnet
+	Key       string          // адрес или подсеть
+	Failures  int             // всего неудачных попыток
+	Peak      int             // максимум попыток в окне
+	PeakStart time.Time       // первая попытка окна с максимумом
+	PeakEnd   time.Time       // последняя попытка окна с максимумом
+	Successes int             // успешные входы с этого IP — возможно, пароль подобран
+	SourceIPs int             // сколько разных IP в подсети
+	Timeline  []TimelinePoint // попытки по интервалам
+}
+
+// BruteForceDetector ищет перебор паролей и credential stuffing: много неудачных входов с одного IP
+// или с одной подсети (распределённый перебор) в скользящем окне. Безопасен для вызова из нескольких воркеров
+type BruteForceDetector struct {
+	cfg BruteForceConfig
+
+	mu      sync.Mutex
+	ips     map[string]*tracker
+	subnets map[string]*tracker
+	failed  map[int]bool
+	clock   pruneClock
+}
+
+// NewBruteForceDetector создаёт детектор
+func NewBruteForceDetector(cfg BruteForceConfig) *BruteForceDetector {
+	cfg = cfg.withDefaults()
+	failed := make(map[int]bool, len(cfg.Statuses))
+	for _, status := range cfg.Statuses {
+		failed[status] = true
+	}
+	return &BruteForceDetector{cfg: cfg, ips: make(map[string]*tracker), subnets: make(map[string]*tracker), failed: failed}
+}
+
+// Observe учитывает запись: неудачный ответ на маршруте входа — попытка перебора
+func (d *BruteForceDetector) Observe(l model.LogEntry) {
+	if !d.cfg.Routes.MatchString(utilits.NormalizeRoute(l.URL)) {
+		return
+	}
+	ip := netaddr.ClientIP(l)
+
+	var alerts []Alert
+	d.mu.Lock()
+	d.prune(l.Timestamp)
+	if !d.failed[l.StatusCode] {
+		if l.StatusCode < 400 { // Успешный вход: если до этого был перебор, пароль мог быть подобран
+			t := d.get(d.ips, ip)
+			t.successes++
+			t.seen(l.Timestamp)
+		}
+		d.mu.Unlock()
+		return
+	}
+
+	if alert, ok := d.record(d.get(d.ips, ip), "ip", ip, l.Timestamp, d.cfg.IPThreshold); ok {
+		alerts = append(alerts, alert)
+	}
+	if addr := netaddr.ClientAddr(l); addr.IsValid() {
+		subnet := netaddr.Subnet(addr, d.cfg.SubnetV4Bits, d.cfg.SubnetV6Bits).String()
+		t := d.get(d.subnets, subnet)
+		if t.sourceIPs == nil {
+			t.sourceIPs = make(map[string]bool)
+		}
+		t.sourceIPs[ip] = true
+		if alert, ok := d.record(t, "subnet", subnet, l.Timestamp, d.cfg.SubnetThreshold); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	d.mu.Unlock()
+
+	if d.cfg.OnAlert != nil { // Вызываем без блокировки: обработчик может быть медленным
+		for _, alert := range alerts {
+			d.cfg.OnAlert(alert)
+		}
+	}
+}
+
+// get возвращает счётчик ключа, создавая его при первом обращении. Вызывается под d.mu
+func (d *BruteForceDetector) get(m map[string]*tracker, key string) *tracker {
+	t, ok := m[key]
+	if !ok {
+		t = &tracker{}
+		m[key] = t
+	}
+	return t
+}
+
+// prune удаляет IP и подсети без оповещений, у которых последнее событие старше окна: в режиме слежения
+// иначе память росла бы с каждым новым адресом. Вызывается под d.mu
+func (d *BruteForceDetector) prune(at time.Time) {
+	cutoff, ok := d.clock.due(at, d.cfg.Window)
+	if !ok {
+		return
+	}
+	for _, trackers := range []map[string]*tracker{d.ips, d.subnets} {
+		for key, t := range trackers {
+			if !t.alerted && t.last.Before(cutoff) {
+				delete(trackers, key)
+			}
+		}
+	}
+}
+
+// record добавляет попытку и возвращает оповещение, если порог превышен впервые. Вызывается под d.mu
+func (d *BruteForceDetector) record(t *tracker, kind, key string, at time.Time, threshold int) (Alert, bool) {
+	t.seen(at)
+	i := t.insert(at)
+	if peak, start, end := t.peakAfterInsert(i, d.cfg.Window); peak > t.peak {
+		t.peak, t.peakStart, t.peakEnd = peak, start, end
+	}
+	if t.alerted || t.peak < threshold {
+		return Alert{}, false
+	}
+
+	t.alerted = true
+	what := i18n.T("bruteforce.alert_ip", key)
+	if kind == "subnet" {
+		what = i18n.T("bruteforce.alert_subnet", key, len(t.sourceIPs))
+	}
+	return Alert{
+		Detector: "bruteforce",
+		Kind:     kind,
+		Key:      key,
+		Count:    t.peak,
+		Start:    t.peakStart,
+		End:      t.peakEnd,
+		Message:  i18n.T("bruteforce.alert", what, t.peak, d.cfg.Window),
+	}, true
+}
+
+// Offenders возвращает IP и подсети, превысившие порог, по убыванию максимума попыток в окне
+func (d *BruteForceDetector) Offenders() []Offender {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var offenders []Offender
+	for _, group := range []struct {
+		kind     string
+		trackers map[string]*tracker
+	}{{"ip", d.ips}, {"subnet", d.subnets}} {
+		for key, t := range group.trackers {
+			if !t.alerted {
+				continue
+			}
+			o := Offender{
+				Kind: group.kind, Key: key, Failures: len(t.times), Peak: t.peak, PeakStart: t.peakStart, PeakEnd: t.peakEnd,
+				Successes: t.successes, Timeline: t.timeline(d.cfg.TimelineBucket),
+			}
+			if group.kind == "subnet" {
+				o.SourceIPs = len(t.sourceIPs)
+			}
+			offenders = append(offenders, o)
+		}
+	}
+
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].Peak != offenders[j].Peak {
+			return offenders[i].Peak > offenders[j].Peak
+		}
+		return offenders[i].Key < offenders[j].Key
+	})
+	return offenders
+}
+
+// Report форматирует раздел отчёта: topN нарушителей с хронологией попыток на языке p (nil — язык по умолчанию)
+func (d *BruteForceDetector) Report(p *i18n.Printer, topN int) string {
+	offenders := d.Offenders()
+	if len(offenders) == 0 {
+		return p.Sprintf("bruteforce.none") + "\n"
+	}
+	if len(offenders) > topN {
+		offenders = offenders[:topN]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(p.Sprintf("bruteforce.title", d.cfg.Window, d.cfg.IPThreshold, d.cfg.SubnetThreshold) + "\n")
+	for i, o := range offenders {
+		what := p.Sprintf("bruteforce.ip", o.Key)
+		if o.Kind == "subnet" {
+			what = p.Sprintf("bruteforce.subnet", o.Key, o.SourceIPs)
+		}
+		sb.WriteString(p.Sprintf("bruteforce.row", i+1, what, o.Failures, o.Peak, o.PeakStart.Format(time.DateTime), o.PeakEnd.Format(time.DateTime)))
+		if o.Successes > 0 {
+			sb.WriteString(p.Sprintf("bruteforce.row_successes", o.Successes))
+		}
+		sb.WriteString("\n" + p.Sprintf("bruteforce.timeline", formatTimeline(o.Timeline)) + "\n")
+	}
+	return sb.String()
+}
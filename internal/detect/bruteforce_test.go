@@ -0,0 +1,153 @@
+package detect
+
+import (
+	"fmt"     // Для генерации адресов
+	"strings" // Для проверки отчёта
+	"sync"    // Для параллельных вызовов Observe
+	"testing" // Cтандартная библиотека для тестов Go
+	"time"    // Для работы с датой и временем
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+var base = time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+func login(ip string, at time.Duration, status int) model.LogEntry {
+	return model.LogEntry{Timestamp: base.Add(at), IP: ip, Method: "POST", URL: "/api/login", StatusCode: status}
+}
+
+// ================================================ Тесты временного ряда ================================================
+
+func TestSeriesPeak(t *testing.T) {
+	var s series
+	var peak int
+	for _, sec := range []int{50, 0, 10, 300, 20, 30} { // Вне порядка, как при нескольких воркерах
+		i := s.insert(base.Add(time.Duration(sec) * time.Second))
+		if p, _, _ := s.peakAfterInsert(i, time.Minute); p > peak {
+			peak = p
+		}
+	}
+	if peak != 5 { // 0, 10, 20, 30, 50 — в одной минуте
+		t.Errorf("Ожидался максимум 5 событий в окне, получили %d", peak)
+	}
+
+	points := s.timeline(time.Minute)
+	if got := formatTimeline(points); got != "10:30 ×5, 10:35 ×1" {
+		t.Errorf("Неверная хронология: %q", got)
+	}
+}
+
+// ================================================ Тесты детектора перебора ================================================
+
+func TestBruteForceIP(t *testing.T) {
+	var alerts []Alert
+	d := NewBruteForceDetector(BruteForceConfig{
+		Window: time.Minute, IPThreshold: 4, SubnetThreshold: 100,
+		OnAlert: func(a Alert) { alerts = append(alerts, a) },
+	})
+
+	// Неудачи раз в 100 с — ниже порога и забываются, когда IP затихает дольше окна; потом 5 подряд и успешный вход
+	for _, sec := range []int{0, 100, 200} {
+		d.Observe(login("10.0.0.1", time.Duration(sec)*time.Second, 401))
+	}
+	for _, sec := range []int{300, 305, 310, 315, 320} {
+		d.Observe(login("10.0.0.1", time.Duration(sec)*time.Second, 400))
+	}
+	d.Observe(login("10.0.0.1", 330*time.Second, 200))
+	d.Observe(model.LogEntry{Timestamp: base, IP: "10.0.0.1", URL: "/api/users", StatusCode: 403}) // Не маршрут входа
+	d.Observe(login("10.0.0.2", 0, 401))                                                           // Одна неудача — не нарушитель
+
+	if len(alerts) != 1 || alerts[0].Kind != "ip" || alerts[0].Key != "10.0.0.1" || alerts[0].Count != 4 {
+		t.Fatalf("Ожидалось одно оповещение по 10.0.0.1, получили %+v", alerts)
+	}
+
+	offenders := d.Offenders()
+	if len(offenders) != 1 {
+		t.Fatalf("Ожидался один нарушитель, получили %+v", offenders)
+	}
+	o := offenders[0]
+	if o.Failures != 5 || o.Peak != 5 || o.Successes != 1 || !o.PeakStart.Equal(base.Add(300*time.Second)) {
+		t.Errorf("Неверные данные нарушителя: %+v", o)
+	}
+
+	report := d.Report(nil, 5)
+	for _, want := range []string{"IP 10.0.0.1 — 5 неудачных попыток, до 5 за окно", "успешных входов: 1", "хронология: 10:35 ×5"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("В отчёте нет %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestBruteForceSubnet(t *testing.T) {
+	d := NewBruteForceDetector(BruteForceConfig{Window: time.Minute, IPThreshold: 5, SubnetThreshold: 10})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ { // Распределённый перебор: 12 адресов одной /24, по одной попытке с каждого
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			d.Observe(login(fmt.Sprintf("203.0.113.%d", i+1), time.Duration(i)*time.Second, 401))
+		}(i)
+	}
+	wg.Wait()
+
+	offenders := d.Offenders()
+	if len(offenders) != 1 || offenders[0].Kind != "subnet" || offenders[0].Key != "203.0.113.0/24" ||
+		offenders[0].Peak != 12 || offenders[0].SourceIPs != 12 {
+		t.Fatalf("Ожидался нарушитель-подсеть 203.0.113.0/24, получили %+v", offenders)
+	}
+	if report := d.Report(nil, 5); !strings.Contains(report, "подсеть 203.0.113.0/24 (12 IP)") {
+		t.Errorf("В отчёте нет подсети:\n%s", report)
+	}
+}
+
+func TestBruteForceAlertMessage(t *testing.T) {
+	defer i18n.SetDefault(i18n.Default().Lang())
+
+	for lang, want := range map[i18n.Lang]string{
+		i18n.RU: "Перебор паролей с IP 10.0.0.1: 3 неудачных входов за 1\u00a0мин",
+		i18n.EN: "Password brute force from IP 10.0.0.1: 3 failed logins in 1\u00a0min",
+	} {
+		i18n.SetDefault(lang)
+		var alerts []Alert
+		d := NewBruteForceDetector(BruteForceConfig{Window: time.Minute, IPThreshold: 3, OnAlert: func(a Alert) { alerts = append(alerts, a) }})
+		for i := 0; i < 3; i++ {
+			d.Observe(login("10.0.0.1", time.Duration(i)*time.Second, 401))
+		}
+		if len(alerts) != 1 || alerts[0].Message != want {
+			t.Errorf("Ожидалось оповещение %q, получили %+v", want, alerts)
+		}
+	}
+}
+
+// Адреса без оповещений, затихшие дольше окна, не копятся в памяти; нарушители остаются в отчёте
+func TestBruteForcePrune(t *testing.T) {
+	d := NewBruteForceDetector(BruteForceConfig{Window: time.Minute, IPThreshold: 3, SubnetThreshold: 1000})
+	for _, sec := range []int{0, 1, 2} {
+		d.Observe(login("10.0.0.1", time.Duration(sec)*time.Second, 401))
+	}
+	for i := 0; i < 1000; i++ { // Поток разных клиентов: успешный вход и одна неудача, по адресу в секунду
+		ip := fmt.Sprintf("172.16.%d.%d", i/256, i%256)
+		d.Observe(login(ip, time.Duration(i)*time.Second, 200))
+		d.Observe(login(ip, time.Duration(i)*time.Second, 401))
+	}
+
+	d.mu.Lock()
+	ips, subnets := len(d.ips), len(d.subnets)
+	d.mu.Unlock()
+	if ips > 2*60+1 || subnets > 2*60+1 { // Не больше адресов, чем за два окна, и нарушитель
+		t.Errorf("Старые адреса не удаляются: %d IP, %d подсетей", ips, subnets)
+	}
+	if offenders := d.Offenders(); len(offenders) != 1 || offenders[0].Key != "10.0.0.1" || offenders[0].Failures != 3 {
+		t.Errorf("Нарушитель не должен удаляться: %+v", offenders)
+	}
+}
+
+func TestBruteForceNothing(t *testing.T) {
+	d := NewBruteForceDetector(BruteForceConfig{})
+	d.Observe(login("10.0.0.1", 0, 400))
+	if report := d.Report(nil, 5); report != "Перебор паролей: не обнаружен\n" {
+		t.Errorf("Неожиданный отчёт: %q", report)
+	}
+}
@@ -0,0 +1,304 @@
+package detect
+
+import (
+	"bufio"   // Для построчного чтения списка сигнатур
+	_ "embed" // Для встраивания списка сигнатур в бинарник
+	"io"      // Для чтения сигнатур из произвольного источника
+	"sort"    // Для ранжирования клиентов
+	"strings" // Для сравнения путей
+	"sync"    // Для параллельных вызовов Observe
+	"time"    // Для окон
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"
+)
+
+// ================================================ Сканеры ================================================
+
+//go:embed probes.txt
+var defaultProbes string
+
+// DefaultSignatures возвращает встроенный список путей, характерных для сканеров
+func DefaultSignatures() []string {
+	signatures, _ := LoadSignatures(strings.NewReader(defaultProbes)) // Чтение из строки не возвращает ошибок
+	return signatures
+}
+
+// LoadSignatures читает список сигнатур: по одному фрагменту пути на строку, пустые строки и комментарии (#) пропускаются.
+// «~» в начале строки помечает слабую сигнатуру: она учитывается, только если сервер ответил не 2xx
+func LoadSignatures(r io.Reader) ([]string, error) {
+	var signatures []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.TrimPrefix(line, "~") == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		signatures = append(signatures, strings.ToLower(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, i18n.Errorf("scanner.read", err)
+	}
+	return signatures, nil
+}
+
+// ScannerConfig — настройки детектора сканеров. Нулевые поля заменяются значениями по умолчанию
+type ScannerConfig struct {
+	Window        time.Duration // окно для подсчёта разных несуществующих путей (по умолчанию 10 минут)
+	DistinctPaths int           // разных путей с ответом 404 в окне (по умолчанию 10)
+	NotFoundRatio float64       // доля ответов 404 среди всех запросов клиента (по умолчанию 0.5)
+	Signatures    []string      // фрагменты путей сканеров, «~» в начале — слабая сигнатура (по умолчанию DefaultSignatures)
+	OnAlert       func(Alert)   // вызывается, когда клиент впервые признан подозрительным
+}
+
+// withDefaults заполняет нулевые поля
+func (c ScannerConfig) withDefaults() ScannerConfig {
+	if c.Window <= 0 {
+		c.Window = 10 * time.Minute
+	}
+	if c.DistinctPaths <= 0 {
+		c.DistinctPaths = 10
+	}
+	if c.NotFoundRatio <= 0 {
+		c.NotFoundRatio = 0.5
+	}
+	if c.Signatures == nil {
+		c.Signatures = DefaultSignatures()
+	}
+	return c
+}
+
+// client — активность одного IP
+type client struct {
+	requests  int
+	notFound  int
+	firstSeen map[string]time.Time // когда путь с ответом 404 встретился впервые
+	series                         // моменты первого появления несуществующих путей
+	peak      int                  // максимум новых несуществующих путей в окне
+	probes    map[string]int       // сработавшие сигнатуры
+	alerted   bool
+	last      time.Time // время последнего запроса
+}
+
+// SuspiciousClient — клиент, похожий на сканер
+type SuspiciousClient struct {
+	IP            string
+	Score         int      // оценка для ранжирования
+	Requests      int      // всего запросов
+	NotFound      int      // из них с ответом 404
+	DistinctPaths int      // максимум разных несуществующих путей в окне
+	Probes        []string // сработавшие сигнатуры
+}
+
+// NotFoundRatio — доля ответов 404
+func (c SuspiciousClient) NotFoundRatio() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.NotFound) / float64(c.Requests)
+}
+
+// ScannerDetector ищет сканеры: клиентов с большой долей 404 и множеством разных несуществующих путей
+// в окне, а также всех, кто обращался к известным путям сканеров. Безопасен для вызова из нескольких воркеров
+type ScannerDetector struct {
+	cfg ScannerConfig
+
+	mu      sync.Mutex
+	clients map[string]*client
+	clock   pruneClock
+}
+
+// NewScannerDetector создаёт детектор
+func NewScannerDetector(cfg ScannerConfig) *ScannerDetector {
+	return &ScannerDetector{cfg: cfg.withDefaults(), clients: make(map[string]*client)}
+}
+
+// Observe учитывает запись
+func (d *ScannerDetector) Observe(l model.LogEntry) {
+	ip := netaddr.ClientIP(l)
+	path := l.URL
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	probe := d.matchSignature(strings.ToLower(l.URL), l.StatusCode)
+
+	d.mu.Lock()
+	d.prune(l.Timestamp)
+	c, ok := d.clients[ip]
+	if !ok {
+		c = &client{}
+		d.clients[ip] = c
+	}
+	c.requests++
+	if l.Timestamp.After(c.last) {
+		c.last = l.Timestamp
+	}
+	if probe != "" {
+		if c.probes == nil {
+			c.probes = make(map[string]int)
+		}
+		c.probes[probe]++
+	}
+	if l.StatusCode == 404 {
+		c.notFound++
+		d.recordPath(c, path, l.Timestamp)
+	}
+
+	var alert *Alert
+	if !c.alerted && d.suspicious(c) {
+		c.alerted = true
+		alert = &Alert{Detector: "scanner", Kind: "ip", Key: ip, Count: c.peak, Start: l.Timestamp, End: l.Timestamp,
+			Message: i18n.T("scanner.alert", ip, d.reason(c))}
+	}
+	d.mu.Unlock()
+
+	if alert != nil && d.cfg.OnAlert != nil {
+		d.cfg.OnAlert(*alert)
+	}
+}
+
+// prune удаляет клиентов, не признанных сканерами, у которых в окне не осталось ни одного запроса:
+// в режиме слежения иначе память росла бы с каждым новым адресом. Вызывается под d.mu
+func (d *ScannerDetector) prune(at time.Time) {
+	cutoff, ok := d.clock.due(at, d.cfg.Window)
+	if !ok {
+		return
+	}
+	for ip, c := range d.clients {
+		if !c.alerted && c.last.Before(cutoff) {
+			delete(d.clients, ip)
+		}
+	}
+}
+
+// recordPath учитывает несуществующий путь. Учитывается первое по времени обращение, даже если записи пришли не по порядку.
+// Вызывается под d.mu
+func (d *ScannerDetector) recordPath(c *client, path string, at time.Time) {
+	if c.firstSeen == nil {
+		c.firstSeen = make(map[string]time.Time)
+	}
+	if seen, ok := c.firstSeen[path]; ok {
+		if !at.Before(seen) {
+			return
+		}
+		i := sort.Search(len(c.times), func(i int) bool { return !c.times[i].Before(seen) }) // Переносим путь на более раннее время
+		c.times = append(c.times[:i], c.times[i+1:]...)
+	}
+	c.firstSeen[path] = at
+	i := c.insert(at)
+	if peak, _, _ := c.peakAfterInsert(i, d.cfg.Window); peak > c.peak {
+		c.peak = peak
+	}
+}
+
+// matchSignature возвращает сработавшую сигнатуру без пометки «~» (пусто — ни одна не подошла).
+// Слабые сигнатуры — пути, которые бывают и у обычных приложений, — при ответе 2xx не учитываются
+func (d *ScannerDetector) matchSignature(url string, status int) string {
+	for _, sig := range d.cfg.Signatures {
+		pattern, weak := strings.CutPrefix(sig, "~")
+		if weak && status >= 200 && status < 300 {
+			continue
+		}
+		if containsPath(url, pattern) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// containsPath сообщает, входит ли фрагмент pattern в url целиком: если фрагмент заканчивается буквой или
+// цифрой, следом не должно идти продолжение имени — /shell совпадает с /shell.php и /shell?c=, но не с /shells/
+func containsPath(url, pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+	for from := 0; ; {
+		i := strings.Index(url[from:], pattern)
+		if i < 0 {
+			return false
+		}
+		end := from + i + len(pattern)
+		if end == len(url) || !nameByte(pattern[len(pattern)-1]) || !nameByte(url[end]) {
+			return true
+		}
+		from += i + 1
+	}
+}
+
+// nameByte — байт может продолжать имя в пути: буква, цифра, «-», «_» или часть многобайтового символа
+func nameByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '-' || b == '_' || b >= 0x80
+}
+
+// suspicious — клиент похож на сканер. Вызывается под d.mu
+func (d *ScannerDetector) suspicious(c *client) bool {
+	if len(c.probes) > 0 {
+		return true
+	}
+	return c.peak >= d.cfg.DistinctPaths && float64(c.notFound) >= d.cfg.NotFoundRatio*float64(c.requests)
+}
+
+// reason кратко объясняет, почему клиент подозрителен. Вызывается под d.mu
+func (d *ScannerDetector) reason(c *client) string {
+	if len(c.probes) > 0 {
+		probes := make([]string, 0, len(c.probes))
+		for p := range c.probes {
+			probes = append(probes, p)
+		}
+		sort.Strings(probes)
+		return i18n.T("scanner.reason_probes", strings.Join(probes, ", "))
+	}
+	return i18n.T("scanner.reason_paths", c.peak, d.cfg.Window)
+}
+
+// Suspicious возвращает подозрительных клиентов по убыванию оценки.
+// Оценка: 10 за каждую сработавшую сигнатуру + максимум разных несуществующих путей в окне + доля 404 × 10
+func (d *ScannerDetector) Suspicious() []SuspiciousClient {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var result []SuspiciousClient
+	for ip, c := range d.clients {
+		if !d.suspicious(c) {
+			continue
+		}
+		s := SuspiciousClient{IP: ip, Requests: c.requests, NotFound: c.notFound, DistinctPaths: c.peak}
+		for p := range c.probes {
+			s.Probes = append(s.Probes, p)
+		}
+		sort.Strings(s.Probes)
+		s.Score = 10*len(s.Probes) + s.DistinctPaths + int(10*s.NotFoundRatio())
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].IP < result[j].IP
+	})
+	return result
+}
+
+// Report форматирует раздел «Подозрительные клиенты» с topN клиентами на языке p (nil — язык по умолчанию)
+func (d *ScannerDetector) Report(p *i18n.Printer, topN int) string {
+	clients := d.Suspicious()
+	if len(clients) == 0 {
+		return p.Sprintf("scanner.none") + "\n"
+	}
+	if len(clients) > topN {
+		clients = clients[:topN]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(p.Sprintf("scanner.title") + "\n")
+	for i, c := range clients {
+		sb.WriteString(p.Sprintf("scanner.row", i+1, c.IP, c.Score, c.NotFound, c.Requests, 100*c.NotFoundRatio(), c.DistinctPaths))
+		if len(c.Probes) > 0 {
+			sb.WriteString(p.Sprintf("scanner.row_probes", strings.Join(c.Probes, ", ")))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
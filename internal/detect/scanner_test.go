@@ -0,0 +1,159 @@
+package detect
+
+import (
+	"fmt"     // Для генерации путей
+	"strings" // Для проверки отчёта
+	"testing" // Cтандартная библиотека для тестов Go
+	"time"    // Для работы с датой и временем
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+func request(ip, url string, at time.Duration, status int) model.LogEntry {
+	return model.LogEntry{Timestamp: base.Add(at), IP: ip, Method: "GET", URL: url, StatusCode: status}
+}
+
+// ================================================ Тесты детектора сканеров ================================================
+
+func TestDefaultSignatures(t *testing.T) {
+	signatures := DefaultSignatures()
+	for _, want := range []string{"/.env", "/wp-admin", "/phpmyadmin", "../"} {
+		found := false
+		for _, sig := range signatures {
+			found = found || sig == want
+		}
+		if !found {
+			t.Errorf("Во встроенном списке нет сигнатуры %q", want)
+		}
+	}
+	for _, sig := range signatures {
+		if strings.HasPrefix(sig, "#") || sig != strings.ToLower(sig) {
+			t.Errorf("Неверная сигнатура %q", sig)
+		}
+	}
+}
+
+func TestScannerDistinctPaths(t *testing.T) {
+	var alerts []Alert
+	d := NewScannerDetector(ScannerConfig{
+		Window: time.Minute, DistinctPaths: 5, Signatures: []string{},
+		OnAlert: func(a Alert) { alerts = append(alerts, a) },
+	})
+
+	// Сканер: 6 разных несуществующих путей за 30 секунд, вне порядка и с повторами
+	for _, sec := range []int{25, 0, 5, 10, 15, 20} {
+		d.Observe(request("10.0.0.1", fmt.Sprintf("/api/invalid%d?x=1", sec), time.Duration(sec)*time.Second, 404))
+	}
+	d.Observe(request("10.0.0.1", "/api/invalid0", 26*time.Second, 404)) // Повтор не считается новым путём
+	d.Observe(request("10.0.0.1", "/", 27*time.Second, 200))
+
+	// Обычный клиент: много 404, но путей мало; и клиент с разными путями, растянутыми во времени
+	for i := 0; i < 10; i++ {
+		d.Observe(request("10.0.0.2", "/favicon.ico", time.Duration(i)*time.Second, 404))
+		d.Observe(request("10.0.0.3", fmt.Sprintf("/old/%d", i), time.Duration(i)*time.Minute, 404))
+	}
+
+	if len(alerts) != 1 || alerts[0].Key != "10.0.0.1" || alerts[0].Count != 5 {
+		t.Fatalf("Ожидалось одно оповещение для 10.0.0.1 на пятом пути, получили %+v", alerts)
+	}
+
+	suspects := d.Suspicious()
+	if len(suspects) != 1 {
+		t.Fatalf("Ожидался один подозрительный клиент, получили %+v", suspects)
+	}
+	s := suspects[0]
+	if s.Requests != 8 || s.NotFound != 7 || s.DistinctPaths != 6 || s.Score != 6+8 {
+		t.Errorf("Неверные данные о клиенте: %+v", s)
+	}
+}
+
+func TestScannerLowRatio(t *testing.T) {
+	d := NewScannerDetector(ScannerConfig{Window: time.Minute, DistinctPaths: 3, NotFoundRatio: 0.5, Signatures: []string{}})
+
+	// Три разных 404, но на фоне множества успешных запросов — скорее битые ссылки, чем сканер
+	for i := 0; i < 10; i++ {
+		d.Observe(request("10.0.0.1", "/", time.Duration(i)*time.Second, 200))
+	}
+	for i := 0; i < 3; i++ {
+		d.Observe(request("10.0.0.1", fmt.Sprintf("/missing/%d", i), time.Duration(i)*time.Second, 404))
+	}
+	if got := d.Suspicious(); len(got) != 0 {
+		t.Errorf("Клиент с долей 404 ниже порога не должен считаться сканером: %+v", got)
+	}
+}
+
+// Обычные клиенты, затихшие дольше окна, не копятся в памяти; сканеры остаются в отчёте
+func TestScannerPrune(t *testing.T) {
+	d := NewScannerDetector(ScannerConfig{Window: time.Minute})
+	d.Observe(request("10.0.0.1", "/.env", 0, 404))
+	for i := 0; i < 1000; i++ { // Поток разных клиентов по адресу в секунду
+		d.Observe(request(fmt.Sprintf("172.16.%d.%d", i/256, i%256), "/missing", time.Duration(i)*time.Second, 404))
+	}
+
+	d.mu.Lock()
+	clients := len(d.clients)
+	d.mu.Unlock()
+	if clients > 2*60+1 { // Не больше клиентов, чем за два окна, и сканер
+		t.Errorf("Старые клиенты не удаляются: %d", clients)
+	}
+	if got := d.Suspicious(); len(got) != 1 || got[0].IP != "10.0.0.1" {
+		t.Errorf("Сканер не должен удаляться: %+v", got)
+	}
+}
+
+func TestScannerSignaturesAndReport(t *testing.T) {
+	d := NewScannerDetector(ScannerConfig{})
+	d.Observe(request("10.0.0.1", "/WP-Admin/setup.php", 0, 404)) // Регистр не важен
+	d.Observe(request("10.0.0.1", "/.env", time.Second, 200))     // Ответ 200 ещё опаснее
+	d.Observe(request("10.0.0.2", "/.git/config", 0, 403))
+	d.Observe(request("10.0.0.3", "/api/users", 0, 200))
+
+	report := d.Report(nil, 5)
+	for _, want := range []string{
+		"Подозрительные клиенты (сканеры):",
+		"1. 10.0.0.1 — оценка 26: 404 — 1 из 2 (50%), разных несуществующих путей за окно: 1, пути сканеров: /.env, /wp-admin",
+		"2. 10.0.0.2 — оценка 10: 404 — 0 из 1 (0%), разных несуществующих путей за окно: 0, пути сканеров: /.git/",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("В отчёте нет %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "10.0.0.3") {
+		t.Errorf("Обычный клиент попал в отчёт:\n%s", report)
+	}
+
+	if got := NewScannerDetector(ScannerConfig{}).Report(nil, 5); got != "Подозрительные клиенты: не обнаружены\n" {
+		t.Errorf("Неверный отчёт без сканеров: %q", got)
+	}
+}
+
+// Обычные пути приложений не делают клиента сканером: слабые сигнатуры при ответе 2xx не учитываются,
+// а имя в пути должно совпасть целиком
+func TestScannerSignatureFalsePositives(t *testing.T) {
+	d := NewScannerDetector(ScannerConfig{})
+	d.Observe(request("10.0.0.1", "/config.json", 0, 200)) // Настройки фронтенда
+	d.Observe(request("10.0.0.1", "/actuator/health", time.Second, 200))
+	d.Observe(request("10.0.0.2", "/shells/seashell.png", 0, 200))
+	d.Observe(request("10.0.0.2", "/api/.environment", time.Second, 404))
+	d.Observe(request("10.0.0.3", "/config.json", 0, 404)) // Того же пути нет — это уже проба
+	d.Observe(request("10.0.0.4", "/shell.php?cmd=id", 0, 403))
+
+	suspects := d.Suspicious()
+	var got []string
+	for _, s := range suspects {
+		got = append(got, s.IP+" "+strings.Join(s.Probes, ","))
+	}
+	if want := "10.0.0.3 /config.json; 10.0.0.4 /shell"; strings.Join(got, "; ") != want {
+		t.Errorf("Неверные подозрительные клиенты: %q, ожидали %q", got, want)
+	}
+}
+
+func TestLoadSignatures(t *testing.T) {
+	signatures, err := LoadSignatures(strings.NewReader("# комментарий\n\n  /Secret \n/admin\n~\n~/Console/\n"))
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if strings.Join(signatures, ",") != "/secret,/admin,~/console/" {
+		t.Errorf("Неверные сигнатуры: %q", signatures)
+	}
+}
@@ -0,0 +1,120 @@
+// Детекторы подозрительной активности: перебор паролей, сканирование путей.
+// Окна считаются по времени записей лога, а не по часам машины, поэтому одинаково работают
+// и на старом файле, и в режиме слежения. Записи могут приходить не по порядку — их обрабатывают несколько воркеров.
+
+package detect
+
+import (
+	"fmt"     // Для форматирования хронологии
+	"sort"    // Для поиска позиции во времени
+	"strings" // Для сборки строк
+	"time"    // Для окон и хронологии
+)
+
+// ================================================ Временной ряд ================================================
+
+// series — отсортированные по времени события одного ключа
+type series struct {
+	times []time.Time
+}
+
+// insert добавляет событие, сохраняя порядок, и возвращает его индекс
+func (s *series) insert(t time.Time) int {
+	i := sort.Search(len(s.times), func(i int) bool { return s.times[i].After(t) })
+	s.times = append(s.times, time.Time{})
+	copy(s.times[i+1:], s.times[i:])
+	s.times[i] = t
+	return i
+}
+
+// windowAt возвращает количество событий в окне (end-window, end] и индекс первого из них, где end — событие j
+func (s *series) windowAt(j int, window time.Duration) (int, int) {
+	from := s.times[j].Add(-window)
+	first := sort.Search(j+1, func(i int) bool { return s.times[i].After(from) })
+	return j - first + 1, first
+}
+
+// peakAfterInsert проверяет окна, на которые повлияло событие i: заканчивающиеся на нём и на событиях
+// не позже чем через window после него. Возвращает максимум событий в окне и его границы
+func (s *series) peakAfterInsert(i int, window time.Duration) (int, time.Time, time.Time) {
+	peak, start, end := 0, time.Time{}, time.Time{}
+	limit := s.times[i].Add(window)
+	for j := i; j < len(s.times) && !s.times[j].After(limit); j++ {
+		if count, first := s.windowAt(j, window); count > peak {
+			peak, start, end = count, s.times[first], s.times[j]
+		}
+	}
+	return peak, start, end
+}
+
+// pruneClock решает, когда пора очищать ключи, затихшие дольше окна. Время берётся из записей лога,
+// очистка — не чаще раза за окно, чтобы обход всех ключей не стоил на каждой записи
+type pruneClock struct {
+	latest time.Time // самое позднее время среди записей
+	pruned time.Time // latest на момент последней очистки
+}
+
+// due отмечает запись в момент at и, если пора чистить, возвращает границу: ключи без событий позже неё можно удалять
+func (c *pruneClock) due(at time.Time, window time.Duration) (time.Time, bool) {
+	if !at.After(c.latest) {
+		return time.Time{}, false
+	}
+	c.latest = at
+	if c.latest.Sub(c.pruned) < window {
+		return time.Time{}, false
+	}
+	c.pruned = c.latest
+	return c.latest.Add(-window), true
+}
+
+// ================================================ Хронология ================================================
+
+// TimelinePoint — количество событий в интервале, начинающемся с Time
+type TimelinePoint struct {
+	Time  time.Time
+	Count int
+}
+
+// timeline группирует события в интервалы длиной bucket
+func (s *series) timeline(bucket time.Duration) []TimelinePoint {
+	var points []TimelinePoint
+	for _, t := range s.times {
+		start := t.Truncate(bucket)
+		if n := len(points); n > 0 && points[n-1].Time.Equal(start) {
+			points[n-1].Count++
+			continue
+		}
+		points = append(points, TimelinePoint{Time: start, Count: 1})
+	}
+	return points
+}
+
+// formatTimeline форматирует хронологию вида «10:30 ×3, 10:31 ×5»; если события в разные дни, добавляется дата
+func formatTimeline(points []TimelinePoint) string {
+	if len(points) == 0 {
+		return ""
+	}
+	layout := "15:04"
+	if points[0].Time.YearDay() != points[len(points)-1].Time.YearDay() || points[0].Time.Year() != points[len(points)-1].Time.Year() {
+		layout = "2006-01-02 15:04"
+	}
+
+	parts := make([]string, len(points))
+	for i, p := range points {
+		parts[i] = fmt.Sprintf("%s ×%d", p.Time.Format(layout), p.Count)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ================================================ Оповещения ================================================
+
+// Alert — срабатывание детектора: ключ (IP или подсеть) превысил порог в окне
+type Alert struct {
+	Detector string    // bruteforce, scanner
+	Kind     string    // ip или subnet
+	Key      string    // адрес или подсеть
+	Count    int       // сколько событий в окне
+	Start    time.Time // первое событие окна
+	End      time.Time // последнее событие окна
+	Message  string    // описание для человека
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/csv" // Для записи CSV
+	"io"           // Для записи в произвольный поток
+	"strconv"      // Для чисел и логических значений
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// TimeLayout — формат времени в CSV, как во входных логах
+const TimeLayout = "2006-01-02 15:04:05"
+
+// csvWriter пишет заголовок из Columns и по строке на запись
+type csvWriter struct {
+	csv    *csv.Writer
+	opts   Options
+	record []string
+}
+
+func newCSVWriter(w io.Writer, opts Options) (*csvWriter, error) {
+	cw := &csvWriter{csv: csv.NewWriter(w), opts: opts, record: make([]string, len(Columns))}
+	for i, c := range Columns {
+		cw.record[i] = c.Name
+	}
+	if err := cw.csv.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Write добавляет строку; данные сбрасываются в поток по мере заполнения буфера
+func (w *csvWriter) Write(l model.LogEntry) error {
+	for i, c := range Columns {
+		v := c.get(l, w.opts.ApdexT)
+		switch c.Kind {
+		case KindString:
+			w.record[i] = v.s
+		case KindInt:
+			w.record[i] = strconv.FormatInt(v.i, 10)
+		case KindBool:
+			w.record[i] = strconv.FormatBool(v.b)
+		case KindTime:
+			w.record[i] = v.t.Format(TimeLayout)
+		}
+	}
+	return w.csv.Write(w.record)
+}
+
+// Close сбрасывает буфер в поток
+func (w *csvWriter) Close() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
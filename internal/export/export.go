@@ -0,0 +1,175 @@
+// Пакет export записывает обработанные записи в CSV, JSON Lines и Parquet.
+
+package export
+
+import (
+	"io"            // Для записи в произвольный поток
+	"os"            // Для создания файла
+	"path/filepath" // Для формата по расширению файла
+	"strings"       // Для сравнения расширений без учёта регистра
+	"time"          // Для времени записи
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"
+)
+
+// ================================================ Колонки ================================================
+
+// Kind — тип значения колонки
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindBool
+	KindTime
+)
+
+// Column — колонка выгрузки: название и способ получить значение из записи
+type Column struct {
+	Name string
+	Kind Kind
+	get  func(l model.LogEntry, apdexT int) value
+}
+
+// value — значение колонки; заполнено только поле, соответствующее типу
+type value struct {
+	s string
+	i int64
+	b bool
+	t time.Time
+}
+
+// Columns — колонки выгрузки по порядку. Первые девять совпадают с входным CSV, поэтому выгрузку в CSV
+// можно снова подать на вход; остальные — результаты обогащения: реальный IP клиента, маршрут, GeoIP,
+// разобранный User-Agent и класс Apdex
+var Columns = []Column{
+	{"timestamp", KindTime, func(l model.LogEntry, _ int) value { return value{t: l.Timestamp} }},
+	{"ip", KindString, func(l model.LogEntry, _ int) value { return value{s: l.IP} }},
+	{"method", KindString, func(l model.LogEntry, _ int) value { return value{s: l.Method} }},
+	{"url", KindString, func(l model.LogEntry, _ int) value { return value{s: l.URL} }},
+	{"status", KindInt, func(l model.LogEntry, _ int) value { return value{i: int64(l.StatusCode)} }},
+	{"response_time", KindInt, func(l model.LogEntry, _ int) value { return value{i: int64(l.ResponseTime)} }},
+	{"x_forwarded_for", KindString, func(l model.LogEntry, _ int) value { return value{s: l.ForwardedFor} }},
+	{"x_real_ip", KindString, func(l model.LogEntry, _ int) value { return value{s: l.RealIP} }},
+	{"user_agent", KindString, func(l model.LogEntry, _ int) value { return value{s: l.UserAgent} }},
+	{"client_ip", KindString, func(l model.LogEntry, _ int) value { return value{s: netaddr.ClientIP(l)} }},
+	{"route", KindString, func(l model.LogEntry, _ int) value { return value{s: utilits.NormalizeRoute(l.URL)} }},
+	{"country", KindString, func(l model.LogEntry, _ int) value { return value{s: l.Country} }},
+	{"city", KindString, func(l model.LogEntry, _ int) value { return value{s: l.City} }},
+	{"asn", KindInt, func(l model.LogEntry, _ int) value { return value{i: int64(l.ASN)} }},
+	{"as_org", KindString, func(l model.LogEntry, _ int) value { return value{s: l.ASOrg} }},
+	{"browser", KindString, func(l model.LogEntry, _ int) value { return value{s: l.Client.Browser} }},
+	{"browser_version", KindString, func(l model.LogEntry, _ int) value { return value{s: l.Client.Version} }},
+	{"os", KindString, func(l model.LogEntry, _ int) value { return value{s: l.Client.OS} }},
+	{"device", KindString, func(l model.LogEntry, _ int) value { return value{s: l.Client.Device} }},
+	{"bot", KindBool, func(l model.LogEntry, _ int) value { return value{b: l.Client.Bot} }},
+	{"bot_name", KindString, func(l model.LogEntry, _ int) value { return value{s: l.Client.BotName} }},
+	{"bot_category", KindString, func(l model.LogEntry, _ int) value { return value{s: l.Client.BotCategory} }},
+	{"apdex", KindString, func(l model.LogEntry, t int) value { return value{s: model.ApdexClass(l, t)} }},
+}
+
+// ================================================ Запись ================================================
+
+// Форматы выгрузки
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+)
+
+// Writer записывает записи по одной. Close дописывает буферизованные данные (для Parquet — последнюю
+// группу строк и метаданные), но не закрывает поток, переданный в New
+type Writer interface {
+	Write(l model.LogEntry) error
+	Close() error
+}
+
+// Options — настройки выгрузки
+type Options struct {
+	ApdexT       int // порог Apdex T в мс для колонки apdex (0 — model.DefaultApdexT)
+	RowGroupSize int // строк в группе Parquet (0 — DefaultRowGroupSize)
+}
+
+// FormatFromPath определяет формат по расширению файла: .csv, .jsonl/.ndjson или .parquet
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	case ".parquet":
+		return FormatParquet, nil
+	}
+	return "", i18n.Errorf("export.unknown_ext", path)
+}
+
+// New создаёт писателя нужного формата поверх w
+func New(w io.Writer, format string, opts Options) (Writer, error) {
+	if opts.ApdexT <= 0 {
+		opts.ApdexT = model.DefaultApdexT
+	}
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, opts)
+	case FormatJSONL:
+		return newJSONLWriter(w, opts), nil
+	case FormatParquet:
+		return newParquetWriter(w, opts), nil
+	}
+	return nil, i18n.Errorf("export.unknown_format", format)
+}
+
+// Create создаёт файл и писателя; пустой format определяется по расширению. Close писателя закрывает и файл
+func Create(path, format string, opts Options) (Writer, error) {
+	if format == "" {
+		var err error
+		if format, err = FormatFromPath(path); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := New(f, format, opts)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	return &fileWriter{Writer: w, f: f}, nil
+}
+
+// fileWriter закрывает файл после писателя
+type fileWriter struct {
+	Writer
+	f *os.File
+}
+
+// Close дописывает данные и закрывает файл
+func (w *fileWriter) Close() error {
+	err := w.Writer.Close()
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// WriteAll записывает все записи из канала, например из выхода ProcessLogs или FilterLogs, и возвращает
+// их количество. При ошибке оставшиеся записи вычитываются без записи, чтобы не блокировать отправителя
+func WriteAll(w Writer, in <-chan model.LogEntry) (int, error) {
+	n := 0
+	var err error
+	for l := range in {
+		if err != nil {
+			continue
+		}
+		if err = w.Write(l); err == nil {
+			n++
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,308 @@
+package export
+
+import (
+	"bufio"           // Для чтения JSON Lines построчно
+	"bytes"           // Для выгрузки в память
+	"encoding/binary" // Для разбора Parquet
+	"encoding/json"   // Для проверки JSON Lines
+	"net/netip"       // Для реального IP клиента
+	"os"              // Для чтения выгруженного файла
+	"path/filepath"   // Для временных файлов
+	"testing"         // Cтандартная библиотека для тестов Go
+	"time"            // Для работы с датой и временем
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/processor"
+)
+
+var base = time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+// entries создаёт n записей: каждая третья — от бота, у первой — реальный IP клиента за прокси и GeoIP
+func entries(n int) []model.LogEntry {
+	logs := make([]model.LogEntry, n)
+	for i := range logs {
+		logs[i] = model.LogEntry{
+			Timestamp: base.Add(time.Duration(i) * time.Second), IP: "10.0.0.1", Method: "GET", URL: "/api/users/42?a=1&b=<x>",
+			StatusCode: 200 + i, ResponseTime: i * 100, UserAgent: "Mozilla/5.0, \"тест\"",
+			Client: model.ClientInfo{Browser: "Chrome", Version: "120", OS: "Windows", Device: "desktop", Bot: i%3 == 0},
+		}
+	}
+	logs[0].ForwardedFor = "203.0.113.9, 10.0.0.1"
+	logs[0].ClientAddr = netip.MustParseAddr("203.0.113.9")
+	logs[0].Country, logs[0].City, logs[0].ASN, logs[0].ASOrg = "DE", "Берлин", 64500, "Example AS"
+	return logs
+}
+
+// write выгружает записи в память
+func write(t *testing.T, format string, opts Options, logs []model.LogEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := New(&buf, format, opts)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	for _, l := range logs {
+		if err := w.Write(l); err != nil {
+			t.Fatalf("Неожиданная ошибка: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// ================================================ Тесты CSV и JSON Lines ================================================
+
+func TestCSVReadsBack(t *testing.T) {
+	logs := entries(5)
+	data := write(t, FormatCSV, Options{}, logs)
+
+	reader, err := processor.NewLogReader(bytes.NewReader(data)) // Выгрузка снова подаётся на вход
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	for i, want := range logs {
+		got, err := reader.Read()
+		if err != nil {
+			t.Fatalf("Строка %d: %v", i, err)
+		}
+		if !got.Timestamp.Equal(want.Timestamp) || got.IP != want.IP || got.URL != want.URL || got.StatusCode != want.StatusCode ||
+			got.ResponseTime != want.ResponseTime || got.ForwardedFor != want.ForwardedFor || got.UserAgent != want.UserAgent {
+			t.Errorf("Строка %d: получили %+v, ожидалось %+v", i, got, want)
+		}
+	}
+
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	if want := "timestamp,ip,method,url,status,response_time,x_forwarded_for,x_real_ip,user_agent,client_ip,route,country,city," +
+		"asn,as_org,browser,browser_version,os,device,bot,bot_name,bot_category,apdex"; string(header) != want {
+		t.Errorf("Неверный заголовок: %s", header)
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	data := write(t, FormatJSONL, Options{ApdexT: 100}, entries(3))
+	if !bytes.Contains(data, []byte(`"url":"/api/users/42?a=1&b=<x>"`)) {
+		t.Errorf("URL не должен экранироваться: %s", data)
+	}
+
+	var rows []map[string]any
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var row map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("Строка не в JSON: %v: %s", err, scanner.Bytes())
+		}
+		if len(row) != len(Columns) {
+			t.Errorf("Ожидалось %d полей, получили %d", len(Columns), len(row))
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Ожидалось 3 строки, получили %d", len(rows))
+	}
+	first := rows[0]
+	if first["timestamp"] != "2024-01-15T10:30:00Z" || first["client_ip"] != "203.0.113.9" || first["route"] != "/api/users/:id" ||
+		first["country"] != "DE" || first["asn"] != float64(64500) || first["bot"] != true || first["apdex"] != model.ApdexSatisfied {
+		t.Errorf("Неверная первая строка: %v", first)
+	}
+	if rows[1]["client_ip"] != "10.0.0.1" || rows[1]["apdex"] != model.ApdexSatisfied { // 100 мс при T = 100 мс — ещё удовлетворён
+		t.Errorf("Неверная вторая строка: %v", rows[1])
+	}
+	if rows[2]["apdex"] != model.ApdexTolerating {
+		t.Errorf("200 мс при T = 100 мс — терпимо, получили %v", rows[2]["apdex"])
+	}
+}
+
+func TestCreateAndWriteAll(t *testing.T) {
+	if _, err := FormatFromPath("out.txt"); err == nil {
+		t.Error("Ожидалась ошибка для неизвестного расширения")
+	}
+	if _, err := New(&bytes.Buffer{}, "xml", Options{}); err == nil {
+		t.Error("Ожидалась ошибка для неизвестного формата")
+	}
+
+	path := filepath.Join(t.TempDir(), "out.NDJSON")
+	w, err := Create(path, "", Options{})
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	in := make(chan model.LogEntry)
+	go func() {
+		defer close(in)
+		for _, l := range entries(4) {
+			in <- l
+		}
+	}()
+	n, err := WriteAll(w, in)
+	if err != nil || n != 4 {
+		t.Fatalf("Записано %d, ошибка %v", n, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := bytes.Count(data, []byte("\n")); lines != 4 {
+		t.Errorf("Ожидалось 4 строки в файле, получили %d", lines)
+	}
+}
+
+// ================================================ Тесты Parquet ================================================
+
+// thriftReader — независимый от писателя разбор Thrift Compact: структура — map номеров полей,
+// список — []any, целые — int64, строки — string
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case ctBoolTrue:
+		return true
+	case ctBoolFalse:
+		return false
+	case ctI32, ctI64:
+		return r.varint()
+	case ctBinary:
+		n := int(r.uvarint())
+		s := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case ctList:
+		header := r.data[r.pos]
+		r.pos++
+		n, elem := int(header>>4), header&0x0F
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(elem)
+		}
+		return list
+	case ctStruct:
+		return r.structure()
+	}
+	panic("неизвестный тип Thrift")
+}
+
+func (r *thriftReader) structure() map[int]any {
+	fields := map[int]any{}
+	last := 0
+	for {
+		header := r.data[r.pos]
+		r.pos++
+		if header == 0 {
+			return fields
+		}
+		id := last + int(header>>4)
+		if header>>4 == 0 {
+			id = int(r.varint())
+		}
+		fields[id] = r.value(header & 0x0F)
+		last = id
+	}
+}
+
+func TestParquet(t *testing.T) {
+	logs := entries(20)
+	data := write(t, FormatParquet, Options{RowGroupSize: 7}, logs)
+	if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatal("Нет сигнатуры PAR1")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta := (&thriftReader{data: data[len(data)-8-size : len(data)-8]}).structure()
+
+	if meta[3] != int64(20) {
+		t.Errorf("Ожидалось 20 строк, получили %v", meta[3])
+	}
+	schema := meta[2].([]any)
+	if len(schema) != len(Columns)+1 || schema[0].(map[int]any)[5] != int64(len(Columns)) {
+		t.Fatalf("Неверный корень схемы: %v", schema[0])
+	}
+	for i, c := range Columns {
+		el := schema[i+1].(map[int]any)
+		if el[4] != c.Name || el[3] != int64(repetitionRequired) {
+			t.Errorf("Колонка %d: %v", i, el)
+		}
+	}
+
+	groups := meta[4].([]any)
+	if len(groups) != 3 {
+		t.Fatalf("Ожидалось 3 группы по 7 строк, получили %d", len(groups))
+	}
+	column := func(name string) (kind Kind, values []any) { // Читает колонку из всех групп
+		for i, c := range Columns {
+			if c.Name != name {
+				continue
+			}
+			kind = c.Kind
+			for _, g := range groups {
+				chunk := g.(map[int]any)[1].([]any)[i].(map[int]any)[3].(map[int]any)
+				r := &thriftReader{data: data, pos: int(chunk[9].(int64))}
+				page := r.structure()
+				rows := int(page[5].(map[int]any)[1].(int64))
+				if chunk[5] != int64(rows) || page[2] != page[3] {
+					t.Errorf("Неверные размеры страницы %v", page)
+				}
+				body := data[r.pos : r.pos+int(page[2].(int64))]
+				for row := 0; row < rows; row++ {
+					switch kind {
+					case KindString:
+						n := int(binary.LittleEndian.Uint32(body))
+						values = append(values, string(body[4:4+n]))
+						body = body[4+n:]
+					case KindInt, KindTime:
+						values = append(values, int64(binary.LittleEndian.Uint64(body)))
+						body = body[8:]
+					case KindBool:
+						values = append(values, body[row/8]>>(row%8)&1 == 1)
+					}
+				}
+			}
+		}
+		return kind, values
+	}
+
+	_, urls := column("url")
+	_, statuses := column("status")
+	_, bots := column("bot")
+	_, times := column("timestamp")
+	_, cities := column("city")
+	if len(urls) != 20 || len(statuses) != 20 || len(bots) != 20 || len(times) != 20 {
+		t.Fatalf("Неверное число значений: %d %d %d %d", len(urls), len(statuses), len(bots), len(times))
+	}
+	for i, l := range logs {
+		if urls[i] != l.URL || statuses[i] != int64(l.StatusCode) || bots[i] != l.Client.Bot || times[i] != l.Timestamp.UnixMilli() {
+			t.Errorf("Строка %d: %v %v %v %v", i, urls[i], statuses[i], bots[i], times[i])
+		}
+	}
+	if cities[0] != "Берлин" || cities[1] != "" {
+		t.Errorf("Неверный город: %q %q", cities[0], cities[1])
+	}
+}
+
+func TestParquetEmpty(t *testing.T) {
+	data := write(t, FormatParquet, Options{}, nil)
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if string(data[:4]) != "PAR1" || 4+size+8 != len(data) {
+		t.Fatalf("Неверный пустой файл: %d байт, метаданные %d", len(data), size)
+	}
+	meta := (&thriftReader{data: data[4 : 4+size]}).structure()
+	if meta[3] != int64(0) || len(meta[4].([]any)) != 0 {
+		t.Errorf("Ожидался файл без строк: %v", meta)
+	}
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"bufio"         // Для буферизованной записи
+	"bytes"         // Для буфера строковых значений
+	"encoding/json" // Для экранирования строк
+	"io"            // Для записи в произвольный поток
+	"strconv"       // Для чисел и логических значений
+	"time"          // Для формата времени
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// jsonlWriter пишет по объекту JSON на строку. Поля идут в порядке Columns, время — в RFC 3339
+type jsonlWriter struct {
+	w      *bufio.Writer
+	opts   Options
+	buf    []byte
+	str    bytes.Buffer  // строка в JSON
+	encode *json.Encoder // пишет в str, не экранируя <, > и &: URL читаемы как есть
+}
+
+func newJSONLWriter(w io.Writer, opts Options) *jsonlWriter {
+	jw := &jsonlWriter{w: bufio.NewWriter(w), opts: opts}
+	jw.encode = json.NewEncoder(&jw.str)
+	jw.encode.SetEscapeHTML(false)
+	return jw
+}
+
+// Write добавляет строку с объектом записи
+func (w *jsonlWriter) Write(l model.LogEntry) error {
+	b := append(w.buf[:0], '{')
+	for i, c := range Columns {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendQuote(b, c.Name)
+		b = append(b, ':')
+		v := c.get(l, w.opts.ApdexT)
+		switch c.Kind {
+		case KindString:
+			w.str.Reset()
+			if err := w.encode.Encode(v.s); err != nil {
+				return err
+			}
+			b = append(b, bytes.TrimSuffix(w.str.Bytes(), []byte("\n"))...)
+		case KindInt:
+			b = strconv.AppendInt(b, v.i, 10)
+		case KindBool:
+			b = strconv.AppendBool(b, v.b)
+		case KindTime:
+			b = append(b, '"')
+			b = v.t.AppendFormat(b, time.RFC3339Nano)
+			b = append(b, '"')
+		}
+	}
+	b = append(b, '}', '\n')
+	w.buf = b
+	_, err := w.w.Write(b)
+	return err
+}
+
+// Close сбрасывает буфер в поток
+func (w *jsonlWriter) Close() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,245 @@
+package export
+
+import (
+	"encoding/binary" // Для значений в порядке little-endian
+	"io"              // Для записи в произвольный поток
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Parquet ================================================
+
+// Писатель Parquet без внешних зависимостей. Все колонки обязательные (REQUIRED) и плоские, значения
+// кодируются PLAIN без сжатия: на каждую колонку группы строк — одна страница данных. Строки хранятся как
+// BYTE_ARRAY с типом STRING, числа — INT64, время — INT64 с типом TIMESTAMP в миллисекундах UTC.
+// Формат описан в https://github.com/apache/parquet-format
+
+// DefaultRowGroupSize — строк в группе по умолчанию. Группа целиком держится в памяти до записи
+const DefaultRowGroupSize = 64 * 1024
+
+const parquetMagic = "PAR1"
+
+// Физические типы, кодировки и прочие перечисления из parquet.thrift
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	repetitionRequired = 0
+	convertedUTF8      = 0
+	convertedTimestamp = 9 // TIMESTAMP_MILLIS
+	pageData           = 0
+	codecUncompressed  = 0
+)
+
+// parquetWriter копит группу строк по колонкам и записывает её целиком
+type parquetWriter struct {
+	w       io.Writer
+	opts    Options
+	pos     int64    // сколько байт записано в поток
+	columns [][]byte // закодированные значения колонок текущей группы
+	rows    int      // строк в текущей группе
+	total   int64    // строк во всех группах
+	groups  []rowGroupMeta
+	started bool // записана сигнатура в начале файла
+}
+
+// rowGroupMeta — сведения о записанной группе строк для метаданных в конце файла
+type rowGroupMeta struct {
+	rows    int64
+	size    int64
+	columns []columnChunkMeta
+}
+
+type columnChunkMeta struct {
+	offset int64 // начало страницы данных
+	size   int64 // заголовок и данные страницы
+}
+
+func newParquetWriter(w io.Writer, opts Options) *parquetWriter {
+	if opts.RowGroupSize <= 0 {
+		opts.RowGroupSize = DefaultRowGroupSize
+	}
+	return &parquetWriter{w: w, opts: opts, columns: make([][]byte, len(Columns))}
+}
+
+// Write добавляет строку в группу и записывает группу, когда она заполнена
+func (w *parquetWriter) Write(l model.LogEntry) error {
+	for i, c := range Columns {
+		v := c.get(l, w.opts.ApdexT)
+		b := w.columns[i]
+		switch c.Kind {
+		case KindString:
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(v.s)))
+			b = append(b, v.s...)
+		case KindInt:
+			b = binary.LittleEndian.AppendUint64(b, uint64(v.i))
+		case KindBool: // Логические значения упакованы по биту, начиная с младшего
+			if w.rows%8 == 0 {
+				b = append(b, 0)
+			}
+			if v.b {
+				b[len(b)-1] |= 1 << (w.rows % 8)
+			}
+		case KindTime:
+			b = binary.LittleEndian.AppendUint64(b, uint64(v.t.UnixMilli()))
+		}
+		w.columns[i] = b
+	}
+	w.rows++
+	if w.rows >= w.opts.RowGroupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close записывает последнюю группу и метаданные файла
+func (w *parquetWriter) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	if err := w.start(); err != nil { // Файл без строк тоже должен быть корректным
+		return err
+	}
+	meta := w.footer()
+	meta = binary.LittleEndian.AppendUint32(meta, uint32(len(meta)))
+	return w.write(append(meta, parquetMagic...))
+}
+
+// write пишет данные в поток и сдвигает позицию
+func (w *parquetWriter) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.pos += int64(n)
+	return err
+}
+
+// start записывает сигнатуру в начало файла
+func (w *parquetWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	return w.write([]byte(parquetMagic))
+}
+
+// flush записывает накопленную группу строк: по странице данных на колонку
+func (w *parquetWriter) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+	group := rowGroupMeta{rows: int64(w.rows)}
+	for i, data := range w.columns {
+		var t thriftWriter
+		t.structBody(func() { // PageHeader
+			t.i32(1, pageData)
+			t.i32(2, int32(len(data)))
+			t.i32(3, int32(len(data)))
+			t.structField(5, func() { // DataPageHeader
+				t.i32(1, int32(w.rows))
+				t.i32(2, encodingPlain)
+				t.i32(3, encodingRLE) // Уровней определения и повторения нет: колонки обязательные и плоские
+				t.i32(4, encodingRLE)
+			})
+		})
+		chunk := columnChunkMeta{offset: w.pos, size: int64(len(t.buf) + len(data))}
+		if err := w.write(t.buf); err != nil {
+			return err
+		}
+		if err := w.write(data); err != nil {
+			return err
+		}
+		group.columns = append(group.columns, chunk)
+		group.size += chunk.size
+		w.columns[i] = data[:0]
+	}
+	w.groups = append(w.groups, group)
+	w.total += int64(w.rows)
+	w.rows = 0
+	return nil
+}
+
+// footer кодирует FileMetaData: схему, число строк и расположение колонок в группах
+func (w *parquetWriter) footer() []byte {
+	var t thriftWriter
+	t.structBody(func() {
+		t.i32(1, 1) // версия формата
+		t.list(2, ctStruct, len(Columns)+1)
+		t.structBody(func() { // Корень схемы
+			t.string(4, "schema")
+			t.i32(5, int32(len(Columns)))
+		})
+		for _, c := range Columns {
+			t.structBody(func() { writeSchemaElement(&t, c) })
+		}
+		t.i64(3, w.total)
+		t.list(4, ctStruct, len(w.groups))
+		for _, g := range w.groups {
+			t.structBody(func() { // RowGroup
+				t.list(1, ctStruct, len(g.columns))
+				for i, chunk := range g.columns {
+					t.structBody(func() { // ColumnChunk
+						t.i64(2, chunk.offset)
+						t.structField(3, func() { // ColumnMetaData
+							t.i32(1, physicalType(Columns[i].Kind))
+							t.list(2, ctI32, 2)
+							t.listI32(encodingPlain)
+							t.listI32(encodingRLE)
+							t.list(3, ctBinary, 1)
+							t.listString(Columns[i].Name)
+							t.i32(4, codecUncompressed)
+							t.i64(5, g.rows)
+							t.i64(6, chunk.size)
+							t.i64(7, chunk.size)
+							t.i64(9, chunk.offset)
+						})
+					})
+				}
+				t.i64(2, g.size)
+				t.i64(3, g.rows)
+			})
+		}
+		t.string(6, "Go-Log-Processor")
+	})
+	return t.buf
+}
+
+// writeSchemaElement кодирует описание колонки
+func writeSchemaElement(t *thriftWriter, c Column) {
+	t.i32(1, physicalType(c.Kind))
+	t.i32(3, repetitionRequired)
+	t.string(4, c.Name)
+	switch c.Kind {
+	case KindString:
+		t.i32(6, convertedUTF8)
+		t.structField(10, func() { // LogicalType
+			t.structField(1, func() {}) // STRING
+		})
+	case KindTime:
+		t.i32(6, convertedTimestamp)
+		t.structField(10, func() {
+			t.structField(8, func() { // TIMESTAMP
+				t.bool(1, true) // isAdjustedToUTC
+				t.structField(2, func() {
+					t.structField(1, func() {}) // MILLIS
+				})
+			})
+		})
+	}
+}
+
+// physicalType — физический тип Parquet для типа колонки
+func physicalType(k Kind) int32 {
+	switch k {
+	case KindString:
+		return parquetByteArray
+	case KindBool:
+		return parquetBoolean
+	}
+	return parquetInt64
+}
@@ -0,0 +1,97 @@
+package export
+
+import "encoding/binary" // Для varint
+
+// ================================================ Thrift Compact ================================================
+
+// Метаданные Parquet кодируются протоколом Thrift Compact. Здесь — только то, что нужно писателю:
+// целые, строки, логические значения, вложенные структуры и списки
+
+// Типы полей Thrift Compact
+const (
+	ctBoolTrue  = 1
+	ctBoolFalse = 2
+	ctI32       = 5
+	ctI64       = 6
+	ctBinary    = 8
+	ctList      = 9
+	ctStruct    = 12
+)
+
+// thriftWriter накапливает закодированную структуру в buf
+type thriftWriter struct {
+	buf  []byte
+	last int16 // номер предыдущего поля текущей структуры: заголовок поля хранит разницу номеров
+}
+
+// field пишет заголовок поля
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.buf = binary.AppendVarint(t.buf, int64(id)) // Номер поля — zigzag varint
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, ctI32)
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, ctI64)
+	t.buf = binary.AppendVarint(t.buf, v)
+}
+
+func (t *thriftWriter) bool(id int16, v bool) {
+	if v {
+		t.field(id, ctBoolTrue)
+	} else {
+		t.field(id, ctBoolFalse)
+	}
+}
+
+func (t *thriftWriter) string(id int16, s string) {
+	t.field(id, ctBinary)
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+// structField пишет вложенную структуру, поля которой записывает body
+func (t *thriftWriter) structField(id int16, body func()) {
+	t.field(id, ctStruct)
+	t.structBody(body)
+}
+
+// structBody пишет поля структуры и признак её конца; номера полей внутри отсчитываются заново
+func (t *thriftWriter) structBody(body func()) {
+	saved := t.last
+	t.last = 0
+	body()
+	t.buf = append(t.buf, 0)
+	t.last = saved
+}
+
+// list пишет заголовок списка из n элементов типа elem; элементы пишутся следом без заголовков полей
+func (t *thriftWriter) list(id int16, elem byte, n int) {
+	t.field(id, ctList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+	} else {
+		t.buf = append(t.buf, 0xF0|elem)
+		t.buf = binary.AppendUvarint(t.buf, uint64(n))
+	}
+}
+
+// listI32 пишет элемент списка целых
+func (t *thriftWriter) listI32(v int32) {
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+// listString пишет элемент списка строк
+func (t *thriftWriter) listString(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
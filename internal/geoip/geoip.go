@@ -0,0 +1,128 @@
+package geoip
+
+import (
+	"fmt"       // Для подписи автономной системы
+	"net/netip" // Для IP-адресов
+	"sync"      // Для защиты кэша
+)
+
+// ================================================ Геоданные адреса ================================================
+
+// Location — страна, город и автономная система IP-адреса. Пустые поля — данных нет
+type Location struct {
+	Country     string // ISO-код страны, например RU
+	CountryName string // название страны на английском
+	City        string // название города на английском
+	ASN         uint32 // номер автономной системы
+	ASOrg       string // организация, владеющая автономной системой
+}
+
+// ASLabel возвращает подпись автономной системы вида «AS13335 Cloudflare, Inc.» (пусто, если ASN неизвестен)
+func (l Location) ASLabel() string {
+	if l.ASN == 0 {
+		return ""
+	}
+	if l.ASOrg == "" {
+		return fmt.Sprintf("AS%d", l.ASN)
+	}
+	return fmt.Sprintf("AS%d %s", l.ASN, l.ASOrg)
+}
+
+// cacheSize — сколько адресов помнит DB; при переполнении кэш очищается целиком
+const cacheSize = 1 << 16
+
+// DB объединяет несколько баз, например GeoLite2-City и GeoLite2-ASN: каждое поле Location берётся
+// из первой базы, где оно есть. Результаты кэшируются — в логах адреса обычно повторяются
+type DB struct {
+	readers []*Reader
+
+	mu    sync.Mutex
+	cache map[netip.Addr]Location
+}
+
+// New создаёт DB из уже открытых баз
+func New(readers ...*Reader) *DB {
+	return &DB{readers: readers, cache: make(map[netip.Addr]Location)}
+}
+
+// OpenDB открывает базы из файлов
+func OpenDB(paths ...string) (*DB, error) {
+	readers := make([]*Reader, 0, len(paths))
+	for _, path := range paths {
+		r, err := Open(path)
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, r)
+	}
+	return New(readers...), nil
+}
+
+// Lookup возвращает геоданные адреса. Адрес, которого нет в базах, даёт пустой Location без ошибки
+func (db *DB) Lookup(addr netip.Addr) (Location, error) {
+	addr = addr.Unmap()
+	db.mu.Lock()
+	loc, ok := db.cache[addr]
+	db.mu.Unlock()
+	if ok {
+		return loc, nil
+	}
+
+	for _, r := range db.readers {
+		value, found, err := r.Lookup(addr)
+		if err != nil {
+			return Location{}, err
+		}
+		if found {
+			merge(&loc, value)
+		}
+	}
+
+	db.mu.Lock()
+	if len(db.cache) >= cacheSize {
+		db.cache = make(map[netip.Addr]Location)
+	}
+	db.cache[addr] = loc
+	db.mu.Unlock()
+	return loc, nil
+}
+
+// merge заполняет пустые поля loc из записи базы (схема GeoIP2/GeoLite2: City, Country, ASN)
+func merge(loc *Location, value any) {
+	if loc.Country == "" {
+		loc.Country = str(value, "country", "iso_code")
+		if loc.Country == "" { // Для анонимных сетей бывает только страна регистрации
+			loc.Country = str(value, "registered_country", "iso_code")
+		}
+	}
+	if loc.CountryName == "" {
+		loc.CountryName = str(value, "country", "names", "en")
+	}
+	if loc.City == "" {
+		loc.City = str(value, "city", "names", "en")
+	}
+	if loc.ASN == 0 {
+		loc.ASN = uint32(toUint(path(value, "autonomous_system_number")))
+	}
+	if loc.ASOrg == "" {
+		loc.ASOrg = str(value, "autonomous_system_organization")
+	}
+}
+
+// path достаёт значение по цепочке ключей вложенных словарей
+func path(value any, keys ...string) any {
+	for _, key := range keys {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// str — строковое значение по цепочке ключей (пусто, если его нет)
+func str(value any, keys ...string) string {
+	s, _ := path(value, keys...).(string)
+	return s
+}
@@ -0,0 +1,197 @@
+package geoip
+
+import (
+	"errors"        // Для проверки типа ошибки
+	"math/big"      // Для uint128
+	"net/netip"     // Для адресов
+	"os"            // Для временного файла базы
+	"path/filepath" // Для пути к временному файлу
+	"reflect"       // Для сравнения разобранных значений
+	"testing"       // Cтандартная библиотека для тестов Go
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/geoip/mmdbtest"
+)
+
+// ================================================ Вспомогательные функции ================================================
+
+// cityNetworks — записи в схеме GeoLite2-City
+var cityNetworks = []mmdbtest.Network{
+	{CIDR: "81.2.69.0/24", Data: map[string]any{
+		"country": map[string]any{"iso_code": "GB", "names": map[string]any{"en": "United Kingdom", "ru": "Великобритания"}},
+		"city":    map[string]any{"names": map[string]any{"en": "London"}},
+	}},
+	{CIDR: "81.2.70.0/23", Data: map[string]any{
+		"country": map[string]any{"iso_code": "GB", "names": map[string]any{"en": "United Kingdom", "ru": "Великобритания"}},
+	}},
+	{CIDR: "2001:db8:1::/48", Data: map[string]any{
+		"registered_country": map[string]any{"iso_code": "DE"},
+	}},
+}
+
+// asnNetworks — записи в схеме GeoLite2-ASN
+var asnNetworks = []mmdbtest.Network{
+	{CIDR: "81.2.64.0/20", Data: map[string]any{"autonomous_system_number": uint32(20712), "autonomous_system_organization": "Andrews & Arnold Ltd"}},
+	{CIDR: "2001:db8::/32", Data: map[string]any{"autonomous_system_number": uint32(64496)}},
+}
+
+func build(t *testing.T, opts mmdbtest.Options, networks []mmdbtest.Network) *Reader {
+	t.Helper()
+	buf, err := mmdbtest.Build(opts, networks)
+	if err != nil {
+		t.Fatalf("Ошибка сборки тестовой базы: %v", err)
+	}
+	r, err := FromBytes(buf)
+	if err != nil {
+		t.Fatalf("FromBytes вернул ошибку: %v", err)
+	}
+	return r
+}
+
+// ================================================ Тесты ридера ================================================
+
+func TestReaderRecordSizes(t *testing.T) {
+	for _, size := range []int{24, 28, 32} {
+		r := build(t, mmdbtest.Options{RecordSize: size, DatabaseType: "GeoLite2-City"}, cityNetworks)
+		if r.Metadata.RecordSize != uint(size) || r.Metadata.IPVersion != 6 || r.Metadata.DatabaseType != "GeoLite2-City" {
+			t.Errorf("Размер %d: неверные метаданные %+v", size, r.Metadata)
+		}
+
+		tests := map[string]string{
+			"81.2.69.160":      "London",
+			"::ffff:81.2.69.1": "London", // IPv4 в виде IPv6
+			"81.2.71.1":        "",       // Сеть без города
+			"2001:db8:1:2::1":  "",
+			"8.8.8.8":          "-", // Нет в базе
+			"2001:db8:2::1":    "-",
+		}
+		for ip, city := range tests {
+			value, found, err := r.Lookup(netip.MustParseAddr(ip))
+			if err != nil {
+				t.Errorf("Размер %d: Lookup(%s) вернул ошибку: %v", size, ip, err)
+				continue
+			}
+			if city == "-" {
+				if found {
+					t.Errorf("Размер %d: адреса %s нет в базе, но он найден: %v", size, ip, value)
+				}
+				continue
+			}
+			if !found || str(value, "city", "names", "en") != city {
+				t.Errorf("Размер %d: Lookup(%s) = %v, ожидался город %q", size, ip, value, city)
+			}
+		}
+	}
+}
+
+func TestReaderIPv4Database(t *testing.T) {
+	r := build(t, mmdbtest.Options{IPVersion: 4, RecordSize: 24}, cityNetworks[:2])
+
+	if value, found, err := r.Lookup(netip.MustParseAddr("81.2.69.5")); err != nil || !found || str(value, "country", "iso_code") != "GB" {
+		t.Errorf("Ожидалась запись GB, получили %v (%v, %v)", value, found, err)
+	}
+	if _, found, err := r.Lookup(netip.MustParseAddr("2001:db8::1")); err != nil || found {
+		t.Errorf("IPv6-адрес не должен находиться в IPv4-базе")
+	}
+}
+
+func TestDecodeTypes(t *testing.T) {
+	expected := map[string]any{
+		"string": "значение",
+		"double": 3.5,
+		"bool":   true,
+		"uint16": uint64(65535),
+		"uint32": uint64(1 << 20),
+		"uint64": uint64(1 << 40),
+		"int32":  int32(-5),
+		"array":  []any{"значение", uint64(0), false}, // Повторная строка записывается указателем
+		"long":   string(make([]byte, 300)),           // Длина с дополнительными байтами размера
+	}
+	r := build(t, mmdbtest.Options{}, []mmdbtest.Network{{CIDR: "10.0.0.0/8", Data: map[string]any{
+		"string": "значение", "double": 3.5, "bool": true, "uint16": uint16(65535), "uint32": uint32(1 << 20),
+		"uint64": uint64(1 << 40), "int32": int32(-5), "array": []any{"значение", uint32(0), false},
+		"long": string(make([]byte, 300)),
+	}}})
+
+	value, found, err := r.Lookup(netip.MustParseAddr("10.1.2.3"))
+	if err != nil || !found {
+		t.Fatalf("Запись не найдена: %v", err)
+	}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("Неверно разобраны значения:\n%#v\nожидалось\n%#v", value, expected)
+	}
+
+	// uint128 и float в тестовом сборщике не поддерживаются — проверяем декодер напрямую
+	v, _, err := decoder{buf: []byte{0x02, 0x03, 0x01, 0x00}}.decode(0, 0) // Расширенный тип 10 (uint128), 2 байта
+	if n, ok := v.(*big.Int); err != nil || !ok || n.Int64() != 256 {
+		t.Errorf("Ожидалось uint128 = 256, получили %v (%v)", v, err)
+	}
+	v, _, err = decoder{buf: []byte{0x04, 0x08, 0x3F, 0xC0, 0x00, 0x00}}.decode(0, 0) // Расширенный тип 15 (float)
+	if f, ok := v.(float32); err != nil || !ok || f != 1.5 {
+		t.Errorf("Ожидалось float = 1.5, получили %v (%v)", v, err)
+	}
+}
+
+func TestCorruptDatabase(t *testing.T) {
+	buf, err := mmdbtest.Build(mmdbtest.Options{}, cityNetworks)
+	if err != nil {
+		t.Fatalf("Ошибка сборки тестовой базы: %v", err)
+	}
+
+	if _, err := FromBytes([]byte("не база")); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Ожидалась ErrCorrupt для файла без метаданных, получили %v", err)
+	}
+	if _, err := FromBytes(buf[len(buf)/2:]); !errors.Is(err, ErrCorrupt) { // Обрезанный файл: дерево больше данных
+		t.Errorf("Ожидалась ErrCorrupt для обрезанного файла, получили %v", err)
+	}
+	if _, _, err := (decoder{buf: []byte{0x45, 'a'}}).decode(0, 0); !errors.Is(err, ErrCorrupt) { // Строка длиной 5, а байт 1
+		t.Errorf("Ожидалась ErrCorrupt для оборванной строки, получили %v", err)
+	}
+	if _, _, err := (decoder{buf: []byte{0x20, 0x00}}).decode(0, 0); !errors.Is(err, ErrCorrupt) { // Указатель сам на себя
+		t.Errorf("Ожидалась ErrCorrupt для зацикленного указателя, получили %v", err)
+	}
+}
+
+// ================================================ Тесты DB ================================================
+
+func TestDBLookup(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for name, networks := range map[string][]mmdbtest.Network{"city.mmdb": cityNetworks, "asn.mmdb": asnNetworks} {
+		buf, err := mmdbtest.Build(mmdbtest.Options{}, networks)
+		if err != nil {
+			t.Fatalf("Ошибка сборки тестовой базы: %v", err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, buf, 0644); err != nil {
+			t.Fatalf("Ошибка записи базы: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	db, err := OpenDB(paths...)
+	if err != nil {
+		t.Fatalf("OpenDB вернул ошибку: %v", err)
+	}
+
+	tests := map[string]Location{
+		"81.2.69.160":   {Country: "GB", CountryName: "United Kingdom", City: "London", ASN: 20712, ASOrg: "Andrews & Arnold Ltd"},
+		"2001:db8:1::5": {Country: "DE", ASN: 64496},
+		"8.8.8.8":       {},
+		"81.2.79.1":     {ASN: 20712, ASOrg: "Andrews & Arnold Ltd"}, // Есть только в базе ASN
+	}
+	for ip, expected := range tests {
+		for i := 0; i < 2; i++ { // Второй раз — из кэша
+			loc, err := db.Lookup(netip.MustParseAddr(ip))
+			if err != nil || loc != expected {
+				t.Errorf("Lookup(%s) = %+v (%v), ожидалось %+v", ip, loc, err, expected)
+			}
+		}
+	}
+
+	if label := tests["81.2.69.160"].ASLabel(); label != "AS20712 Andrews & Arnold Ltd" {
+		t.Errorf("Неверная подпись AS: %q", label)
+	}
+	if _, err := OpenDB(filepath.Join(dir, "нет.mmdb")); err == nil {
+		t.Errorf("Ожидалась ошибка для несуществующей базы")
+	}
+}
@@ -0,0 +1,410 @@
+// Чтение баз в формате MaxMind DB (.mmdb) без внешних зависимостей и без обращений к сети.
+// Формат: двоичное дерево поиска по битам IP-адреса, секция данных и блок метаданных в конце файла.
+
+package geoip
+
+import (
+	"bytes"           // Для поиска блока метаданных
+	"encoding/binary" // Для чтения чисел big-endian
+	"fmt"             // Для пути к файлу в ошибке
+	"math"            // Для чисел с плавающей точкой
+	"math/big"        // Для uint128
+	"net/netip"       // Для IP-адресов
+	"os"              // Для чтения файла базы
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+)
+
+// ================================================ Ридер базы ================================================
+
+// metadataMarker предшествует блоку метаданных в конце файла
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// ErrCorrupt — файл повреждён или не является базой MaxMind DB
+var ErrCorrupt error = i18n.Error("geoip.corrupt")
+
+// Metadata — описание базы из блока метаданных
+type Metadata struct {
+	NodeCount    uint              // количество узлов дерева поиска
+	RecordSize   uint              // размер записи узла в битах: 24, 28 или 32
+	IPVersion    uint              // 4 или 6
+	DatabaseType string            // например GeoLite2-City
+	Languages    []string          // языки названий
+	BuildEpoch   uint64            // время сборки (Unix)
+	Description  map[string]string // описание по языкам
+}
+
+// Reader ищет записи в базе, целиком загруженной в память. Безопасен для параллельного использования
+type Reader struct {
+	Metadata Metadata
+
+	tree      []byte // дерево поиска
+	data      []byte // секция данных
+	ipv4Start uint   // узел, с которого начинается поиск IPv4-адреса в IPv6-дереве (::/96)
+}
+
+// Open читает базу из файла
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, i18n.Errorf("geoip.read", err)
+	}
+	r, err := FromBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// FromBytes разбирает базу из среза байт (срез не копируется)
+func FromBytes(buf []byte) (*Reader, error) {
+	start := bytes.LastIndex(buf, metadataMarker)
+	if start < 0 {
+		return nil, i18n.Errorf("geoip.no_metadata", ErrCorrupt)
+	}
+
+	raw, _, err := decoder{buf: buf[start+len(metadataMarker):]}.decode(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := parseMetadata(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	treeSize := meta.NodeCount * meta.RecordSize / 4 // Узел — две записи по RecordSize бит
+	if treeSize+16 > uint(start) {                   // За деревом идут 16 нулевых байт-разделителей
+		return nil, i18n.Errorf("geoip.tree_size", ErrCorrupt)
+	}
+
+	r := &Reader{Metadata: meta, tree: buf[:treeSize], data: buf[treeSize+16 : start]}
+	if meta.IPVersion == 6 { // IPv4-адреса хранятся в IPv6-дереве как ::a.b.c.d — проходим 96 нулевых бит заранее
+		node := uint(0)
+		for i := 0; i < 96 && node < meta.NodeCount; i++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// parseMetadata проверяет и переносит метаданные в структуру
+func parseMetadata(raw any) (Metadata, error) {
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return Metadata{}, i18n.Errorf("geoip.metadata_map", ErrCorrupt)
+	}
+
+	meta := Metadata{
+		NodeCount:   uint(toUint(m["node_count"])),
+		RecordSize:  uint(toUint(m["record_size"])),
+		IPVersion:   uint(toUint(m["ip_version"])),
+		BuildEpoch:  toUint(m["build_epoch"]),
+		Description: make(map[string]string),
+	}
+	meta.DatabaseType, _ = m["database_type"].(string)
+	if langs, ok := m["languages"].([]any); ok {
+		for _, l := range langs {
+			if s, ok := l.(string); ok {
+				meta.Languages = append(meta.Languages, s)
+			}
+		}
+	}
+	if desc, ok := m["description"].(map[string]any); ok {
+		for lang, text := range desc {
+			if s, ok := text.(string); ok {
+				meta.Description[lang] = s
+			}
+		}
+	}
+
+	if meta.RecordSize != 24 && meta.RecordSize != 28 && meta.RecordSize != 32 {
+		return Metadata{}, i18n.Errorf("geoip.record_size", ErrCorrupt, meta.RecordSize)
+	}
+	if meta.IPVersion != 4 && meta.IPVersion != 6 {
+		return Metadata{}, i18n.Errorf("geoip.ip_version", ErrCorrupt, meta.IPVersion)
+	}
+	return meta, nil
+}
+
+// Lookup возвращает запись для адреса: словари map[string]any, массивы []any, строки, числа (uint64, int32,
+// float64, float32, *big.Int) и bool. found == false, если адрес не входит ни в одну сеть базы
+func (r *Reader) Lookup(addr netip.Addr) (value any, found bool, err error) {
+	addr = addr.Unmap()
+	var ip []byte
+	node := uint(0)
+	switch {
+	case addr.Is4():
+		b := addr.As4()
+		ip = b[:]
+		if r.Metadata.IPVersion == 6 {
+			node = r.ipv4Start
+		}
+	case addr.Is6():
+		if r.Metadata.IPVersion == 4 { // В IPv4-базе IPv6-адресов нет
+			return nil, false, nil
+		}
+		b := addr.As16()
+		ip = b[:]
+	default:
+		return nil, false, nil
+	}
+
+	for i := 0; i < len(ip)*8 && node < r.Metadata.NodeCount; i++ {
+		bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+		node = r.record(node, bit)
+	}
+
+	switch {
+	case node == r.Metadata.NodeCount: // Пустая запись — сеть не найдена
+		return nil, false, nil
+	case node < r.Metadata.NodeCount: // Адрес закончился, а дерево — нет
+		return nil, false, i18n.Errorf("geoip.tree_depth", ErrCorrupt)
+	}
+
+	offset := node - r.Metadata.NodeCount - 16 // Ссылка на данные отсчитывается от конца дерева вместе с разделителем
+	if offset >= uint(len(r.data)) {
+		return nil, false, i18n.Errorf("geoip.bad_pointer", ErrCorrupt)
+	}
+	value, _, err = decoder{buf: r.data}.decode(offset, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// record читает левую (bit == 0) или правую запись узла
+func (r *Reader) record(node uint, bit byte) uint {
+	t := r.tree
+	switch r.Metadata.RecordSize {
+	case 24:
+		off := node*6 + uint(bit)*3
+		return uint(t[off])<<16 | uint(t[off+1])<<8 | uint(t[off+2])
+	case 28: // Старшие 4 бита обеих записей лежат в среднем байте узла
+		off := node * 7
+		if bit == 0 {
+			return uint(t[off+3]&0xF0)<<20 | uint(t[off])<<16 | uint(t[off+1])<<8 | uint(t[off+2])
+		}
+		return uint(t[off+3]&0x0F)<<24 | uint(t[off+4])<<16 | uint(t[off+5])<<8 | uint(t[off+6])
+	default:
+		off := node*8 + uint(bit)*4
+		return uint(binary.BigEndian.Uint32(t[off:]))
+	}
+}
+
+// ================================================ Декодер секции данных ================================================
+
+// Типы значений секции данных
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// maxDepth ограничивает вложенность, чтобы повреждённый файл не привёл к бесконечной рекурсии
+const maxDepth = 64
+
+// decoder разбирает значения секции; смещения указателей отсчитываются от начала buf
+type decoder struct {
+	buf []byte
+}
+
+// decode разбирает значение по смещению off и возвращает его и смещение следующего значения
+func (d decoder) decode(off uint, depth int) (any, uint, error) {
+	if depth > maxDepth {
+		return nil, 0, i18n.Errorf("geoip.nesting", ErrCorrupt)
+	}
+	if err := d.need(off, 1); err != nil {
+		return nil, 0, err
+	}
+	ctrl := d.buf[off]
+	off++
+
+	typ := int(ctrl >> 5)
+	if typ == typePointer { // Указатель: значение лежит в другом месте, а разбор продолжается после указателя
+		target, next, err := d.pointer(ctrl, off)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(target, depth+1)
+		return value, next, err
+	}
+	if typ == typeExtended {
+		if err := d.need(off, 1); err != nil {
+			return nil, 0, err
+		}
+		typ = 7 + int(d.buf[off])
+		off++
+	}
+
+	size, off, err := d.size(ctrl, off)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch typ {
+	case typeMap:
+		m := make(map[string]any, size)
+		for i := uint(0); i < size; i++ {
+			var key, value any
+			if key, off, err = d.decode(off, depth+1); err != nil {
+				return nil, 0, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, 0, i18n.Errorf("geoip.map_key", ErrCorrupt)
+			}
+			if value, off, err = d.decode(off, depth+1); err != nil {
+				return nil, 0, err
+			}
+			m[k] = value
+		}
+		return m, off, nil
+
+	case typeArray:
+		a := make([]any, 0, size)
+		for i := uint(0); i < size; i++ {
+			var value any
+			if value, off, err = d.decode(off, depth+1); err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+		}
+		return a, off, nil
+
+	case typeBool: // Значение хранится прямо в поле размера
+		if size > 1 {
+			return nil, 0, i18n.Errorf("geoip.bad_bool", ErrCorrupt)
+		}
+		return size == 1, off, nil
+	}
+
+	if err := d.need(off, size); err != nil {
+		return nil, 0, err
+	}
+	payload := d.buf[off : off+size]
+	off += size
+
+	switch typ {
+	case typeString:
+		return string(payload), off, nil
+	case typeBytes:
+		return append([]byte(nil), payload...), off, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, i18n.Errorf("geoip.double_size", ErrCorrupt)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(payload)), off, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, i18n.Errorf("geoip.float_size", ErrCorrupt)
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(payload)), off, nil
+	case typeUint16, typeUint32, typeUint64:
+		limit := map[int]uint{typeUint16: 2, typeUint32: 4, typeUint64: 8}[typ]
+		if size > limit {
+			return nil, 0, i18n.Errorf("geoip.int_size", ErrCorrupt)
+		}
+		var v uint64
+		for _, b := range payload {
+			v = v<<8 | uint64(b)
+		}
+		return v, off, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, i18n.Errorf("geoip.int_size", ErrCorrupt)
+		}
+		var v uint32
+		for _, b := range payload {
+			v = v<<8 | uint32(b)
+		}
+		return int32(v), off, nil
+	case typeUint128:
+		if size > 16 {
+			return nil, 0, i18n.Errorf("geoip.int_size", ErrCorrupt)
+		}
+		return new(big.Int).SetBytes(payload), off, nil
+	}
+	return nil, 0, i18n.Errorf("geoip.data_type", ErrCorrupt, typ)
+}
+
+// size разбирает размер значения из управляющего байта и, при необходимости, следующих байт
+func (d decoder) size(ctrl byte, off uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1F)
+	if size < 29 {
+		return size, off, nil
+	}
+
+	n := size - 28 // 29 — один дополнительный байт, 30 — два, 31 — три
+	if err := d.need(off, n); err != nil {
+		return 0, 0, err
+	}
+	var extra uint
+	for _, b := range d.buf[off : off+n] {
+		extra = extra<<8 | uint(b)
+	}
+	switch size {
+	case 29:
+		return 29 + extra, off + n, nil
+	case 30:
+		return 285 + extra, off + n, nil
+	}
+	return 65821 + extra, off + n, nil
+}
+
+// pointer разбирает указатель: 2 бита управляющего байта задают длину, ещё 3 бита — старшие биты адреса
+func (d decoder) pointer(ctrl byte, off uint) (uint, uint, error) {
+	n := uint((ctrl>>3)&0x3) + 1
+	if err := d.need(off, n); err != nil {
+		return 0, 0, err
+	}
+	b := d.buf[off : off+n]
+	high := uint(ctrl & 0x7)
+
+	var target uint
+	switch n {
+	case 1:
+		target = high<<8 | uint(b[0])
+	case 2:
+		target = (high<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+	case 3:
+		target = (high<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+	default:
+		target = uint(binary.BigEndian.Uint32(b))
+	}
+	return target, off + n, nil
+}
+
+// need проверяет, что в буфере есть n байт начиная с off
+func (d decoder) need(off, n uint) error {
+	if off > uint(len(d.buf)) || n > uint(len(d.buf))-off {
+		return i18n.Errorf("geoip.truncated", ErrCorrupt)
+	}
+	return nil
+}
+
+// toUint приводит беззнаковое число из метаданных к uint64 (0 для других типов)
+func toUint(v any) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int32:
+		if n >= 0 {
+			return uint64(n)
+		}
+	}
+	return 0
+}
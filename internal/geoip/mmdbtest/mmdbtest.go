@@ -0,0 +1,273 @@
+// Сборка небольших баз в формате MaxMind DB для тестов: настоящие базы большие и лицензируются отдельно.
+
+package mmdbtest
+
+import (
+	"bytes"           // Для сборки файла
+	"encoding/binary" // Для записи чисел big-endian
+	"fmt"             // Для форматирования ошибок
+	"math"            // Для double
+	"net/netip"       // Для подсетей
+	"sort"            // Для детерминированного порядка ключей
+)
+
+// Network — подсеть и запись базы для неё
+type Network struct {
+	CIDR string
+	Data map[string]any
+}
+
+// Options — параметры базы
+type Options struct {
+	IPVersion    int    // 4 или 6 (по умолчанию 6)
+	RecordSize   int    // 24, 28 или 32 (по умолчанию 28)
+	DatabaseType string // по умолчанию Test
+}
+
+// record — ссылка в узле дерева: пусто, другой узел или запись данных
+type record struct {
+	kind  int // 0 — пусто, 1 — узел, 2 — данные
+	index int
+}
+
+// Build собирает базу. Значения записей: string, map[string]any, []any, bool, float64,
+// uint16, uint32, uint64, int (как uint32) и int32. Вложенные подсети не поддерживаются
+func Build(opts Options, networks []Network) ([]byte, error) {
+	if opts.IPVersion == 0 {
+		opts.IPVersion = 6
+	}
+	if opts.RecordSize == 0 {
+		opts.RecordSize = 28
+	}
+	if opts.DatabaseType == "" {
+		opts.DatabaseType = "Test"
+	}
+
+	nodes := [][2]record{{}}
+	for i, n := range networks {
+		prefix, err := netip.ParsePrefix(n.CIDR)
+		if err != nil {
+			return nil, err
+		}
+		ip, bits := prefix.Addr().AsSlice(), prefix.Bits()
+		if prefix.Addr().Is4() && opts.IPVersion == 6 { // IPv4 хранится в IPv6-дереве как ::a.b.c.d
+			ip, bits = append(make([]byte, 12), ip...), bits+96
+		}
+		if prefix.Addr().Is6() && opts.IPVersion == 4 {
+			return nil, fmt.Errorf("IPv6-подсеть %s в IPv4-базе", n.CIDR)
+		}
+		if bits == 0 {
+			return nil, fmt.Errorf("подсеть %s нулевой длины", n.CIDR)
+		}
+
+		node := 0
+		for depth := 0; depth < bits; depth++ {
+			bit := (ip[depth/8] >> (7 - uint(depth%8))) & 1
+			rec := nodes[node][bit]
+			if depth == bits-1 {
+				if rec.kind != 0 {
+					return nil, fmt.Errorf("подсеть %s пересекается с другой", n.CIDR)
+				}
+				nodes[node][bit] = record{kind: 2, index: i}
+				break
+			}
+			switch rec.kind {
+			case 2:
+				return nil, fmt.Errorf("подсеть %s пересекается с другой", n.CIDR)
+			case 0:
+				nodes = append(nodes, [2]record{})
+				rec = record{kind: 1, index: len(nodes) - 1}
+				nodes[node][bit] = rec
+			}
+			node = rec.index
+		}
+	}
+
+	data := &encoder{strings: make(map[string]int)}
+	offsets := make([]int, len(networks))
+	for i, n := range networks {
+		offsets[i] = data.buf.Len()
+		if err := data.encode(n.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	nodeCount := len(nodes)
+	for _, node := range nodes {
+		var values [2]uint64
+		for b, rec := range node {
+			switch rec.kind {
+			case 0:
+				values[b] = uint64(nodeCount)
+			case 1:
+				values[b] = uint64(rec.index)
+			case 2:
+				values[b] = uint64(nodeCount + 16 + offsets[rec.index])
+			}
+			if values[b] >= 1<<opts.RecordSize {
+				return nil, fmt.Errorf("значение %d не помещается в запись %d бит", values[b], opts.RecordSize)
+			}
+		}
+		writeNode(&out, opts.RecordSize, values[0], values[1])
+	}
+	out.Write(make([]byte, 16)) // Разделитель дерева и секции данных
+	out.Write(data.buf.Bytes())
+
+	out.WriteString("\xAB\xCD\xEFMaxMind.com")
+	meta := &encoder{}
+	err := meta.encode(map[string]any{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(opts.RecordSize),
+		"ip_version":                  uint16(opts.IPVersion),
+		"database_type":               opts.DatabaseType,
+		"languages":                   []any{"en"},
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1700000000),
+		"description":                 map[string]any{"en": "Test database"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	out.Write(meta.buf.Bytes())
+	return out.Bytes(), nil
+}
+
+// writeNode записывает узел из двух записей заданного размера
+func writeNode(out *bytes.Buffer, size int, left, right uint64) {
+	switch size {
+	case 24:
+		out.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)})
+	case 28:
+		out.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left),
+			byte(left>>20)&0xF0 | byte(right>>24)&0x0F,
+			byte(right >> 16), byte(right >> 8), byte(right)})
+	default:
+		out.Write([]byte{byte(left >> 24), byte(left >> 16), byte(left >> 8), byte(left),
+			byte(right >> 24), byte(right >> 16), byte(right >> 8), byte(right)})
+	}
+}
+
+// ================================================ Кодирование данных ================================================
+
+// encoder пишет значения секции данных. Повторяющиеся строки заменяются указателями, как в настоящих базах
+type encoder struct {
+	buf     bytes.Buffer
+	strings map[string]int // смещения уже записанных строк (nil — без указателей)
+}
+
+func (e *encoder) encode(v any) error {
+	switch v := v.(type) {
+	case string:
+		if off, ok := e.strings[v]; ok && len(v) >= 4 {
+			e.pointer(off)
+			return nil
+		}
+		if e.strings != nil {
+			e.strings[v] = e.buf.Len()
+		}
+		e.header(2, len(v))
+		e.buf.WriteString(v)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		e.header(7, len(v))
+		for _, k := range keys {
+			if err := e.encode(k); err != nil {
+				return err
+			}
+			if err := e.encode(v[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		e.header(11, len(v))
+		for _, item := range v {
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		e.header(14, size)
+	case float64:
+		e.header(3, 8)
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(v)))
+	case uint16:
+		e.uint(5, uint64(v))
+	case uint32:
+		e.uint(6, uint64(v))
+	case int:
+		if v < 0 {
+			return fmt.Errorf("отрицательное число %d: используйте int32", v)
+		}
+		e.uint(6, uint64(v))
+	case uint64:
+		e.uint(9, v)
+	case int32:
+		e.header(8, 4)
+		e.buf.Write([]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+	default:
+		return fmt.Errorf("неподдерживаемый тип %T", v)
+	}
+	return nil
+}
+
+// uint пишет беззнаковое число минимальным количеством байт
+func (e *encoder) uint(typ int, v uint64) {
+	var b []byte
+	for ; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	e.header(typ, len(b))
+	e.buf.Write(b)
+}
+
+// header пишет управляющий байт с типом и размером
+func (e *encoder) header(typ, size int) {
+	var sizeBits byte
+	var extra []byte
+	switch {
+	case size < 29:
+		sizeBits = byte(size)
+	case size < 285:
+		sizeBits, extra = 29, []byte{byte(size - 29)}
+	case size < 65821:
+		s := size - 285
+		sizeBits, extra = 30, []byte{byte(s >> 8), byte(s)}
+	default:
+		s := size - 65821
+		sizeBits, extra = 31, []byte{byte(s >> 16), byte(s >> 8), byte(s)}
+	}
+
+	if typ < 8 {
+		e.buf.WriteByte(byte(typ)<<5 | sizeBits)
+	} else { // Расширенный тип: номер типа во втором байте
+		e.buf.WriteByte(sizeBits)
+		e.buf.WriteByte(byte(typ - 7))
+	}
+	e.buf.Write(extra)
+}
+
+// pointer пишет указатель на уже записанное значение
+func (e *encoder) pointer(off int) {
+	switch {
+	case off < 2048:
+		e.buf.Write([]byte{1<<5 | byte(off>>8), byte(off)})
+	case off < 526336:
+		v := off - 2048
+		e.buf.Write([]byte{1<<5 | 1<<3 | byte(v>>16), byte(v >> 8), byte(v)})
+	case off < 134744064:
+		v := off - 526336
+		e.buf.Write([]byte{1<<5 | 2<<3 | byte(v>>24), byte(v >> 16), byte(v >> 8), byte(v)})
+	default:
+		e.buf.Write([]byte{1<<5 | 3<<3, byte(off >> 24), byte(off >> 16), byte(off >> 8), byte(off)})
+	}
+}
@@ -0,0 +1,159 @@
+package i18n
+
+import (
+	"fmt"          // Для форматирования по глаголу шаблона
+	"strconv"      // Для дробной части длительностей
+	"strings"      // Для сборки чисел
+	"time"         // Для длительностей
+	"unicode/utf8" // Для ширины поля в символах
+)
+
+// ================================================ Числа ================================================
+
+// number — числовой аргумент сообщения: форматируется по глаголу шаблона (%d, %.2f, %5d...),
+// затем получает разделители разрядов и дробной части языка
+type number struct {
+	p *Printer
+	v any
+}
+
+// duration — аргумент-длительность: для %s и %v печатается словами языка
+type duration struct {
+	p *Printer
+	d time.Duration
+}
+
+// localize оборачивает числа и длительности среди аргументов; остальные аргументы остаются как есть
+func (p *Printer) localize(args []any) []any {
+	out := make([]any, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			out[i] = number{p, v}
+		case time.Duration:
+			out[i] = duration{p, v}
+		default:
+			out[i] = arg
+		}
+	}
+	return out
+}
+
+// Format реализует fmt.Formatter
+func (n number) Format(f fmt.State, verb rune) {
+	if f.Flag('0') || (verb != 'd' && verb != 'f' && verb != 'v') { // Нули слева и прочие глаголы (%x, %e...) — как в fmt
+		fmt.Fprintf(f, fmt.FormatString(f, verb), n.v)
+		return
+	}
+	spec := "%"
+	for _, flag := range "+ " {
+		if f.Flag(int(flag)) {
+			spec += string(flag)
+		}
+	}
+	if prec, ok := f.Precision(); ok {
+		spec += "." + strconv.Itoa(prec)
+	}
+	writePadded(f, n.p.localNumber(fmt.Sprintf(spec+string(verb), n.v)))
+}
+
+// Format реализует fmt.Formatter
+func (d duration) Format(f fmt.State, verb rune) {
+	if verb != 's' && verb != 'v' {
+		fmt.Fprintf(f, fmt.FormatString(f, verb), int64(d.d))
+		return
+	}
+	writePadded(f, d.p.Duration(d.d))
+}
+
+// writePadded печатает s с учётом ширины поля и флага «-»
+func writePadded(f fmt.State, s string) {
+	if width, ok := f.Width(); ok {
+		if pad := width - utf8.RuneCountInString(s); pad > 0 {
+			if f.Flag('-') {
+				s += strings.Repeat(" ", pad)
+			} else {
+				s = strings.Repeat(" ", pad) + s
+			}
+		}
+	}
+	f.Write([]byte(s))
+}
+
+// localNumber расставляет разделители языка в числе, отформатированном fmt: "-12345.67" → "-12 345,67".
+// Строки, которые не похожи на десятичное число (NaN, +Inf, 1e+06), не меняются
+func (p *Printer) localNumber(s string) string {
+	sign := ""
+	if s != "" && (s[0] == '-' || s[0] == '+' || s[0] == ' ') {
+		sign, s = s[:1], s[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" || strings.Trim(whole, "0123456789") != "" || strings.Trim(frac, "0123456789") != "" {
+		return sign + s
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	group := p.message("number.group")
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteString(group)
+		}
+		sb.WriteRune(digit)
+	}
+	if hasFrac {
+		sb.WriteString(p.message("number.decimal"))
+		sb.WriteString(frac)
+	}
+	return sb.String()
+}
+
+// Number форматирует число с digits знаками после запятой
+func (p *Printer) Number(v float64, digits int) string {
+	p = p.or()
+	return p.localNumber(strconv.FormatFloat(v, 'f', digits, 64))
+}
+
+// ================================================ Длительности ================================================
+
+// Duration печатает длительность словами языка: «1 д 2 ч», «1 мин 30 с», «1,5 с», «250 мс».
+// Секунды и миллисекунды дробные только если у длительности есть более мелкая часть
+func (p *Printer) Duration(d time.Duration) string {
+	p = p.or()
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	if d < time.Second {
+		return sign + p.unit(p.trimmed(float64(d)/float64(time.Millisecond), 3), "unit.millisecond")
+	}
+
+	var parts []string
+	for _, u := range []struct {
+		size time.Duration
+		key  string
+	}{{24 * time.Hour, "unit.day"}, {time.Hour, "unit.hour"}, {time.Minute, "unit.minute"}} {
+		if n := d / u.size; n > 0 {
+			parts = append(parts, p.unit(p.localNumber(strconv.FormatInt(int64(n), 10)), u.key))
+			d -= n * u.size
+		}
+	}
+	if d > 0 {
+		parts = append(parts, p.unit(p.trimmed(d.Seconds(), 3), "unit.second"))
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+// unit — значение с единицей измерения из каталога через неразрывный пробел, чтобы они не разделялись при переносе
+func (p *Printer) unit(value, key string) string {
+	return value + "\u00a0" + p.message(key)
+}
+
+// trimmed форматирует число с не более чем digits знаками после запятой, отбрасывая нули в конце
+func (p *Printer) trimmed(v float64, digits int) string {
+	s := strconv.FormatFloat(v, 'f', digits, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return p.localNumber(s)
+}
@@ -0,0 +1,175 @@
+// Пакет i18n — каталоги сообщений на русском и английском и форматирование чисел и длительностей по языку.
+
+package i18n
+
+import (
+	"embed"         // Для встроенных каталогов
+	"encoding/json" // Для разбора каталогов
+	"fmt"           // Для подстановки аргументов
+	"os"            // Для переменных окружения LANG и LC_*
+	"strings"       // Для разбора названия языка
+	"sync/atomic"   // Для языка по умолчанию, который читают воркеры
+)
+
+// ================================================ Языки ================================================
+
+// Lang — язык вывода
+type Lang string
+
+const (
+	RU Lang = "ru" // русский (по умолчанию)
+	EN Lang = "en" // английский
+)
+
+// Languages — все языки, для которых есть каталог
+var Languages = []Lang{RU, EN}
+
+// Parse разбирает название языка: "en", "EN", "en_US.UTF-8", "ru-RU"
+func Parse(s string) (Lang, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexAny(name, "_-.@"); i >= 0 { // Регион, кодировка и модификатор не важны
+		name = name[:i]
+	}
+	for _, lang := range Languages {
+		if name == string(lang) {
+			return lang, nil
+		}
+	}
+	return "", Errorf("lang.unknown", s)
+}
+
+// FromEnv определяет язык по переменным LC_ALL, LC_MESSAGES и LANG (первая заданная с известным языком);
+// если ни одна не подходит (например, LANG=C), возвращает RU
+func FromEnv() Lang {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang, err := Parse(os.Getenv(name)); err == nil {
+			return lang
+		}
+	}
+	return RU
+}
+
+// ================================================ Каталоги ================================================
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs — сообщения по языку и ключу. Загружаются при старте: ошибка в каталоге — ошибка сборки, её ловят тесты
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[Lang]map[string]string {
+	catalogs := make(map[Lang]map[string]string, len(Languages))
+	for _, lang := range Languages {
+		data, err := localeFiles.ReadFile("locales/" + string(lang) + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: нет каталога %s: %v", lang, err))
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: ошибка в каталоге %s: %v", lang, err))
+		}
+		catalogs[lang] = messages
+	}
+	return catalogs
+}
+
+// Keys возвращает ключи каталога языка
+func Keys(lang Lang) []string {
+	keys := make([]string, 0, len(catalogs[lang]))
+	for key := range catalogs[lang] {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ================================================ Перевод ================================================
+
+// Printer подставляет аргументы в сообщения каталога одного языка. Числа и длительности среди аргументов
+// форматируются по правилам языка: 12 345,6 и «1 ч 30 мин» по-русски, 12,345.6 и «1 h 30 min» по-английски.
+// Nil-Printer работает на языке по умолчанию, поэтому его можно не передавать
+type Printer struct {
+	lang     Lang
+	messages map[string]string
+}
+
+// New создаёт Printer для языка; для неизвестного языка используется русский
+func New(lang Lang) *Printer {
+	messages, ok := catalogs[lang]
+	if !ok {
+		lang, messages = RU, catalogs[RU]
+	}
+	return &Printer{lang: lang, messages: messages}
+}
+
+// Lang — язык Printer
+func (p *Printer) Lang() Lang {
+	return p.or().lang
+}
+
+// or возвращает p или, если он nil, Printer языка по умолчанию
+func (p *Printer) or() *Printer {
+	if p == nil {
+		return Default()
+	}
+	return p
+}
+
+// message возвращает шаблон сообщения; если ключа нет, берётся русский вариант, а если нет и его — сам ключ
+func (p *Printer) message(key string) string {
+	p = p.or()
+	if m, ok := p.messages[key]; ok {
+		return m
+	}
+	if m, ok := catalogs[RU][key]; ok {
+		return m
+	}
+	return key
+}
+
+// Sprintf возвращает сообщение key с подставленными аргументами
+func (p *Printer) Sprintf(key string, args ...any) string {
+	p = p.or()
+	return fmt.Sprintf(p.message(key), p.localize(args)...)
+}
+
+// Errorf возвращает ошибку с сообщением key; %w в шаблоне работает как в fmt.Errorf
+func (p *Printer) Errorf(key string, args ...any) error {
+	p = p.or()
+	return fmt.Errorf(p.message(key), p.localize(args)...)
+}
+
+// ================================================ Язык по умолчанию ================================================
+
+var defaultPrinter atomic.Pointer[Printer]
+
+func init() {
+	defaultPrinter.Store(New(RU))
+}
+
+// SetDefault задаёт язык сообщений, для которых он не передаётся явно (ошибки загрузки, сообщения воркеров, отчёт)
+func SetDefault(lang Lang) {
+	defaultPrinter.Store(New(lang))
+}
+
+// Default возвращает Printer языка по умолчанию
+func Default() *Printer {
+	return defaultPrinter.Load()
+}
+
+// T — сообщение key на языке по умолчанию
+func T(key string, args ...any) string {
+	return Default().Sprintf(key, args...)
+}
+
+// Errorf — ошибка с сообщением key на языке по умолчанию
+func Errorf(key string, args ...any) error {
+	return Default().Errorf(key, args...)
+}
+
+// Error — ошибка-константа с ключом сообщения: текст берётся на языке по умолчанию в момент вывода,
+// поэтому её можно объявить переменной пакета и сравнивать через errors.Is
+type Error string
+
+func (e Error) Error() string {
+	return T(string(e))
+}
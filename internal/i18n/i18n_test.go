@@ -0,0 +1,239 @@
+package i18n
+
+import (
+	"fmt"           // Для проверки глаголов форматирования
+	"io"            // Для ошибки в примере
+	"io/fs"         // Для обхода исходников
+	"os"            // Для чтения исходников
+	"path/filepath" // Для путей к исходникам
+	"regexp"        // Для поиска глаголов в шаблонах
+	"sort"          // Для стабильного вывода ключей
+	"strings"       // Для неразрывных пробелов в ожидаемых строках
+	"testing"       // Cтандартная библиотека для тестов Go
+	"time"          // Для длительностей
+)
+
+// verbs — глаголы шаблона по порядку, без флагов ширины и точности: "%d. %s — %.2f" → "d s f"
+func verbs(message string) []string {
+	var out []string
+	for _, m := range regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]+)?([a-zA-Z%])`).FindAllStringSubmatch(message, -1) {
+		if m[1] != "%" {
+			out = append(out, m[1])
+		}
+	}
+	return out
+}
+
+// nbsp заменяет «_» на неразрывный пробел между числом и единицей измерения: nbsp("1_мин") == "1\u00a0мин"
+func nbsp(s string) string {
+	return strings.ReplaceAll(s, "_", "\u00a0")
+}
+
+// ================================================ Тесты каталогов ================================================
+
+func TestCatalogsComplete(t *testing.T) {
+	keys := map[string]bool{}
+	for _, lang := range Languages {
+		for _, key := range Keys(lang) {
+			keys[key] = true
+		}
+	}
+	all := make([]string, 0, len(keys))
+	for key := range keys {
+		all = append(all, key)
+	}
+	sort.Strings(all)
+
+	for _, key := range all {
+		want := verbs(catalogs[RU][key])
+		for _, lang := range Languages {
+			message, ok := catalogs[lang][key]
+			if !ok || message == "" {
+				t.Errorf("В каталоге %s нет ключа %q", lang, key)
+				continue
+			}
+			if got := verbs(message); len(got) != len(want) || (len(got) > 0 && !equal(got, want)) {
+				t.Errorf("Глаголы %q в каталоге %s (%v) не совпадают с ru (%v)", key, lang, got, want)
+			}
+		}
+	}
+}
+
+// Ключи, которые передаются в i18n.T, i18n.Errorf, i18n.Error и Printer.Sprintf в коде проекта (а также в errorf пакета query)
+// и в функцию t шаблонов HTML, должны быть в каталогах
+func TestCatalogsCoverCode(t *testing.T) {
+	use := map[string]*regexp.Regexp{
+		".go":   regexp.MustCompile(`(?:(?:i18n\.T|i18n\.Errorf|i18n\.Error|\.Sprintf|line)\(|errorf\([^"\n]*)"([a-z]+\.[a-z0-9_.]+)"`),
+		".html": regexp.MustCompile(`\{\{t "([a-z]+\.[a-z0-9_.]+)"`),
+	}
+	found := 0
+	err := filepath.WalkDir(filepath.Join("..", ".."), func(path string, d fs.DirEntry, err error) error {
+		re := use[filepath.Ext(path)]
+		if err != nil || d.IsDir() || re == nil || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, m := range re.FindAllStringSubmatch(string(data), -1) {
+			found++
+			if _, ok := catalogs[RU][m[1]]; !ok {
+				t.Errorf("%s: ключа %q нет в каталогах", path, m[1])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if found < 50 {
+		t.Errorf("Найдено подозрительно мало ключей в коде: %d", found)
+	}
+}
+
+func equal(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParse(t *testing.T) {
+	for text, want := range map[string]Lang{"ru": RU, "EN": EN, "en_US.UTF-8": EN, "ru-RU": RU, " en ": EN, "en@euro": EN} {
+		if got, err := Parse(text); err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v; ожидалось %q", text, got, err, want)
+		}
+	}
+	for _, text := range []string{"", "C", "POSIX", "de_DE.UTF-8"} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("Ожидалась ошибка для %q", text)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "C")
+	t.Setenv("LANG", "en_GB.UTF-8")
+	if got := FromEnv(); got != EN {
+		t.Errorf("Ожидался язык из LANG, получили %q", got)
+	}
+	t.Setenv("LC_ALL", "ru_RU.UTF-8")
+	if got := FromEnv(); got != RU {
+		t.Errorf("LC_ALL важнее LANG, получили %q", got)
+	}
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LANG", "C.UTF-8")
+	if got := FromEnv(); got != RU {
+		t.Errorf("Без известного языка используется русский, получили %q", got)
+	}
+}
+
+// ================================================ Тесты форматирования ================================================
+
+func TestSprintf(t *testing.T) {
+	ru, en := New(RU), New(EN)
+	for _, tt := range []struct {
+		p    *Printer
+		key  string
+		args []any
+		want string
+	}{
+		{ru, "summary.total", []any{1234567}, "Всего запросов: 1\u00a0234\u00a0567"},
+		{en, "summary.total", []any{1234567}, "Total requests: 1,234,567"},
+		{ru, "summary.average", []any{12345.678}, "Среднее время ответа: 12\u00a0345,68 мс"},
+		{en, "summary.average", []any{-0.5}, "Average response time: -0.50 ms"},
+		{ru, "summary.requests_row", []any{1, "10.0.0.1", 999}, "  1. 10.0.0.1 — 999 запросов"},
+		{en, "summary.worst_buckets", []any{5, time.Minute}, nbsp("Worst 5 intervals by Apdex (1_min each):")},
+		{ru, "summary.worst_buckets", []any{5, 90 * time.Second}, nbsp("Худшие 5 интервалов по Apdex (по 1_мин 30_с):")},
+		{New("de"), "summary.total", []any{8}, "Всего запросов: 8"}, // Неизвестный язык — русский
+		{en, "no.such.key", nil, "no.such.key"},
+	} {
+		if got := tt.p.Sprintf(tt.key, tt.args...); got != tt.want {
+			t.Errorf("%s %s: %q, ожидалось %q", tt.p.Lang(), tt.key, got, tt.want)
+		}
+	}
+
+	if err := New(EN).Errorf("load.open", io.ErrUnexpectedEOF); err.Error() != "Failed to open file: unexpected EOF" {
+		t.Errorf("Неверная ошибка: %v", err)
+	}
+}
+
+func TestNumberVerbs(t *testing.T) {
+	p := New(EN)
+	for _, tt := range []struct {
+		format string
+		arg    any
+		want   string
+	}{
+		{"[%6d]", 1234, "[ 1,234]"},
+		{"[%-6d]", 1234, "[1,234 ]"},
+		{"[%06d]", 1234, "[001234]"}, // С нулями слева разделители не ставятся
+		{"[%+d]", int64(1234), "[+1,234]"},
+		{"[%x]", 1234, "[4d2]"},
+		{"[%v]", uint8(255), "[255]"},
+		{"[%.1f]", 1234.0, "[1,234.0]"},
+		{"[%9.1f]", float32(-1234), "[ -1,234.0]"},
+		{"[%v]", 1e21, "[1e+21]"},
+		{"[%d]", time.Second, "[1000000000]"},
+		{"[%q]", "1234", `["1234"]`},
+	} {
+		if got := fmt.Sprintf(tt.format, p.localize([]any{tt.arg})...); got != tt.want {
+			t.Errorf("%s %v: %q, ожидалось %q", tt.format, tt.arg, got, tt.want)
+		}
+	}
+	if got := p.Number(1234.5, 2); got != "1,234.50" {
+		t.Errorf("Неверное число: %q", got)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	ru, en := New(RU), New(EN)
+	for d, want := range map[time.Duration]string{
+		0:                                    nbsp("0_мс"),
+		250 * time.Millisecond:               nbsp("250_мс"),
+		1500 * time.Microsecond:              nbsp("1,5_мс"),
+		1500 * time.Millisecond:              nbsp("1,5_с"),
+		time.Minute:                          nbsp("1_мин"),
+		26*time.Hour + 30*time.Minute:        nbsp("1_д 2_ч 30_мин"),
+		-(time.Hour + 5*time.Second):         nbsp("-1_ч 5_с"),
+		1000 * 24 * time.Hour:                nbsp("1_000_д"),
+		2*time.Minute + 500*time.Millisecond: nbsp("2_мин 0,5_с"),
+	} {
+		if got := ru.Duration(d); got != want {
+			t.Errorf("Duration(%v) = %q, ожидалось %q", d, got, want)
+		}
+	}
+	if got := en.Duration(90*time.Minute + 1500*time.Millisecond); got != nbsp("1_h 30_min 1.5_s") {
+		t.Errorf("Неверная длительность по-английски: %q", got)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	defer SetDefault(Default().Lang())
+
+	SetDefault(EN)
+	if got := T("summary.errors", 2); got != "Errors (4xx/5xx): 2" {
+		t.Errorf("Ожидалось сообщение на английском: %q", got)
+	}
+	if err := Errorf("main.error.load", io.EOF); err.Error() != "Failed to load logs: EOF" {
+		t.Errorf("Ожидалась ошибка на английском: %v", err)
+	}
+	if _, err := Parse("de"); err == nil || err.Error() != `Unknown language "de" (available: ru, en)` {
+		t.Errorf("Ожидалась ошибка разбора языка на английском: %v", err)
+	}
+	var sentinel error = Error("stage.transient") // Текст ошибки-константы зависит от языка в момент вывода
+	if sentinel.Error() != "transient error" {
+		t.Errorf("Ожидалась ошибка-константа на английском: %v", sentinel)
+	}
+	SetDefault(RU)
+	if sentinel.Error() != "временная ошибка" {
+		t.Errorf("Ожидалась ошибка-константа на русском: %v", sentinel)
+	}
+	if got := T("summary.errors", 2); got != "Ошибок (4xx/5xx): 2" {
+		t.Errorf("Ожидалось сообщение на русском: %q", got)
+	}
+}
@@ -0,0 +1,91 @@
+// Пакет logging — журнал диагностики на log/slog: уровни, текстовый или JSON-формат, вывод в stderr или файл.
+// Результаты программы идут в stdout, журнал — отдельно, поэтому они не смешиваются.
+
+package logging
+
+import (
+	"io"       // Для произвольного вывода
+	"log/slog" // Структурированный журнал
+	"os"       // Для stderr и файла журнала
+	"strings"  // Для разбора названий
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Журнал ================================================
+
+// Форматы журнала
+const (
+	FormatText = "text" // key=value, удобно читать глазами
+	FormatJSON = "json" // по объекту на строку, удобно разбирать
+)
+
+// Options — настройки журнала
+type Options struct {
+	Level  slog.Level // записи ниже уровня не пишутся (по умолчанию info)
+	Format string     // FormatText (по умолчанию) или FormatJSON
+	File   string     // файл журнала (дописывается); пусто — Output
+	Output io.Writer  // куда писать без File (по умолчанию os.Stderr)
+}
+
+// ParseLevel разбирает уровень: debug, info, warn, error (без учёта регистра, допускается смещение, например info+2)
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, i18n.Errorf("logging.level", s)
+	}
+	return level, nil
+}
+
+// ParseFormat проверяет название формата; пустое — FormatText
+func ParseFormat(s string) (string, error) {
+	switch format := strings.ToLower(strings.TrimSpace(s)); format {
+	case "":
+		return FormatText, nil
+	case FormatText, FormatJSON:
+		return format, nil
+	}
+	return "", i18n.Errorf("logging.format", s)
+}
+
+// New создаёт журнал и функцию, которая закрывает файл журнала (без файла она ничего не делает)
+func New(opts Options) (*slog.Logger, func() error, error) {
+	if _, err := ParseFormat(opts.Format); err != nil {
+		return nil, nil, err
+	}
+	out, closeFn := opts.Output, func() error { return nil }
+	if out == nil {
+		out = os.Stderr
+	}
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, i18n.Errorf("logging.open", err)
+		}
+		out, closeFn = f, f.Close
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	if format, _ := ParseFormat(opts.Format); format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(out, handlerOpts)), closeFn, nil
+	}
+	return slog.New(slog.NewTextHandler(out, handlerOpts)), closeFn, nil
+}
+
+// Discard — журнал, который ничего не пишет
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// Entry — запись лога группой атрибутов entry: время, метод, URL, статус, время ответа и IP
+func Entry(l model.LogEntry) slog.Attr {
+	return slog.Group("entry",
+		slog.Time("time", l.Timestamp),
+		slog.String("method", l.Method),
+		slog.String("url", l.URL),
+		slog.Int("status", l.StatusCode),
+		slog.Int("response_ms", l.ResponseTime),
+		slog.String("ip", l.IP),
+	)
+}
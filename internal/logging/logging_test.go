@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"bytes"         // Для журнала в памяти
+	"encoding/json" // Для разбора записей JSON
+	"log/slog"      // Для уровней журнала
+	"os"            // Для чтения файла журнала
+	"path/filepath" // Для пути к файлу журнала
+	"strings"       // Для проверки строк журнала
+	"testing"       // Cтандартная библиотека для тестов Go
+	"time"          // Для времени записи лога
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Тесты журнала ================================================
+
+func TestParseLevel(t *testing.T) {
+	for text, want := range map[string]slog.Level{"debug": slog.LevelDebug, "INFO": slog.LevelInfo, " warn ": slog.LevelWarn, "error": slog.LevelError, "info+2": slog.LevelInfo + 2} {
+		if got, err := ParseLevel(text); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; ожидалось %v", text, got, err, want)
+		}
+	}
+	for _, text := range []string{"", "verbose", "5"} {
+		if _, err := ParseLevel(text); err == nil {
+			t.Errorf("Ожидалась ошибка для %q", text)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for text, want := range map[string]string{"": FormatText, "text": FormatText, "JSON": FormatJSON} {
+		if got, err := ParseFormat(text); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; ожидалось %q", text, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("Ожидалась ошибка для неизвестного формата")
+	}
+	if _, _, err := New(Options{Format: "xml"}); err == nil {
+		t.Error("New не должен принимать неизвестный формат")
+	}
+}
+
+func TestErrorsLanguage(t *testing.T) {
+	defer i18n.SetDefault(i18n.Default().Lang())
+
+	for lang, want := range map[i18n.Lang]string{
+		i18n.RU: `Неизвестный формат журнала "xml" (доступны: text, json)`,
+		i18n.EN: `Unknown log format "xml" (available: text, json)`,
+	} {
+		i18n.SetDefault(lang)
+		if _, err := ParseFormat("xml"); err == nil || err.Error() != want {
+			t.Errorf("Ожидалась ошибка %q, получили %v", want, err)
+		}
+	}
+	i18n.SetDefault(i18n.EN)
+	if _, err := ParseLevel("verbose"); err == nil || !strings.HasPrefix(err.Error(), `Unknown log level "verbose"`) {
+		t.Errorf("Ошибка уровня не переведена: %v", err)
+	}
+}
+
+func TestNewJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, closeLog, err := New(Options{Level: slog.LevelWarn, Format: FormatJSON, Output: &buf})
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	defer closeLog()
+
+	entry := model.LogEntry{
+		Timestamp: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		IP:        "10.0.0.1", Method: "GET", URL: "/api/users", StatusCode: 500, ResponseTime: 120,
+	}
+	logger.Info("не попадёт в журнал")
+	logger.Warn("ошибка записи", slog.Int("worker", 3), Entry(entry))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Записи ниже уровня warn не пишутся, получили: %q", buf.String())
+	}
+	var record struct {
+		Level  string
+		Msg    string
+		Worker int
+		Entry  struct {
+			Time       time.Time
+			Method     string
+			URL        string
+			Status     int
+			ResponseMS int `json:"response_ms"`
+			IP         string
+		}
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Запись не в JSON: %v", err)
+	}
+	if record.Level != "WARN" || record.Msg != "ошибка записи" || record.Worker != 3 {
+		t.Errorf("Неверная запись: %s", lines[0])
+	}
+	if e := record.Entry; !e.Time.Equal(entry.Timestamp) || e.Method != "GET" || e.URL != "/api/users" || e.Status != 500 || e.ResponseMS != 120 || e.IP != "10.0.0.1" {
+		t.Errorf("Неверная группа entry: %s", lines[0])
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "processor.log")
+	for _, msg := range []string{"первый запуск", "второй запуск"} { // Файл дописывается, а не перезаписывается
+		logger, closeLog, err := New(Options{File: path})
+		if err != nil {
+			t.Fatalf("Неожиданная ошибка: %v", err)
+		}
+		logger.Info(msg, slog.String("key", "value"))
+		if err := closeLog(); err != nil {
+			t.Fatalf("Неожиданная ошибка: %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `msg="первый запуск"`) || !strings.Contains(lines[1], "level=INFO") || !strings.Contains(lines[1], "key=value") {
+		t.Errorf("Неверный файл журнала:\n%s", data)
+	}
+
+	if _, _, err := New(Options{File: filepath.Join(t.TempDir(), "нет", "processor.log")}); err == nil {
+		t.Error("Ожидалась ошибка для недоступного файла")
+	}
+	Discard().Error("никуда не пишется")
+}
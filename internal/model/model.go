@@ -3,23 +3,237 @@
 package model
 
 import (
-	"sync" // Для защиты данных от одновременного доступа (mutex)
-	"time" // Для работы с датой и временем
+	"math"      // Для округления времени ответа до значащих цифр
+	"net/netip" // Для разобранного IP-адреса клиента
+	"sort"      // Для перцентилей времени ответа
+	"sync"      // Для защиты данных от одновременного доступа (mutex)
+	"time"      // Для работы с датой и временем
 )
 
 type LogEntry struct {
-	Timestamp    time.Time // время в формате "2024-01-15 10:30:00"
-	IP           string    // IP адрес клиента
-	Method       string    // HTTP метод (GET, POST и т.д.)
-	URL          string    // путь запроса
-	StatusCode   int       // HTTP статус код
-	ResponseTime int       // время ответа в миллисекундах
+	Timestamp    time.Time  // время в формате "2024-01-15 10:30:00"
+	IP           string     // IP адрес, с которого пришло соединение (за балансировщиком — адрес прокси)
+	Addr         netip.Addr // разобранный IP адрес соединения (IPv4 или IPv6), заполняется при загрузке
+	Method       string     // HTTP метод (GET, POST и т.д.)
+	URL          string     // путь запроса
+	StatusCode   int        // HTTP статус код
+	ResponseTime int        // время ответа в миллисекундах
+	ForwardedFor string     // заголовок X-Forwarded-For, если он есть в логе
+	RealIP       string     // заголовок X-Real-IP, если он есть в логе
+	ClientAddr   netip.Addr // реальный IP клиента с учётом доверенных прокси; нулевой — совпадает с Addr
+	Country      string     // ISO-код страны клиента (заполняется этапом GeoIP)
+	City         string     // город клиента
+	ASN          uint32     // номер автономной системы клиента
+	ASOrg        string     // организация, владеющая автономной системой
+	UserAgent    string     // заголовок User-Agent, если он есть в логе
+	Client       ClientInfo // разобранный User-Agent (заполняется этапом разбора User-Agent)
+}
+
+// ClientInfo — браузер, ОС и тип устройства клиента, а для ботов — имя и категория
+type ClientInfo struct {
+	Browser     string // семейство браузера: Chrome, Firefox, Safari...
+	Version     string // основная версия браузера
+	OS          string // Windows, Android, iOS...
+	Device      string // desktop, mobile, tablet или bot; пусто — неизвестно
+	Bot         bool   // запрос сделан ботом, утилитой или сканером
+	BotName     string // Googlebot, curl, sqlmap...
+	BotCategory string // crawler, monitoring, tool или scanner
+}
+
+// FamilyOther — семейство клиентов, которых не удалось узнать. Это идентификатор, а не подпись:
+// в сводке и отчётах он переводится на язык вывода
+const FamilyOther = "other"
+
+// Family — семейство клиента для статистики: имя бота, браузер или FamilyOther
+func (c ClientInfo) Family() string {
+	switch {
+	case c.Bot:
+		return c.BotName
+	case c.Browser != "":
+		return c.Browser
+	}
+	return FamilyOther
 }
 
 type Statistics struct {
-	Mu              sync.Mutex     // mutex для защиты глобальных данных
-	TotalRequests   int            // общее количество запросов
-	ErrorCount      int            // количество ошибок (статус >= 400)
-	RequestsByIP    map[string]int // количество запросов с каждого IP
-	AverageRespTime float64        // среднее время ответа
+	Mu              sync.Mutex                 // mutex для защиты глобальных данных
+	TotalRequests   int                        // общее количество запросов
+	ErrorCount      int                        // количество ошибок (статус >= 400)
+	RequestsByIP    map[string]int             // количество запросов с каждого IP клиента
+	ProxiedRequests int                        // количество запросов, пришедших через доверенные прокси
+	RequestsByProxy map[string]int             // количество запросов через каждый доверенный прокси
+	ByCountry       map[string]RequestCounter  // запросы и ошибки по странам (если включён GeoIP)
+	ByASN           map[string]RequestCounter  // запросы и ошибки по автономным системам
+	ByClient        map[string]RequestCounter  // запросы и ошибки по семействам клиентов (если в логе есть User-Agent)
+	BotRequests     RequestCounter             // запросы ботов
+	HumanRequests   RequestCounter             // запросы людей (браузеров)
+	AverageRespTime float64                    // среднее время ответа
+	ApdexT          int                        // порог Apdex T в мс (0 — DefaultApdexT)
+	ApdexBucket     time.Duration              // интервал для Apdex и временного ряда (0 — DefaultApdexBucket)
+	Apdex           ApdexCounter               // Apdex по всем запросам
+	ApdexByRoute    map[string]ApdexCounter    // Apdex по нормализованным маршрутам
+	ApdexByBucket   map[time.Time]ApdexCounter // Apdex по интервалам времени (ключ — начало интервала)
+	ByStatus        map[int]int                // запросы по коду ответа
+	Latency         LatencyCounter             // распределение времени ответа
+	ByEndpoint      map[Endpoint]EndpointStats // запросы, ошибки и время ответа по методу и маршруту
+	Series          map[time.Time]SeriesPoint  // запросы, ошибки и время ответа по интервалам ApdexBucket
+	FilteredCount   int                        // записи, отброшенные фильтрами (-query, списки подсетей)
+	ParseErrors     int                        // строки, которые не удалось разобрать и пришлось пропустить
+	FirstSeen       time.Time                  // время самой ранней записи
+	LastSeen        time.Time                  // время самой поздней записи
+	FailedCount     int                        // количество записей, обработка которых завершилась ошибкой
+	RetryCount      int                        // количество повторных попыток обработки
+	Partial         bool                       // обработка была прервана, статистика неполная
+	ActiveWorkers   int                        // текущее количество воркеров в пуле
+	QueueDepth      int                        // глубина входной очереди при последнем замере
+}
+
+// RequestCounter — количество запросов и ошибок в группе
+type RequestCounter struct {
+	Requests int
+	Errors   int
+}
+
+// Значения Apdex по умолчанию
+const (
+	DefaultApdexT      = 500         // порог T в мс
+	DefaultApdexBucket = time.Minute // интервал для Apdex и временного ряда
+)
+
+// ApdexCounter — оценка удовлетворённости Apdex: запрос быстрее T удовлетворяет пользователя,
+// до 4T — терпим, медленнее 4T или с ошибкой (статус >= 400) — разочаровывает
+type ApdexCounter struct {
+	Satisfied  int
+	Tolerating int
+	Frustrated int
+}
+
+// Классы запросов Apdex
+const (
+	ApdexSatisfied  = "satisfied"
+	ApdexTolerating = "tolerating"
+	ApdexFrustrated = "frustrated"
+)
+
+// ApdexClass — класс запроса при пороге t в мс
+func ApdexClass(l LogEntry, t int) string {
+	switch {
+	case l.StatusCode >= 400 || l.ResponseTime > 4*t:
+		return ApdexFrustrated
+	case l.ResponseTime > t:
+		return ApdexTolerating
+	}
+	return ApdexSatisfied
+}
+
+// Add учитывает запрос при пороге t в мс
+func (a *ApdexCounter) Add(l LogEntry, t int) {
+	switch ApdexClass(l, t) {
+	case ApdexFrustrated:
+		a.Frustrated++
+	case ApdexTolerating:
+		a.Tolerating++
+	default:
+		a.Satisfied++
+	}
+}
+
+// Total — всего учтённых запросов
+func (a ApdexCounter) Total() int {
+	return a.Satisfied + a.Tolerating + a.Frustrated
+}
+
+// Score — (удовлетворённые + терпимые / 2) / все, от 0 до 1; без запросов — 1
+func (a ApdexCounter) Score() float64 {
+	if a.Total() == 0 {
+		return 1
+	}
+	return (float64(a.Satisfied) + float64(a.Tolerating)/2) / float64(a.Total())
+}
+
+// LatencyCounter — распределение времени ответа. Значения до 1000 мс хранятся точно, больше — с округлением
+// до трёх значащих цифр, поэтому память зависит от разброса времени ответа, а не от числа запросов
+type LatencyCounter struct {
+	Count  int
+	Sum    int64
+	Max    int
+	Values map[int]int // время ответа в мс → число запросов
+}
+
+// Add учитывает время ответа в мс
+func (c *LatencyCounter) Add(ms int) {
+	if c.Values == nil {
+		c.Values = make(map[int]int)
+	}
+	c.Count++
+	c.Sum += int64(ms)
+	c.Max = max(c.Max, ms)
+	key := ms
+	if ms >= 1000 {
+		scale := math.Pow10(int(math.Log10(float64(ms))) - 2)
+		key = int(math.Round(float64(ms)/scale) * scale)
+	}
+	c.Values[key]++
+}
+
+// Mean — среднее время ответа; без запросов — 0
+func (c LatencyCounter) Mean() float64 {
+	if c.Count == 0 {
+		return 0
+	}
+	return float64(c.Sum) / float64(c.Count)
+}
+
+// Percentile — p-й перцентиль (0–100) с линейной интерполяцией между соседними значениями, как utilits.Percentile
+func (c LatencyCounter) Percentile(p float64) float64 {
+	if c.Count == 0 {
+		return 0
+	}
+	keys := make([]int, 0, len(c.Values))
+	for k := range c.Values {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	rank := math.Max(0, math.Min(p/100, 1)) * float64(c.Count-1) // Позиция перцентиля среди упорядоченных значений
+	lower, upper := int(math.Floor(rank)), int(math.Ceil(rank))
+	at := func(i int) float64 { // Значение с порядковым номером i
+		for _, k := range keys {
+			if i < c.Values[k] {
+				return float64(k)
+			}
+			i -= c.Values[k]
+		}
+		return float64(keys[len(keys)-1])
+	}
+	lo := at(lower)
+	return lo + (at(upper)-lo)*(rank-float64(lower))
+}
+
+// Endpoint — метод и нормализованный маршрут
+type Endpoint struct {
+	Method string
+	Route  string
+}
+
+// EndpointStats — запросы, ошибки, время ответа и Apdex одного метода и маршрута
+type EndpointStats struct {
+	RequestCounter
+	Classes   [5]int // запросы по классам ответа: 1xx, 2xx, 3xx, 4xx, 5xx
+	Latency   LatencyCounter
+	Histogram []int // запросы по корзинам гистограммы метрик (точно, без округления Latency); последняя — выше всех границ
+	Apdex     ApdexCounter
+}
+
+// SeriesPoint — запросы, ошибки и время ответа одного интервала времени
+type SeriesPoint struct {
+	RequestCounter
+	Latency LatencyCounter
+}
+
+// FailedEntry — запись, которую не удалось обработать (dead letter), вместе с причиной ошибки
+type FailedEntry struct {
+	Entry    LogEntry // исходная запись
+	Err      error    // причина ошибки
+	Attempts int      // сколько попыток обработки было сделано
 }
@@ -0,0 +1,198 @@
+// Разбор IP-адресов клиентов, списки подсетей (allow/deny) и агрегация адресов по подсетям.
+
+package netaddr
+
+import (
+	"bufio"     // Для построчного чтения файлов со списками подсетей
+	"fmt"       // Для номера строки в ошибке
+	"net/netip" // Для IP-адресов и подсетей
+	"os"        // Для открытия файлов
+	"sort"      // Для сортировки топа подсетей
+	"strings"   // Для разбора строк
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Разбор адресов ================================================
+
+// ParseAddr разбирает IPv4 или IPv6-адрес. IPv4, записанный как IPv6 (::ffff:1.2.3.4), приводится к IPv4
+func ParseAddr(s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return netip.Addr{}, i18n.Errorf("netaddr.bad_ip", s)
+	}
+	return addr.Unmap(), nil
+}
+
+// EntryAddr возвращает разобранный адрес записи; если Addr не заполнен, разбирает строку IP.
+// Для неверного адреса возвращает нулевой netip.Addr
+func EntryAddr(l model.LogEntry) netip.Addr {
+	if l.Addr.IsValid() {
+		return l.Addr
+	}
+	addr, _ := ParseAddr(l.IP)
+	return addr
+}
+
+// ParsePrefix разбирает подсеть вида 10.0.0.0/8; одиночный адрес превращается в подсеть /32 или /128
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, i18n.Errorf("netaddr.bad_subnet", s)
+	}
+	if prefix.Addr().Is4In6() && prefix.Bits() >= 96 { // ::ffff:10.0.0.0/104 — то же, что 10.0.0.0/8
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked(), nil
+}
+
+// ================================================ Списки подсетей ================================================
+
+// CIDRList — набор подсетей
+type CIDRList struct {
+	prefixes []netip.Prefix
+}
+
+// ParseCIDRList создаёт список из строк с подсетями или адресами
+func ParseCIDRList(items []string) (*CIDRList, error) {
+	list := &CIDRList{}
+	for _, item := range items {
+		prefix, err := ParsePrefix(item)
+		if err != nil {
+			return nil, err
+		}
+		list.prefixes = append(list.prefixes, prefix)
+	}
+	return list, nil
+}
+
+// LoadCIDRFile читает список подсетей из файла: по одной на строку, пустые строки и комментарии (#) пропускаются
+func LoadCIDRFile(path string) (*CIDRList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, i18n.Errorf("load.open", err)
+	}
+	defer file.Close()
+
+	list := &CIDRList{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 { // Отрезаем комментарий
+			text = text[:i]
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		prefix, err := ParsePrefix(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		list.prefixes = append(list.prefixes, prefix)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, i18n.Errorf("load.read", err)
+	}
+	return list, nil
+}
+
+// Len возвращает количество подсетей в списке
+func (l *CIDRList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.prefixes)
+}
+
+// Contains сообщает, входит ли адрес хотя бы в одну подсеть списка
+func (l *CIDRList) Contains(addr netip.Addr) bool {
+	if l == nil || !addr.IsValid() {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range l.prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessList — правила доступа: запрещённые подсети имеют приоритет; если список разрешённых
+// не пуст, проходят только адреса из него
+type AccessList struct {
+	Allow *CIDRList
+	Deny  *CIDRList
+}
+
+// Permits сообщает, проходит ли адрес правила доступа
+func (a AccessList) Permits(addr netip.Addr) bool {
+	if a.Deny.Contains(addr) {
+		return false
+	}
+	if a.Allow.Len() > 0 {
+		return a.Allow.Contains(addr)
+	}
+	return true
+}
+
+// ================================================ Агрегация по подсетям ================================================
+
+// Subnet возвращает подсеть адреса с длиной префикса v4Bits для IPv4 и v6Bits для IPv6
+func Subnet(addr netip.Addr, v4Bits, v6Bits int) netip.Prefix {
+	addr = addr.Unmap()
+	bits := v6Bits
+	if addr.Is4() {
+		bits = v4Bits
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil { // Неверная длина префикса — считаем адрес отдельной подсетью
+		return netip.PrefixFrom(addr, addr.BitLen())
+	}
+	return prefix
+}
+
+// SubnetCount — количество запросов из подсети
+type SubnetCount struct {
+	Subnet netip.Prefix
+	Count  int
+}
+
+// TopSubnets группирует счётчики запросов по IP в подсети и возвращает topN самых активных.
+// Строки, которые не являются IP-адресами, пропускаются
+func TopSubnets(requestsByIP map[string]int, v4Bits, v6Bits, topN int) []SubnetCount {
+	counts := make(map[netip.Prefix]int)
+	for ip, count := range requestsByIP {
+		addr, err := ParseAddr(ip)
+		if err != nil {
+			continue
+		}
+		counts[Subnet(addr, v4Bits, v6Bits)] += count
+	}
+
+	top := make([]SubnetCount, 0, len(counts))
+	for subnet, count := range counts {
+		top = append(top, SubnetCount{subnet, count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Subnet.Addr().Less(top[j].Subnet.Addr()) // Одинаковые счётчики — по адресу, для стабильного вывода
+	})
+	if len(top) > topN {
+		top = top[:topN]
+	}
+	return top
+}
@@ -0,0 +1,179 @@
+package netaddr
+
+import (
+	"fmt"           // Для форматирования результата
+	"net/netip"     // Для сравнения адресов
+	"os"            // Для временного файла со списком подсетей
+	"path/filepath" // Для пути к временному файлу
+	"strings"       // Для проверки текста ошибок
+	"testing"       // Cтандартная библиотека для тестов Go
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Тесты разбора адресов ================================================
+
+func TestParseAddr(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+		ok       bool
+	}{
+		{"192.168.0.1", "192.168.0.1", true},
+		{" 10.0.0.1 ", "10.0.0.1", true},
+		{"2001:db8::1", "2001:db8::1", true},
+		{"::ffff:1.2.3.4", "1.2.3.4", true}, // IPv4 в виде IPv6 приводится к IPv4
+		{"256.0.0.1", "", false},
+		{"example.com", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		addr, err := ParseAddr(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseAddr(%q): ошибка %v, ожидался успех: %v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && addr.String() != tt.expected {
+			t.Errorf("ParseAddr(%q) = %s, ожидалось %s", tt.in, addr, tt.expected)
+		}
+	}
+
+	if got := EntryAddr(model.LogEntry{IP: "10.0.0.7"}); got != netip.MustParseAddr("10.0.0.7") {
+		t.Errorf("EntryAddr без Addr должен разбирать строку IP, получили %s", got)
+	}
+	if got := EntryAddr(model.LogEntry{IP: "мусор"}); got.IsValid() {
+		t.Errorf("EntryAddr для неверного IP должен вернуть нулевой адрес, получили %s", got)
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := map[string]string{
+		"10.1.2.3/8":          "10.0.0.0/8", // Адрес маскируется до начала подсети
+		"192.168.1.1":         "192.168.1.1/32",
+		"2001:db8::1":         "2001:db8::1/128",
+		"2001:db8:1::/48":     "2001:db8:1::/48",
+		"::ffff:10.0.0.0/104": "10.0.0.0/8",
+	}
+	for in, expected := range tests {
+		prefix, err := ParsePrefix(in)
+		if err != nil {
+			t.Errorf("ParsePrefix(%q) вернул ошибку: %v", in, err)
+			continue
+		}
+		if prefix.String() != expected {
+			t.Errorf("ParsePrefix(%q) = %s, ожидалось %s", in, prefix, expected)
+		}
+	}
+
+	for _, bad := range []string{"10.0.0.0/33", "10.0.0/8", "abc"} {
+		if _, err := ParsePrefix(bad); err == nil {
+			t.Errorf("Ожидалась ошибка для %q", bad)
+		}
+	}
+}
+
+// ================================================ Тесты списков подсетей ================================================
+
+func TestLoadCIDRFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deny.txt")
+	content := "# сканеры\n10.0.0.0/8\n\n  192.168.5.7   # отдельный адрес\n2001:db8::/32\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Ошибка создания файла: %v", err)
+	}
+
+	list, err := LoadCIDRFile(path)
+	if err != nil {
+		t.Fatalf("LoadCIDRFile вернул ошибку: %v", err)
+	}
+	if list.Len() != 3 {
+		t.Fatalf("Ожидалось 3 подсети, получили %d", list.Len())
+	}
+
+	for ip, expected := range map[string]bool{
+		"10.20.30.40":     true,
+		"192.168.5.7":     true,
+		"192.168.5.8":     false,
+		"2001:db8:ff::1":  true,
+		"2001:db9::1":     false,
+		"::ffff:10.0.0.1": true,
+	} {
+		if got := list.Contains(netip.MustParseAddr(ip)); got != expected {
+			t.Errorf("Contains(%s) = %v, ожидалось %v", ip, got, expected)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("10.0.0.0/8\nне подсеть\n"), 0644); err != nil {
+		t.Fatalf("Ошибка создания файла: %v", err)
+	}
+	if _, err := LoadCIDRFile(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("Ожидалась ошибка с номером строки 2, получили: %v", err)
+	}
+	if _, err := LoadCIDRFile(filepath.Join(t.TempDir(), "нет.txt")); err == nil {
+		t.Errorf("Ожидалась ошибка для несуществующего файла")
+	}
+}
+
+func TestAccessList(t *testing.T) {
+	allow, _ := ParseCIDRList([]string{"10.0.0.0/8", "2001:db8::/32"})
+	deny, _ := ParseCIDRList([]string{"10.66.0.0/16"})
+
+	tests := []struct {
+		name     string
+		acl      AccessList
+		ip       string
+		expected bool
+	}{
+		{"пустые списки пропускают всё", AccessList{}, "8.8.8.8", true},
+		{"адрес из разрешённых", AccessList{Allow: allow}, "10.1.1.1", true},
+		{"адрес вне разрешённых", AccessList{Allow: allow}, "8.8.8.8", false},
+		{"IPv6 из разрешённых", AccessList{Allow: allow}, "2001:db8::5", true},
+		{"запрет важнее разрешения", AccessList{Allow: allow, Deny: deny}, "10.66.1.1", false},
+		{"только запрет", AccessList{Deny: deny}, "8.8.8.8", true},
+	}
+	for _, tt := range tests {
+		if got := tt.acl.Permits(netip.MustParseAddr(tt.ip)); got != tt.expected {
+			t.Errorf("%s: Permits(%s) = %v, ожидалось %v", tt.name, tt.ip, got, tt.expected)
+		}
+	}
+}
+
+// ================================================ Тесты агрегации по подсетям ================================================
+
+func TestSubnet(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected string
+	}{
+		{"192.168.1.77", "192.168.1.0/24"},
+		{"2001:db8:1:2:3::1", "2001:db8:1:2::/64"},
+		{"::ffff:192.168.1.77", "192.168.1.0/24"},
+	}
+	for _, tt := range tests {
+		if got := Subnet(netip.MustParseAddr(tt.ip), 24, 64); got.String() != tt.expected {
+			t.Errorf("Subnet(%s) = %s, ожидалось %s", tt.ip, got, tt.expected)
+		}
+	}
+}
+
+func TestTopSubnets(t *testing.T) {
+	requestsByIP := map[string]int{
+		"10.0.0.1":        3,
+		"10.0.0.2":        4, // 10.0.0.0/24 — всего 7
+		"10.0.1.1":        5,
+		"2001:db8::1":     2,
+		"2001:db8::ff":    4, // 2001:db8::/64 — всего 6
+		"2001:db8:0:1::1": 1,
+		"не адрес":        100, // Пропускается
+	}
+
+	top := TopSubnets(requestsByIP, 24, 64, 3)
+	expected := []string{"10.0.0.0/24 7", "2001:db8::/64 6", "10.0.1.0/24 5"}
+	if len(top) != len(expected) {
+		t.Fatalf("Ожидалось %d подсетей, получили %v", len(expected), top)
+	}
+	for i, sc := range top {
+		if got := fmt.Sprintf("%s %d", sc.Subnet, sc.Count); got != expected[i] {
+			t.Errorf("Позиция %d: ожидалось %q, получили %q", i+1, expected[i], got)
+		}
+	}
+}
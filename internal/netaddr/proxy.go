@@ -0,0 +1,81 @@
+package netaddr
+
+import (
+	"net/netip" // Для IP-адресов
+	"strings"   // Для разбора цепочки X-Forwarded-For
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Доверенные прокси ================================================
+
+// ProxyResolver определяет реальный IP клиента за балансировщиками.
+// Заголовкам X-Forwarded-For и X-Real-IP верим только тогда, когда их добавил доверенный прокси:
+// иначе клиент может подставить туда любой адрес
+type ProxyResolver struct {
+	Trusted *CIDRList // подсети доверенных прокси
+}
+
+// Resolve возвращает IP клиента. peer — адрес, с которого пришло соединение.
+// Цепочка X-Forwarded-For просматривается справа налево: первый адрес не из доверенных подсетей и есть клиент.
+// Если все адреса цепочки доверенные, клиентом считается самый левый. Встретив неразборчивый адрес,
+// просмотр останавливается: всё, что левее, мог подделать клиент
+func (r ProxyResolver) Resolve(peer netip.Addr, forwardedFor, realIP string) netip.Addr {
+	if !r.Trusted.Contains(peer) { // Соединение не от прокси — заголовки ничего не значат
+		return peer
+	}
+
+	client := peer.Unmap()
+	if strings.TrimSpace(forwardedFor) == "" {
+		if addr, ok := parseHop(realIP); ok { // X-Real-IP выставляет сам доверенный прокси
+			return addr
+		}
+		return client
+	}
+
+	hops := strings.Split(forwardedFor, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseHop(hops[i])
+		if !ok {
+			break
+		}
+		client = addr
+		if !r.Trusted.Contains(addr) {
+			break
+		}
+	}
+	return client
+}
+
+// parseHop разбирает элемент цепочки X-Forwarded-For: адрес, возможно с портом (1.2.3.4:80, [2001:db8::1]:443)
+func parseHop(s string) (netip.Addr, bool) {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	if addr, err := ParseAddr(s); err == nil {
+		return addr, true
+	}
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+// ClientAddr возвращает IP клиента записи: определённый через прокси, а если его нет — адрес соединения
+func ClientAddr(l model.LogEntry) netip.Addr {
+	if l.ClientAddr.IsValid() {
+		return l.ClientAddr
+	}
+	return EntryAddr(l)
+}
+
+// ClientIP — IP клиента записи в виде строки (для ключей статистики)
+func ClientIP(l model.LogEntry) string {
+	if l.ClientAddr.IsValid() {
+		return l.ClientAddr.String()
+	}
+	return l.IP
+}
+
+// Proxied сообщает, что клиент записи определён через прокси и отличается от адреса соединения
+func Proxied(l model.LogEntry) bool {
+	return l.ClientAddr.IsValid() && l.ClientAddr != EntryAddr(l)
+}
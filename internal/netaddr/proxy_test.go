@@ -0,0 +1,57 @@
+package netaddr
+
+import (
+	"net/netip" // Для сравнения адресов
+	"testing"   // Cтандартная библиотека для тестов Go
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Тесты доверенных прокси ================================================
+
+func TestProxyResolver(t *testing.T) {
+	trusted, _ := ParseCIDRList([]string{"10.0.0.0/8", "2001:db8:ffff::/48"})
+	resolver := ProxyResolver{Trusted: trusted}
+
+	tests := []struct {
+		name         string
+		peer         string
+		forwardedFor string
+		realIP       string
+		expected     string
+	}{
+		{"соединение не от прокси — заголовок игнорируется", "203.0.113.5", "1.1.1.1", "", "203.0.113.5"},
+		{"один прокси", "10.0.0.1", "198.51.100.7", "", "198.51.100.7"},
+		{"цепочка прокси", "10.0.0.1", "198.51.100.7, 10.1.1.1, 10.2.2.2", "", "198.51.100.7"},
+		{"подделанный левый адрес не учитывается", "10.0.0.1", "6.6.6.6, 198.51.100.7, 10.1.1.1", "", "198.51.100.7"},
+		{"все адреса доверенные — берём самый левый", "10.0.0.1", "10.3.3.3, 10.1.1.1", "", "10.3.3.3"},
+		{"неразборчивый адрес останавливает просмотр", "10.0.0.1", "198.51.100.7, unknown, 10.1.1.1", "", "10.1.1.1"},
+		{"адреса с портами", "10.0.0.1", "[2001:db8::7]:443, 10.1.1.1:8080", "", "2001:db8::7"},
+		{"IPv6-прокси", "2001:db8:ffff::1", "192.0.2.1", "", "192.0.2.1"},
+		{"X-Real-IP без X-Forwarded-For", "10.0.0.1", "", "192.0.2.9", "192.0.2.9"},
+		{"X-Forwarded-For важнее X-Real-IP", "10.0.0.1", "192.0.2.1", "192.0.2.9", "192.0.2.1"},
+		{"заголовков нет", "10.0.0.1", "", "", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		got := resolver.Resolve(netip.MustParseAddr(tt.peer), tt.forwardedFor, tt.realIP)
+		if got.String() != tt.expected {
+			t.Errorf("%s: получили %s, ожидалось %s", tt.name, got, tt.expected)
+		}
+	}
+
+	if got := (ProxyResolver{}).Resolve(netip.MustParseAddr("10.0.0.1"), "1.1.1.1", ""); got.String() != "10.0.0.1" {
+		t.Errorf("Без доверенных прокси заголовки должны игнорироваться, получили %s", got)
+	}
+}
+
+func TestClientAddr(t *testing.T) {
+	direct := model.LogEntry{IP: "10.0.0.1"}
+	if ClientIP(direct) != "10.0.0.1" || ClientAddr(direct).String() != "10.0.0.1" || Proxied(direct) {
+		t.Errorf("Без определённого клиента должен использоваться адрес соединения")
+	}
+
+	proxied := model.LogEntry{IP: "10.0.0.1", ClientAddr: netip.MustParseAddr("192.0.2.1")}
+	if ClientIP(proxied) != "192.0.2.1" || !Proxied(proxied) {
+		t.Errorf("Ожидался клиент 192.0.2.1 через прокси, получили %s", ClientIP(proxied))
+	}
+}
@@ -0,0 +1,150 @@
+package processor
+
+import (
+	"log/slog" // Для журнала изменений размера пула
+	"sync"     // Для защиты счётчиков задержки
+	"time"     // Для интервалов и измерения задержки
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+)
+
+// ================================================ Адаптивный пул воркеров ================================================
+
+// ScalingPolicy — границы и параметры автоматического масштабирования пула воркеров
+type ScalingPolicy struct {
+	MinWorkers int           // минимальное количество воркеров
+	MaxWorkers int           // максимальное количество воркеров
+	Interval   time.Duration // как часто пересчитывать размер пула (0 — раз в 100 мс)
+
+	// Tick — источник тиков контроллера. Если nil, используется time.Ticker с периодом Interval.
+	// Нужен тестам, чтобы управлять моментами пересчёта детерминированно
+	Tick <-chan time.Time
+}
+
+// defaultScalingInterval — период пересчёта размера пула, если Interval не задан
+const defaultScalingInterval = 100 * time.Millisecond
+
+// withDefaults заполняет Interval: без него контроллеру не создать тикер, а очереди — не с чем сравнивать
+func (p ScalingPolicy) withDefaults() ScalingPolicy {
+	if p.Interval <= 0 {
+		p.Interval = defaultScalingInterval
+	}
+	return p
+}
+
+// bounds возвращает границы пула. Меньше одного воркера быть не может: без воркеров некому заметить
+// закрытие входного канала, и пул никогда не завершится. Максимум не меньше минимума
+func (p ScalingPolicy) bounds() (minWorkers, maxWorkers int) {
+	minWorkers, maxWorkers = max(p.MinWorkers, 1), p.MaxWorkers
+	return minWorkers, max(maxWorkers, minWorkers)
+}
+
+// DesiredWorkers рассчитывает размер пула: сколько воркеров нужно, чтобы разобрать очередь
+// глубиной queueDepth за один интервал при среднем времени обработки записи avgLatency.
+// Рост происходит сразу до нужного значения, уменьшение — по одному воркеру за интервал,
+// чтобы пул не «дёргался» при кратковременных паузах во входных данных. MinWorkers меньше 1 считается равным 1,
+// Interval не больше 0 — равным 100 мс
+func DesiredWorkers(p ScalingPolicy, current, queueDepth int, avgLatency time.Duration) int {
+	p = p.withDefaults()
+	minWorkers, maxWorkers := p.bounds()
+	needed := minWorkers
+	if queueDepth > 0 {
+		if avgLatency <= 0 { // Задержка ещё неизвестна — добавляем по одному воркеру
+			needed = current + 1
+		} else {
+			work := time.Duration(queueDepth) * avgLatency     // Сколько работы накопилось в очереди
+			needed = int((work + p.Interval - 1) / p.Interval) // Округляем вверх
+		}
+	}
+
+	desired := current
+	switch {
+	case needed > current:
+		desired = needed
+	case needed < current:
+		desired = current - 1
+	}
+
+	if desired < minWorkers { // Держим размер пула в заданных границах
+		desired = minWorkers
+	}
+	if desired > maxWorkers {
+		desired = maxWorkers
+	}
+	return desired
+}
+
+// autoscale — контроллер пула: запускает MinWorkers воркеров и на каждом тике подгоняет их число под нагрузку
+func (p *pool) autoscale(policy ScalingPolicy) {
+	defer p.wg.Done()
+
+	policy = policy.withDefaults()
+	tick := policy.Tick
+	if tick == nil {
+		ticker := time.NewTicker(policy.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	workers := 0
+	resize := func(target int) {
+		for ; workers < target; workers++ {
+			select {
+			case <-p.quit: // Отменяем ещё не полученный сигнал на завершение вместо запуска нового воркера
+			default:
+				p.startWorker()
+			}
+		}
+		for ; workers > target; workers-- {
+			p.quit <- struct{}{} // Буфер канала равен MaxWorkers, поэтому отправка не блокируется
+		}
+		SetPoolMetrics(p.stats, workers, len(p.input))
+	}
+	resizeLogged := func(target int) {
+		if from := workers; target != from {
+			resize(target)
+			p.log.Info(i18n.T("pool.resized"), slog.Int("from", from), slog.Int("to", workers), slog.Int("queue", len(p.input)))
+		}
+	}
+
+	minWorkers, _ := policy.bounds()
+	resize(minWorkers)
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.inputClosed: // Входные данные закончились — воркеры завершатся сами
+			return
+		case <-tick:
+			resizeLogged(DesiredWorkers(policy, workers, len(p.input), p.latency.reset()))
+		}
+	}
+}
+
+// latencyMeter накапливает время обработки записей между тиками контроллера
+type latencyMeter struct {
+	mu    sync.Mutex
+	total time.Duration
+	count int
+}
+
+func (m *latencyMeter) observe(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.total += d
+	m.count++
+}
+
+// reset возвращает среднюю задержку с прошлого вызова и обнуляет счётчики
+func (m *latencyMeter) reset() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.count == 0 {
+		return 0
+	}
+	avg := m.total / time.Duration(m.count)
+	m.total, m.count = 0, 0
+	return avg
+}
@@ -0,0 +1,181 @@
+package processor
+
+import (
+	"context" // Для управления отменой пула
+	"testing" // Cтандартная библиотека для тестов Go
+	"time"    // Для интервалов и задержек
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Тест расчёта размера пула ================================================
+
+func TestDesiredWorkers(t *testing.T) {
+	policy := ScalingPolicy{MinWorkers: 2, MaxWorkers: 10, Interval: 100 * time.Millisecond}
+
+	tests := []struct {
+		name       string
+		current    int
+		queueDepth int
+		latency    time.Duration
+		expected   int
+	}{
+		{"пустая очередь — уменьшаем на одного", 5, 0, 10 * time.Millisecond, 4},
+		{"не опускаемся ниже минимума", 2, 0, 0, 2},
+		{"задержка неизвестна — добавляем одного", 3, 50, 0, 4},
+		{"очередь разбирается за интервал — растём сразу", 2, 40, 10 * time.Millisecond, 4},
+		{"не превышаем максимум", 4, 1000, 50 * time.Millisecond, 10},
+		{"очереди хватает текущих воркеров — уменьшаем", 6, 10, 10 * time.Millisecond, 5},
+	}
+
+	for _, tt := range tests {
+		got := DesiredWorkers(policy, tt.current, tt.queueDepth, tt.latency)
+		if got != tt.expected {
+			t.Errorf("%s: ожидалось %d воркеров, получили %d", tt.name, tt.expected, got)
+		}
+	}
+
+	zero := ScalingPolicy{MinWorkers: 0, MaxWorkers: 4, Interval: 100 * time.Millisecond}
+	if got := DesiredWorkers(zero, 1, 0, 10*time.Millisecond); got != 1 {
+		t.Errorf("Пул не должен сжиматься до нуля воркеров, получили %d", got)
+	}
+	// Без Interval очередь сравнивается с периодом по умолчанию 100 мс: 10 × 50 мс — работа для 5 воркеров
+	if got := DesiredWorkers(ScalingPolicy{MaxWorkers: 10}, 1, 10, 50*time.Millisecond); got != 5 {
+		t.Errorf("Ожидалось 5 воркеров при Interval по умолчанию, получили %d", got)
+	}
+}
+
+// Нулевой Interval без Tick не роняет контроллер: тикер создаётся с периодом по умолчанию
+func TestAdaptivePoolZeroInterval(t *testing.T) {
+	defer verifyNoLeaks(t)
+
+	input := make(chan model.LogEntry, 3)
+	for i := 0; i < 3; i++ {
+		input <- model.LogEntry{IP: "1.1.1.1", StatusCode: 200}
+	}
+	close(input)
+	output, failed := ProcessLogsWithErrors(context.Background(), input, Options{Scaling: &ScalingPolicy{MaxWorkers: 2}},
+		&model.Statistics{RequestsByIP: make(map[string]int)})
+	failedDone := drained(failed)
+
+	n := 0
+	for range output {
+		n++
+	}
+	<-failedDone
+	if n != 3 {
+		t.Errorf("Ожидалось 3 обработанные записи, получили %d", n)
+	}
+}
+
+// С MinWorkers: 0 пул всё равно держит одного воркера, который замечает закрытие входа и завершает пул
+func TestAdaptivePoolZeroMinWorkers(t *testing.T) {
+	defer verifyNoLeaks(t)
+
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+	input := make(chan model.LogEntry, 3)
+	tick := make(chan time.Time)
+	output, failed := ProcessLogsWithErrors(context.Background(), input, Options{
+		Scaling: &ScalingPolicy{MinWorkers: 0, MaxWorkers: 0, Interval: time.Second, Tick: tick},
+	}, stats)
+	failedDone := drained(failed)
+
+	for i := 0; i < 3; i++ { // Пустая очередь: контроллер хотел бы уменьшить пул
+		tick <- time.Now()
+	}
+	for i := 0; i < 3; i++ {
+		input <- model.LogEntry{IP: "1.1.1.1", StatusCode: 200}
+	}
+	close(input)
+
+	done := make(chan int)
+	go func() {
+		n := 0
+		for range output {
+			n++
+		}
+		done <- n
+	}()
+	select {
+	case n := <-done:
+		if n != 3 {
+			t.Errorf("Ожидалось 3 обработанные записи, получили %d", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Пул с MinWorkers: 0 не закрыл выходной канал")
+	}
+	<-failedDone
+}
+
+// ================================================ Тест масштабирования пула ================================================
+
+func TestAdaptivePoolScaling(t *testing.T) {
+	defer verifyNoLeaks(t)
+
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+	input := make(chan model.LogEntry, 20)
+	for i := 0; i < 20; i++ {
+		input <- model.LogEntry{IP: "1.1.1.1", StatusCode: 200}
+	}
+
+	gate := make(chan struct{}) // Пока шлюз закрыт, воркеры держат по одной записи и очередь не пустеет
+	stage := func(ctx context.Context, entry *model.LogEntry) error {
+		select {
+		case <-gate:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// Канал тиков без буфера: когда отправка k-го тика завершилась, контроллер
+	// гарантированно закончил обработку всех предыдущих тиков
+	tick := make(chan time.Time)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	output, failed := ProcessLogsWithErrors(ctx, input, Options{
+		Stages:  []Stage{stage},
+		Scaling: &ScalingPolicy{MinWorkers: 1, MaxWorkers: 8, Interval: time.Second, Tick: tick},
+	}, stats)
+	failedDone := drained(failed)
+
+	metrics := func() (int, int) {
+		stats.Mu.Lock()
+		defer stats.Mu.Unlock()
+		return stats.ActiveWorkers, stats.QueueDepth
+	}
+
+	for i := 0; i < 4; i++ {
+		tick <- time.Now()
+	}
+	if workers, depth := metrics(); workers < 4 || depth == 0 { // Три тика уже обработаны: 1 → 2 → 3 → 4
+		t.Errorf("Ожидался рост пула минимум до 4 воркеров при непустой очереди, получили %d (очередь %d)", workers, depth)
+	}
+
+	for i := 0; i < 20; i++ {
+		tick <- time.Now()
+	}
+	if workers, _ := metrics(); workers != 8 {
+		t.Errorf("Ожидалось 8 воркеров (максимум), получили %d", workers)
+	}
+
+	close(gate) // Разрешаем обработку и дожидаемся, пока очередь опустеет
+	for i := 0; i < 20; i++ {
+		<-output
+	}
+
+	for i := 0; i < 9; i++ { // Уменьшение — по одному воркеру за тик: 8 → 1
+		tick <- time.Now()
+	}
+	if workers, depth := metrics(); workers != 1 || depth != 0 {
+		t.Errorf("Ожидалось уменьшение пула до 1 воркера при пустой очереди, получили %d (очередь %d)", workers, depth)
+	}
+
+	close(input)
+	for range output {
+	}
+	<-failedDone
+	if stats.TotalRequests != 20 {
+		t.Errorf("Ожидалось обработать 20 записей, получили %d", stats.TotalRequests)
+	}
+}
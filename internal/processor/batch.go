@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"context" // Для управления отменой
+	"errors"  // Для проверки ошибки ErrSkip
+	"sync"    // Для ожидания воркеров
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Пакетная обработка логов ================================================
+
+// ProcessBatches — пакетный вариант ProcessLogsWithErrors: воркеры получают срезы записей,
+// выполняют над каждой записью этапы opts.Stages и обновляют статистику один раз на пачку.
+// Для дешёвых этапов это убирает накладные расходы на передачу каждой записи через канал.
+// Пакетный режим использует фиксированный пул из opts.NumWorkers воркеров, opts.Scaling игнорируется
+// ProcessBatches забирает пачки из input во владение: успешные записи складываются в тот же срез,
+// поэтому после отправки пачку нельзя ни менять, ни читать — результат приходит в output
+func ProcessBatches(ctx context.Context, input <-chan []model.LogEntry, opts Options, stats *model.Statistics) (<-chan []model.LogEntry, <-chan model.FailedEntry) {
+	output := make(chan []model.LogEntry, opts.NumWorkers)
+	failed := make(chan model.FailedEntry, 100)
+
+	var wg sync.WaitGroup
+	wg.Add(opts.NumWorkers)
+	for i := 0; i < opts.NumWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				var batch []model.LogEntry
+				select {
+				case <-ctx.Done():
+					return
+				case b, ok := <-input:
+					if !ok {
+						return
+					}
+					batch = b
+				}
+
+				processed := batch[:0] // Пачка принадлежит пулу: успешные записи складываем в тот же срез, чтобы не выделять память
+				for _, entry := range batch {
+					attempts, retries, err := runStages(ctx, opts.Stages, opts.Retry, &entry)
+					if ctx.Err() != nil {
+						return
+					}
+					if errors.Is(err, ErrSkip) { // Запись отброшена фильтром
+						RecordFiltered(stats)
+						continue
+					}
+					if err != nil {
+						RecordFailure(stats, retries)
+						select {
+						case failed <- model.FailedEntry{Entry: entry, Err: err, Attempts: attempts}:
+						case <-ctx.Done():
+							return
+						}
+						continue
+					}
+					if retries > 0 {
+						RecordRetries(stats, retries)
+					}
+					processed = append(processed, entry)
+				}
+
+				UpdateStatisticsBatch(stats, processed) // Один захват mutex на всю пачку
+
+				select {
+				case output <- processed:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(output)
+		close(failed)
+	}()
+
+	return output, failed
+}
@@ -0,0 +1,245 @@
+package processor
+
+import (
+	"bufio"         // Для быстрой записи сгенерированного файла
+	"context"       // Для управления отменой
+	"errors"        // Для тестовых ошибок
+	"fmt"           // Для генерации строк лога
+	"os"            // Для работы с файлами
+	"path/filepath" // Для пути к сгенерированному файлу
+	"strings"       // Для чтения CSV из строки
+	"sync"          // Для однократной генерации файла
+	"testing"       // Cтандартная библиотека для тестов Go
+	"time"          // Для измерения скорости
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Тесты пакетного режима ================================================
+
+func TestReadBatches(t *testing.T) {
+	csvContent := "timestamp,ip,method,url,status,response_time\n"
+	for i := 0; i < 7; i++ {
+		csvContent += fmt.Sprintf("2024-01-15 10:30:0%d,10.0.0.%d,GET,/index,200,10\n", i, i)
+	}
+
+	batches, errs := ReadBatches(context.Background(), strings.NewReader(csvContent), 3)
+
+	var sizes []int
+	for b := range batches {
+		sizes = append(sizes, len(b))
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("ReadBatches вернул ошибку: %v", err)
+	}
+	if fmt.Sprint(sizes) != "[3 3 1]" { // 7 записей пачками по 3
+		t.Errorf("Ожидались пачки [3 3 1], получили %v", sizes)
+	}
+}
+
+func TestReadBatchesError(t *testing.T) {
+	csvContent := "timestamp,ip,method,url,status,response_time\n" +
+		"2024-01-15 10:30:00,10.0.0.1,GET,/index,200,10\n" +
+		"2024-01-15 10:30:01,10.0.0.1,GET,/index,abc,10\n"
+
+	batches, errs := ReadBatches(context.Background(), strings.NewReader(csvContent), 10)
+	for range batches {
+	}
+	if err := <-errs; err == nil || !strings.Contains(err.Error(), "status") {
+		t.Errorf("Ожидалась ошибка разбора status, получили: %v", err)
+	}
+}
+
+func TestReadInvalidIP(t *testing.T) {
+	csvContent := "timestamp,ip,method,url,status,response_time\n" +
+		"2024-01-15 10:30:00,2001:db8::1,GET,/index,200,10\n" +
+		"2024-01-15 10:30:01,10.0.0.300,GET,/index,200,10\n"
+
+	reader, err := NewLogReader(strings.NewReader(csvContent))
+	if err != nil {
+		t.Fatalf("NewLogReader вернул ошибку: %v", err)
+	}
+	entry, err := reader.Read()
+	if err != nil || !entry.Addr.Is6() {
+		t.Errorf("Ожидался разобранный IPv6-адрес, получили %v (ошибка: %v)", entry.Addr, err)
+	}
+	if _, err := reader.Read(); err == nil || !strings.Contains(err.Error(), "IP") {
+		t.Errorf("Ожидалась ошибка неверного IP, получили: %v", err)
+	}
+}
+
+func TestReadForwardedColumns(t *testing.T) {
+	csvContent := "timestamp,ip,method,url,status,response_time,X-Forwarded-For,x_real_ip,user_agent\n" +
+		"2024-01-15 10:30:00,10.0.0.1,GET,/index,200,10,\"198.51.100.7, 10.1.1.1\",198.51.100.7,curl/8.4.0\n" +
+		"2024-01-15 10:30:01,10.0.0.1,GET,/index,200,10\n"
+
+	reader, err := NewLogReader(strings.NewReader(csvContent))
+	if err != nil {
+		t.Fatalf("NewLogReader вернул ошибку: %v", err)
+	}
+	entry, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Read вернул ошибку: %v", err)
+	}
+	if entry.ForwardedFor != "198.51.100.7, 10.1.1.1" || entry.RealIP != "198.51.100.7" || entry.UserAgent != "curl/8.4.0" {
+		t.Errorf("Неверно прочитаны необязательные колонки: %+v", entry)
+	}
+	if _, err := reader.Read(); err == nil { // В строке меньше полей, чем в заголовке
+		t.Errorf("Ожидалась ошибка неверного количества полей")
+	}
+}
+
+func TestProcessBatches(t *testing.T) {
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+	input := make(chan []model.LogEntry, 2)
+	input <- []model.LogEntry{{IP: "1.1.1.1", StatusCode: 200, ResponseTime: 100}, {IP: "1.1.1.1", URL: "/bad"}}
+	input <- []model.LogEntry{{IP: "2.2.2.2", StatusCode: 500, ResponseTime: 300}}
+	close(input)
+
+	stage := func(ctx context.Context, entry *model.LogEntry) error {
+		if entry.URL == "/bad" {
+			return errors.New("битая запись")
+		}
+		return nil
+	}
+	output, failed := ProcessBatches(context.Background(), input, Options{NumWorkers: 2, Stages: []Stage{stage}}, stats)
+	failedDone := drained(failed)
+
+	processed := 0
+	for b := range output {
+		processed += len(b)
+	}
+	<-failedDone
+
+	if processed != 2 || stats.TotalRequests != 2 || stats.ErrorCount != 1 || stats.FailedCount != 1 {
+		t.Errorf("Ожидалось 2 обработанные записи и 1 ошибка, получили %d: %+v", processed, stats)
+	}
+	if stats.AverageRespTime != 200 {
+		t.Errorf("Ожидалось среднее время ответа 200, получили %.2f", stats.AverageRespTime)
+	}
+}
+
+// ================================================ Бенчмарки: одиночный и пакетный режим ================================================
+
+const benchLines = 2_000_000 // Размер сгенерированного файла для бенчмарков
+
+var (
+	benchFileOnce sync.Once
+	benchDir      string // временный каталог со сгенерированным файлом, удаляется в TestMain
+	benchFilePath string
+)
+
+// TestMain удаляет файл бенчмарков после всех тестов пакета: он общий для нескольких бенчмарков,
+// поэтому b.TempDir, который очищается после каждого из них, не подходит
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if benchDir != "" {
+		os.RemoveAll(benchDir)
+	}
+	os.Exit(code)
+}
+
+// benchFile генерирует (один раз на запуск) CSV-файл на benchLines строк
+func benchFile(b *testing.B) string {
+	b.Helper()
+	benchFileOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "go-log-processor-bench-")
+		if err != nil {
+			b.Fatalf("Ошибка создания каталога: %v", err)
+		}
+		benchDir = dir
+		benchFilePath = filepath.Join(dir, fmt.Sprintf("logs-%d.csv", benchLines))
+
+		file, err := os.Create(benchFilePath)
+		if err != nil {
+			b.Fatalf("Ошибка создания файла: %v", err)
+		}
+		defer file.Close()
+
+		w := bufio.NewWriter(file)
+		methods := []string{"GET", "POST", "PUT", "DELETE"}
+		statuses := []int{200, 201, 301, 404, 500}
+		start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+		fmt.Fprintln(w, "timestamp,ip,method,url,status,response_time")
+		for i := 0; i < benchLines; i++ {
+			fmt.Fprintf(w, "%s,10.0.%d.%d,%s,/api/items/%d,%d,%d\n",
+				start.Add(time.Duration(i)*time.Millisecond).Format("2006-01-02 15:04:05"),
+				i%256, (i/256)%256, methods[i%len(methods)], i%1000, statuses[i%len(statuses)], i%2000)
+		}
+		if err := w.Flush(); err != nil {
+			b.Fatalf("Ошибка записи файла: %v", err)
+		}
+	})
+	return benchFilePath
+}
+
+func BenchmarkProcessSingle(b *testing.B) {
+	path := benchFile(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		file, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		reader, err := NewLogReader(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+		input := make(chan model.LogEntry, 100)
+		output, failed := ProcessLogsWithErrors(context.Background(), input, Options{NumWorkers: 4}, stats)
+		failedDone := drained(failed)
+		go func() {
+			defer close(input)
+			for {
+				entry, err := reader.Read()
+				if err != nil {
+					return
+				}
+				input <- entry
+			}
+		}()
+		for range output {
+		}
+		<-failedDone
+		file.Close()
+
+		if stats.TotalRequests != benchLines {
+			b.Fatalf("Обработано %d записей из %d", stats.TotalRequests, benchLines)
+		}
+	}
+
+	b.ReportMetric(float64(benchLines)*float64(b.N)/b.Elapsed().Seconds(), "entries/s")
+}
+
+func BenchmarkProcessBatch(b *testing.B) {
+	path := benchFile(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		file, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+		batches, errs := ReadBatches(context.Background(), file, 1024)
+		output, failed := ProcessBatches(context.Background(), batches, Options{NumWorkers: 4}, stats)
+		failedDone := drained(failed)
+		for range output {
+		}
+		<-failedDone
+		if err := <-errs; err != nil {
+			b.Fatal(err)
+		}
+		file.Close()
+
+		if stats.TotalRequests != benchLines {
+			b.Fatalf("Обработано %d записей из %d", stats.TotalRequests, benchLines)
+		}
+	}
+
+	b.ReportMetric(float64(benchLines)*float64(b.N)/b.Elapsed().Seconds(), "entries/s")
+}
@@ -0,0 +1,193 @@
+package processor
+
+import (
+	"bytes"   // Для поиска конца строки
+	"context" // Для остановки слежения
+	"errors"  // Для проверки io.EOF
+	"io"      // Для чтения файла
+	"os"      // Для открытия файла и проверки ротации
+	"strings" // Для чтения строки как потока
+	"time"    // Для интервала опроса
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Слежение за файлом ================================================
+
+// FollowOptions — настройки слежения за растущим логом (как tail -f)
+type FollowOptions struct {
+	Poll    time.Duration // как часто проверять, не появились ли новые строки (по умолчанию 500 мс)
+	FromEnd bool          // пропустить уже записанные строки и читать только новые
+	OnError func(error)   // вызывается для строк, которые не удалось разобрать (они пропускаются)
+}
+
+// Follow читает CSV-лог и продолжает ждать новые строки, пока не отменён ctx. Недописанная строка
+// (без перевода строки в конце) ждёт, пока её допишут. Если файл обрезали или заменили новым (ротация),
+// чтение начинается с начала нового файла, включая заголовок. Значения в кавычках с переводом строки не поддерживаются.
+// Канал ошибок получает не больше одной ошибки, после которой слежение прекращается
+func Follow(ctx context.Context, path string, opts FollowOptions) (<-chan model.LogEntry, <-chan error) {
+	if opts.Poll <= 0 {
+		opts.Poll = 500 * time.Millisecond
+	}
+	entries := make(chan model.LogEntry, 100)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(entries)
+		defer close(errs)
+
+		f := &follower{path: path, opts: opts}
+		defer f.close()
+		if err := f.open(opts.FromEnd); err != nil {
+			errs <- err
+			return
+		}
+
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := f.file.Read(buf)
+			if n > 0 {
+				for _, entry := range f.feed(buf[:n]) {
+					select {
+					case entries <- entry:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				errs <- i18n.Errorf("load.read", err)
+				return
+			}
+			if n > 0 {
+				continue
+			}
+
+			select { // Данных пока нет: ждём и проверяем, не сменился ли файл
+			case <-time.After(opts.Poll):
+			case <-ctx.Done():
+				return
+			}
+			if err := f.checkRotation(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	return entries, errs
+}
+
+// follower — состояние слежения за одним файлом
+type follower struct {
+	path    string
+	opts    FollowOptions
+	file    *os.File
+	offset  int64      // сколько байт прочитано из текущего файла
+	pending []byte     // недописанная строка
+	reader  *LogReader // nil — заголовок ещё не прочитан
+	skip    bool       // пропустить начало до перевода строки (после перехода в конец файла)
+	atEnd   bool       // после заголовка перейти в конец файла
+}
+
+// open открывает файл; fromEnd — после заголовка пропустить уже записанные строки
+func (f *follower) open(fromEnd bool) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return i18n.Errorf("load.open", err)
+	}
+	f.close()
+	f.file, f.offset, f.pending, f.reader, f.skip, f.atEnd = file, 0, nil, nil, false, fromEnd
+	return nil
+}
+
+func (f *follower) close() {
+	if f.file != nil {
+		f.file.Close()
+	}
+}
+
+// feed добавляет прочитанные байты и возвращает разобранные записи из завершённых строк
+func (f *follower) feed(data []byte) []model.LogEntry {
+	f.offset += int64(len(data))
+	f.pending = append(f.pending, data...)
+
+	var entries []model.LogEntry
+	for {
+		i := bytes.IndexByte(f.pending, '\n')
+		if i < 0 {
+			return entries
+		}
+		line := strings.TrimRight(string(f.pending[:i]), "\r")
+		f.pending = f.pending[i+1:]
+
+		switch {
+		case f.skip:
+			f.skip = false
+		case f.reader == nil:
+			reader, err := NewLogReader(strings.NewReader(line + "\n"))
+			if err != nil {
+				f.report(err)
+				continue
+			}
+			f.reader = reader
+			if f.atEnd {
+				f.atEnd = false
+				if err := f.seekEnd(); err != nil {
+					f.report(err)
+				}
+				return entries
+			}
+		case line == "":
+		default:
+			entry, err := f.reader.parseLine(line)
+			if err != nil {
+				f.report(err)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+}
+
+// seekEnd переходит в конец файла; если последняя строка недописана, её остаток будет пропущен
+func (f *follower) seekEnd() error {
+	end, err := f.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return i18n.Errorf("load.seek", err)
+	}
+	last := make([]byte, 1)
+	if end > 0 {
+		if _, err := f.file.ReadAt(last, end-1); err != nil {
+			return i18n.Errorf("load.read", err)
+		}
+	}
+	f.offset, f.pending, f.skip = end, nil, end > 0 && last[0] != '\n'
+	return nil
+}
+
+// checkRotation заново открывает файл, если его обрезали или заменили другим
+func (f *follower) checkRotation() error {
+	current, err := os.Stat(f.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) { // Во время ротации файла может ненадолго не быть
+			return nil
+		}
+		return i18n.Errorf("load.stat", err)
+	}
+	opened, err := f.file.Stat()
+	if err != nil {
+		return i18n.Errorf("load.stat", err)
+	}
+	if os.SameFile(current, opened) && current.Size() >= f.offset {
+		return nil
+	}
+	return f.open(false) // Новый файл читается целиком: всё в нём появилось после ротации
+}
+
+func (f *follower) report(err error) {
+	if f.opts.OnError != nil {
+		f.opts.OnError(err)
+	}
+}
@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"context"       // Для остановки слежения
+	"os"            // Для записи во временный файл
+	"path/filepath" // Для пути во временном каталоге
+	"testing"       // Cтандартная библиотека для тестов Go
+	"time"          // Для таймаутов
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+const followHeader = "timestamp,ip,method,url,status,response_time\n"
+
+// appendFile дописывает строку в конец файла
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// next ждёт следующую запись из канала
+func next(t *testing.T, entries <-chan model.LogEntry) model.LogEntry {
+	t.Helper()
+	select {
+	case entry, ok := <-entries:
+		if !ok {
+			t.Fatal("Канал записей закрыт раньше времени")
+		}
+		return entry
+	case <-time.After(2 * time.Second):
+		t.Fatal("Не дождались записи")
+	}
+	return model.LogEntry{}
+}
+
+// ================================================ Тесты слежения за файлом ================================================
+
+func TestFollowAppendPartialAndRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.csv")
+	if err := os.WriteFile(path, []byte(followHeader+"2024-01-15 10:30:00,10.0.0.1,GET,/a,200,10\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var skipped []error
+	ctx, cancel := context.WithCancel(context.Background())
+	entries, errs := Follow(ctx, path, FollowOptions{Poll: 10 * time.Millisecond, OnError: func(err error) { skipped = append(skipped, err) }})
+
+	if e := next(t, entries); e.URL != "/a" {
+		t.Errorf("Ожидалась уже записанная строка /a, получили %s", e.URL)
+	}
+
+	// Строку дописывают по частям: запись появляется только после перевода строки
+	appendFile(t, path, "2024-01-15 10:30:01,10.0.0.2,GET,/b,4")
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, path, "04,20\nbroken\n2024-01-15 10:30:02,10.0.0.3,GET,/c,200,30\n")
+	if e := next(t, entries); e.URL != "/b" || e.StatusCode != 404 {
+		t.Errorf("Неверно собрана строка из частей: %+v", e)
+	}
+	if e := next(t, entries); e.URL != "/c" {
+		t.Errorf("Ожидалась строка /c, получили %s", e.URL)
+	}
+
+	// Ротация: файл заменён новым с заголовком и другим набором колонок
+	rotated := path + ".new"
+	if err := os.WriteFile(rotated, []byte("timestamp,ip,method,url,status,response_time,user_agent\n2024-01-15 10:31:00,10.0.0.4,GET,/d,200,40,curl/8.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(rotated, path); err != nil {
+		t.Fatal(err)
+	}
+	if e := next(t, entries); e.URL != "/d" || e.UserAgent != "curl/8.0" {
+		t.Errorf("После ротации ожидалась строка /d с User-Agent, получили %+v", e)
+	}
+
+	cancel()
+	for range entries {
+	}
+	if err := <-errs; err != nil {
+		t.Errorf("Неожиданная ошибка: %v", err)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("Ожидалась одна пропущенная строка, получили %v", skipped)
+	}
+}
+
+func TestFollowFromEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.csv")
+	// Последняя строка недописана: её остаток тоже должен быть пропущен
+	if err := os.WriteFile(path, []byte(followHeader+"2024-01-15 10:30:00,10.0.0.1,GET,/old,200,10\n2024-01-15 10:30:01,10.0.0.1,GET,/part"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	entries, _ := Follow(ctx, path, FollowOptions{Poll: 10 * time.Millisecond, FromEnd: true})
+
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, path, "ial,200,10\n2024-01-15 10:30:02,10.0.0.1,GET,/new,200,10\n")
+	if e := next(t, entries); e.URL != "/new" {
+		t.Errorf("Ожидалась только новая строка /new, получили %s", e.URL)
+	}
+}
+
+func TestFollowMissingFile(t *testing.T) {
+	entries, errs := Follow(context.Background(), filepath.Join(t.TempDir(), "missing.csv"), FollowOptions{})
+	for range entries {
+	}
+	if err := <-errs; err == nil {
+		t.Error("Ожидалась ошибка открытия файла")
+	}
+}
@@ -0,0 +1,152 @@
+package processor
+
+import (
+	_ "embed"       // Для встроенного шаблона
+	"fmt"           // Для форматирования чисел
+	"html/template" // Для безопасной подстановки данных в HTML
+	"io"            // Для записи в произвольный поток
+	"time"          // Для интервалов графиков
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/alert"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/detect"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"
+)
+
+// ================================================ HTML-отчёт ================================================
+
+// Шаблон содержит все стили и скрипты: файл открывается без сети и без внешних библиотек
+//
+//go:embed report.html
+var htmlTemplateText string
+
+var htmlTemplate = template.Must(template.New("report").Funcs(htmlFuncs(nil)).Parse(htmlTemplateText))
+
+// htmlFuncs — функции шаблона на языке p: тексты страницы, числа и названия берутся из каталога
+func htmlFuncs(p *i18n.Printer) template.FuncMap {
+	return template.FuncMap{
+		"t":       p.Sprintf,
+		"lang":    func() string { return string(p.Lang()) },
+		"percent": func(v float64) string { return p.Number(v*100, 2) + "%" },
+		"ms":      func(v float64) string { return p.Sprintf("html.ms", v) },
+		"number":  func(v float64) string { return p.Number(v, 2) },
+		"time":    func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+		"anomaly": func(kind string) string { return anomalyLabel(p, kind) },
+		"client":  func(family string) string { return clientName(p, family) },
+		"state":   func(state string) string { return stateLabel(p, state) },
+		"share": func(part, total int) string { // Доля для полос в таблицах: значение CSS, не зависит от языка
+			if total == 0 {
+				return "0%"
+			}
+			return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+		},
+	}
+}
+
+// anomalyLabel — название вида аномалии
+func anomalyLabel(p *i18n.Printer, kind string) string {
+	switch kind {
+	case detect.AnomalySpike:
+		return p.Sprintf("anomaly.kind.spike")
+	case detect.AnomalyDrop:
+		return p.Sprintf("anomaly.kind.drop")
+	case detect.AnomalyErrorSurge:
+		return p.Sprintf("anomaly.kind.error_surge")
+	}
+	return kind
+}
+
+// stateLabel — название состояния правила оповещений
+func stateLabel(p *i18n.Printer, state string) string {
+	switch state {
+	case alert.StateInactive:
+		return p.Sprintf("alert.state.inactive")
+	case alert.StatePending:
+		return p.Sprintf("alert.state.pending")
+	case alert.StateFiring:
+		return p.Sprintf("alert.state.firing")
+	case alert.StateResolved:
+		return p.Sprintf("alert.state.resolved")
+	}
+	return state
+}
+
+// maxChartPoints — сколько интервалов графика заполняется нулями в пропусках. Если период длиннее,
+// на графике остаются только интервалы с запросами
+const maxChartPoints = 10000
+
+// htmlReport — данные шаблона
+type htmlReport struct {
+	Report
+	Generated time.Time
+	Chart     htmlChart
+}
+
+// htmlChart — данные графиков для скрипта в шаблоне
+type htmlChart struct {
+	BucketSeconds float64          `json:"bucket_seconds"`
+	Points        []htmlChartPoint `json:"points"`
+	Anomalies     []htmlChartMark  `json:"anomalies"`
+}
+
+// htmlChartPoint — один интервал. Пустые поля — интервал без запросов, на графике это разрыв линии
+type htmlChartPoint struct {
+	Time      int64    `json:"t"` // Unix, мс
+	Rate      float64  `json:"rate"`
+	ErrorRate *float64 `json:"err,omitempty"`
+	P50       *float64 `json:"p50,omitempty"`
+	P95       *float64 `json:"p95,omitempty"`
+	P99       *float64 `json:"p99,omitempty"`
+	Apdex     *float64 `json:"apdex,omitempty"`
+}
+
+// htmlChartMark — аномалия всего трафика, отмеченная на графиках
+type htmlChartMark struct {
+	Time  int64  `json:"t"`
+	Label string `json:"label"`
+}
+
+// WriteHTMLReport пишет отчёт одной HTML-страницей: графики скорости запросов, доли ошибок, перцентилей
+// времени ответа и Apdex по интервалам, таблицы маршрутов, IP и найденных аномалий
+func WriteHTMLReport(w io.Writer, s *model.Statistics, opts ReportOptions) error {
+	return renderHTML(w, BuildReport(s, opts), time.Now(), opts.Printer)
+}
+
+// renderHTML заполняет шаблон на языке p (nil — язык по умолчанию)
+func renderHTML(w io.Writer, r Report, generated time.Time, p *i18n.Printer) error {
+	bucket, err := utilits.ParseDuration(r.TimeSeries.Bucket)
+	if err != nil || bucket <= 0 {
+		bucket = model.DefaultApdexBucket
+	}
+	tmpl, err := htmlTemplate.Clone()
+	if err != nil {
+		return err
+	}
+	return tmpl.Funcs(htmlFuncs(p)).Execute(w, htmlReport{Report: r, Generated: generated, Chart: chartData(p, r, bucket)})
+}
+
+// chartData переводит временной ряд отчёта в точки графиков, заполняя пропуски пустыми интервалами
+func chartData(p *i18n.Printer, r Report, bucket time.Duration) htmlChart {
+	chart := htmlChart{BucketSeconds: bucket.Seconds(), Points: []htmlChartPoint{}, Anomalies: []htmlChartMark{}}
+	points := r.TimeSeries.Points
+	fill := len(points) > 0 && int(points[len(points)-1].Start.Sub(points[0].Start)/bucket) < maxChartPoints
+	for i, pt := range points {
+		if fill && i > 0 {
+			for t := points[i-1].Start.Add(bucket); t.Before(pt.Start); t = t.Add(bucket) {
+				chart.Points = append(chart.Points, htmlChartPoint{Time: t.UnixMilli()})
+			}
+		}
+		errRate, p50, p95, p99, apdex := pt.ErrorRate, pt.Latency.P50, pt.Latency.P95, pt.Latency.P99, pt.Apdex.Score
+		chart.Points = append(chart.Points, htmlChartPoint{
+			Time: pt.Start.UnixMilli(), Rate: round(float64(pt.Requests)/bucket.Seconds(), 4),
+			ErrorRate: &errRate, P50: &p50, P95: &p95, P99: &p99, Apdex: &apdex,
+		})
+	}
+	for _, a := range r.Anomalies {
+		if a.Route == "" {
+			chart.Anomalies = append(chart.Anomalies, htmlChartMark{Time: a.Time.UnixMilli(), Label: anomalyLabel(p, a.Kind)})
+		}
+	}
+	return chart
+}
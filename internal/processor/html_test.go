@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"bytes"         // Для отчёта в памяти
+	"encoding/json" // Для данных графиков
+	"regexp"        // Для поиска внешних ресурсов и данных графиков
+	"strings"       // Для проверки содержимого
+	"testing"       // Cтандартная библиотека для тестов Go
+	"time"          // Для работы с датой и временем
+	"unicode"       // Для поиска кириллицы в английском отчёте
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/alert"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/detect"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// chartJSON достаёт данные графиков из отчёта
+func chartJSON(t *testing.T, page string) htmlChart {
+	t.Helper()
+	m := regexp.MustCompile(`var data = (.*);\n`).FindStringSubmatch(page)
+	if m == nil {
+		t.Fatal("В отчёте нет данных графиков")
+	}
+	var chart htmlChart
+	if err := json.Unmarshal([]byte(m[1]), &chart); err != nil {
+		t.Fatalf("Данные графиков не в JSON: %v", err)
+	}
+	return chart
+}
+
+// ================================================ Тесты HTML-отчёта ================================================
+
+func TestHTMLReport(t *testing.T) {
+	stats := &model.Statistics{RequestsByIP: make(map[string]int), ApdexT: 100}
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for i, minute := range []int{0, 0, 1, 4} { // Минуты 2 и 3 без запросов
+		UpdateStatistics(stats, model.LogEntry{
+			Timestamp: start.Add(time.Duration(minute) * time.Minute), IP: "192.168.1.100", Method: "GET",
+			URL: "/search/</script><script>alert(1)</script>", StatusCode: 200 + 100*i, ResponseTime: 50 * (i + 1),
+		})
+	}
+	report := BuildReport(stats, ReportOptions{TopN: 5})
+	report.Anomalies = []ReportAnomaly{
+		{Time: start.Add(time.Minute), Kind: detect.AnomalySpike, Value: 40, Baseline: 2, Score: 9.5},
+		{Time: start.Add(4 * time.Minute), Route: "/api/orders", Kind: detect.AnomalyErrorSurge, Value: 0.5, Baseline: 0.01, Score: 6},
+	}
+
+	var buf bytes.Buffer
+	if err := renderHTML(&buf, report, start.Add(time.Hour), nil); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	page := buf.String()
+
+	if external := regexp.MustCompile(`(?i)<link|<script[^>]+src=|@import|url\(`).FindString(page); external != "" {
+		t.Errorf("Отчёт должен быть самодостаточным, найдено: %s", external)
+	}
+	if strings.Contains(page, "<script>alert(1)") || !strings.Contains(page, "/search/&lt;/script&gt;&lt;script&gt;alert(1)&lt;/script&gt;") {
+		t.Error("Маршрут должен экранироваться")
+	}
+	for _, want := range []string{
+		"Отчёт по логам", "Период: 2024-01-15 10:30:00 — 2024-01-15 10:34:00", "Сформирован 2024-01-15 11:30:00",
+		"Маршруты", "Самые активные IP", "<code>192.168.1.100</code>", "всплеск запросов", "рост доли ошибок", "T = 100 мс",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("В отчёте нет %q", want)
+		}
+	}
+
+	chart := chartJSON(t, page)
+	if chart.BucketSeconds != 60 || len(chart.Points) != 5 {
+		t.Fatalf("Ожидалось 5 интервалов по 60 с, получили %v: %+v", chart.BucketSeconds, chart.Points)
+	}
+	if p := chart.Points[0]; p.Rate != round(2.0/60, 4) || p.ErrorRate == nil || *p.ErrorRate != 0 || *p.P50 != 75 {
+		t.Errorf("Неверный первый интервал: %+v", p)
+	}
+	if p := chart.Points[2]; p.Rate != 0 || p.P50 != nil || p.Apdex != nil {
+		t.Errorf("Пропуск должен быть пустым интервалом: %+v", p)
+	}
+	if len(chart.Anomalies) != 1 || chart.Anomalies[0].Time != start.Add(time.Minute).UnixMilli() {
+		t.Errorf("На графиках отмечаются только аномалии всего трафика: %+v", chart.Anomalies)
+	}
+}
+
+func TestHTMLReportEnglish(t *testing.T) {
+	stats := &model.Statistics{RequestsByIP: make(map[string]int), ApdexT: 100}
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		UpdateStatistics(stats, model.LogEntry{Timestamp: start.Add(time.Duration(i) * time.Second), IP: "192.168.1.100", Method: "GET", URL: "/api/users", StatusCode: 200, ResponseTime: 1250})
+	}
+	report := BuildReport(stats, ReportOptions{TopN: 5})
+	report.Anomalies = []ReportAnomaly{{Time: start, Kind: detect.AnomalyDrop, Value: 1.5, Baseline: 20, Score: 4}}
+	report.Alerts = []ReportAlertRule{{Name: "errors", Severity: "critical", Condition: "errors > 0", Window: "5m", State: alert.StateFiring, Since: &start}}
+
+	var buf bytes.Buffer
+	if err := renderHTML(&buf, report, start.Add(time.Hour), i18n.New(i18n.EN)); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	page := buf.String()
+	for _, want := range []string{
+		`<html lang="en">`, "<h1>Log report</h1>", "Generated 2024-01-15 11:30:00", "Response time p50", "1,250 ms",
+		"Apdex (T = 100 ms)", "request drop", "<td class=\"num\">1.50</td>", "firing since 2024-01-15 10:30:00", "Request rate, per second",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("В английском отчёте нет %q", want)
+		}
+	}
+	if chart := chartJSON(t, page); len(chart.Anomalies) != 1 || chart.Anomalies[0].Label != "request drop" {
+		t.Errorf("Отметки аномалий должны подписываться по-английски: %+v", chart.Anomalies)
+	}
+	if i := strings.IndexFunc(page, func(r rune) bool { return unicode.Is(unicode.Cyrillic, r) }); i >= 0 {
+		t.Errorf("В английском отчёте остался русский текст: %q", page[max(i-80, 0):min(i+80, len(page))])
+	}
+}
+
+func TestHTMLReportEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHTMLReport(&buf, &model.Statistics{RequestsByIP: make(map[string]int)}, ReportOptions{TopN: 5}); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if chart := chartJSON(t, buf.String()); len(chart.Points) != 0 || chart.Anomalies == nil {
+		t.Errorf("Неверные данные графиков без запросов: %+v", chart)
+	}
+	if !strings.Contains(buf.String(), "Нет запросов.") {
+		t.Error("Без запросов таблицы должны заменяться пояснением")
+	}
+}
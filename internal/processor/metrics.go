@@ -0,0 +1,201 @@
+package processor
+
+import (
+	"bufio"    // Для буферизованного ответа
+	"fmt"      // Для форматирования строк метрик
+	"io"       // Для записи в произвольный поток
+	"net/http" // Для обработчика /metrics
+	"sort"     // Для стабильного порядка серий и поиска корзины
+	"strconv"  // Для чисел в формате Prometheus
+	"strings"  // Для экранирования меток
+	"sync"     // Для замера скорости между опросами
+	"time"     // Для скорости обработки
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Метрики Prometheus ================================================
+
+// MetricsContentType — тип ответа в текстовом формате Prometheus
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// LatencyBuckets — границы гистограммы времени ответа в секундах
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// statusClasses — значения метки class по индексу model.EndpointStats.Classes
+var statusClasses = [...]string{"1xx", "2xx", "3xx", "4xx", "5xx"}
+
+// MetricsOptions — настройки метрик
+type MetricsOptions struct {
+	QueueDepth func() int // текущая глубина входной очереди; nil — последний замер пула из статистики
+}
+
+// MetricsHandler отдаёт статистику в текстовом формате Prometheus. Скорость обработки считается
+// между соседними опросами, поэтому её видно и без rate() в запросах
+func MetricsHandler(s *model.Statistics, opts MetricsOptions) http.Handler {
+	m := &rateMeter{last: time.Now()}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MetricsContentType)
+		if err := writeMetrics(w, s, opts, m); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// WriteMetrics записывает статистику в текстовом формате Prometheus
+func WriteMetrics(w io.Writer, s *model.Statistics, opts MetricsOptions) error {
+	return writeMetrics(w, s, opts, nil)
+}
+
+// rateMeter — скорость обработки между опросами
+type rateMeter struct {
+	mu    sync.Mutex
+	last  time.Time
+	total int
+	rate  float64
+}
+
+// update возвращает записей в секунду с прошлого опроса. Опросы чаще раза в секунду получают прошлое значение
+func (m *rateMeter) update(total int) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if elapsed := now.Sub(m.last).Seconds(); elapsed >= 1 {
+		m.rate = float64(total-m.total) / elapsed
+		m.last, m.total = now, total
+	}
+	return m.rate
+}
+
+// metricsWriter пишет строки метрик; ошибка записи запоминается в bufio.Writer и возвращается из Flush
+type metricsWriter struct {
+	w *bufio.Writer
+}
+
+// header пишет описание и тип метрики
+func (m metricsWriter) header(name, typ, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample пишет значение; labels — пары имя, значение
+func (m metricsWriter) sample(name string, value float64, labels ...string) {
+	m.w.WriteString(name)
+	if len(labels) > 0 {
+		m.w.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				m.w.WriteByte(',')
+			}
+			m.w.WriteString(labels[i])
+			m.w.WriteString(`="`)
+			m.w.WriteString(labelEscaper.Replace(labels[i+1]))
+			m.w.WriteByte('"')
+		}
+		m.w.WriteByte('}')
+	}
+	m.w.WriteByte(' ')
+	m.w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	m.w.WriteByte('\n')
+}
+
+// metric пишет описание и одно значение без меток
+func (m metricsWriter) metric(name, typ, help string, value float64) {
+	m.header(name, typ, help)
+	m.sample(name, value)
+}
+
+// labelEscaper экранирует значение метки: обратная косая черта, кавычка и перевод строки
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeMetrics записывает все метрики; meter — замер скорости (nil — скорость не пишется)
+func writeMetrics(w io.Writer, s *model.Statistics, opts MetricsOptions, meter *rateMeter) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	m := metricsWriter{w: bufio.NewWriter(w)}
+	endpoints := make([]model.Endpoint, 0, len(s.ByEndpoint))
+	for e := range s.ByEndpoint {
+		endpoints = append(endpoints, e)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Route != endpoints[j].Route {
+			return endpoints[i].Route < endpoints[j].Route
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+
+	m.header("logproc_requests_total", "counter", "Обработанные запросы по методу, маршруту и классу ответа.")
+	for _, e := range endpoints {
+		for i, n := range s.ByEndpoint[e].Classes {
+			if n > 0 {
+				m.sample("logproc_requests_total", float64(n), "method", e.Method, "route", e.Route, "class", statusClasses[i])
+			}
+		}
+	}
+
+	m.header("logproc_errors_total", "counter", "Ответы с ошибкой: 4xx — ошибки клиента, 5xx — ошибки сервера.")
+	var classes [5]int
+	for _, e := range s.ByEndpoint {
+		for i, n := range e.Classes {
+			classes[i] += n
+		}
+	}
+	m.sample("logproc_errors_total", float64(classes[3]), "class", "4xx")
+	m.sample("logproc_errors_total", float64(classes[4]), "class", "5xx")
+
+	m.header("logproc_request_duration_seconds", "histogram", "Время ответа по методу и маршруту.")
+	for _, e := range endpoints {
+		writeHistogram(m, "logproc_request_duration_seconds", s.ByEndpoint[e], "method", e.Method, "route", e.Route)
+	}
+
+	t, _ := ApdexSettings(s)
+	m.metric("logproc_apdex_threshold_seconds", "gauge", "Порог Apdex T.", float64(t)/1000)
+	m.metric("logproc_apdex_score", "gauge", "Apdex по всем запросам, от 0 до 1.", s.Apdex.Score())
+	m.header("logproc_endpoint_apdex_score", "gauge", "Apdex по методу и маршруту, от 0 до 1.")
+	for _, e := range endpoints {
+		m.sample("logproc_endpoint_apdex_score", s.ByEndpoint[e].Apdex.Score(), "method", e.Method, "route", e.Route)
+	}
+
+	m.header("logproc_rejected_total", "counter", "Записи, не попавшие в статистику: отброшенные фильтрами, неразобранные строки и ошибки обработки.")
+	m.sample("logproc_rejected_total", float64(s.FilteredCount), "reason", "filtered")
+	m.sample("logproc_rejected_total", float64(s.ParseErrors), "reason", "parse_error")
+	m.sample("logproc_rejected_total", float64(s.FailedCount), "reason", "failed")
+	m.metric("logproc_retries_total", "counter", "Повторные попытки обработки.", float64(s.RetryCount))
+
+	queueDepth := s.QueueDepth
+	if opts.QueueDepth != nil {
+		queueDepth = opts.QueueDepth()
+	}
+	m.metric("logproc_queue_depth", "gauge", "Записи во входной очереди воркеров.", float64(queueDepth))
+	m.metric("logproc_workers", "gauge", "Воркеры в пуле.", float64(s.ActiveWorkers))
+	m.metric("logproc_processed_total", "counter", "Записи, учтённые в статистике.", float64(s.TotalRequests))
+	if meter != nil {
+		m.metric("logproc_processed_per_second", "gauge", "Скорость обработки с прошлого опроса, записей в секунду.", meter.update(s.TotalRequests))
+	}
+	if !s.LastSeen.IsZero() {
+		m.metric("logproc_last_entry_timestamp_seconds", "gauge", "Время самой поздней записи лога (Unix).", float64(s.LastSeen.UnixMilli())/1000)
+	}
+	return m.w.Flush()
+}
+
+// histogramBucket — номер корзины LatencyBuckets для времени ответа в мс; len(LatencyBuckets) — выше всех границ
+func histogramBucket(ms int) int {
+	return sort.Search(len(LatencyBuckets), func(i int) bool { return float64(ms) <= LatencyBuckets[i]*1000 })
+}
+
+// writeHistogram пишет накопительные корзины, сумму и количество. Корзины берутся из точных счётчиков
+// e.Histogram: значения Latency от 1000 мс округлены и у границ (2504 мс → 2500) попали бы не в ту корзину
+func writeHistogram(m metricsWriter, name string, e model.EndpointStats, labels ...string) {
+	labels = labels[:len(labels):len(labels)] // append ниже всегда копирует метки, не затирая их
+	cumulative := 0
+	for i, le := range LatencyBuckets {
+		if i < len(e.Histogram) {
+			cumulative += e.Histogram[i]
+		}
+		m.sample(name+"_bucket", float64(cumulative), append(labels, "le", strconv.FormatFloat(le, 'g', -1, 64))...)
+	}
+	m.sample(name+"_bucket", float64(e.Latency.Count), append(labels, "le", "+Inf")...)
+	m.sample(name+"_sum", float64(e.Latency.Sum)/1000, labels...)
+	m.sample(name+"_count", float64(e.Latency.Count), labels...)
+}
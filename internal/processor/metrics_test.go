@@ -0,0 +1,174 @@
+package processor
+
+import (
+	"bufio"             // Для разбора ответа построчно
+	"io"                // Для чтения ответа
+	"net/http"          // Для опроса /metrics
+	"net/http/httptest" // Для локального сервера
+	"strconv"           // Для значений метрик
+	"strings"           // Для разбора строк метрик
+	"testing"           // Cтандартная библиотека для тестов Go
+	"time"              // Для работы с датой и временем
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// scrape опрашивает сервер и возвращает значения по строке серии, например `logproc_workers`
+func scrape(t *testing.T, url string) map[string]float64 {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != MetricsContentType {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("Неверный ответ %s %q: %s", resp.Status, resp.Header.Get("Content-Type"), body)
+	}
+
+	samples := map[string]float64{}
+	typed := map[string]string{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# TYPE ") {
+			fields := strings.Fields(line)
+			if _, ok := typed[fields[2]]; ok {
+				t.Errorf("Тип метрики %s объявлен дважды", fields[2])
+			}
+			typed[fields[2]] = fields[3]
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("Неверное значение в строке %q: %v", line, err)
+		}
+		series := line[:i]
+		name, _, _ := strings.Cut(series, "{")
+		base := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(name, "_bucket"), "_sum"), "_count")
+		if typed[name] == "" && typed[base] != "histogram" {
+			t.Errorf("Серия %s без объявления типа", series)
+		}
+		samples[series] = value
+	}
+	return samples
+}
+
+// ================================================ Тесты метрик ================================================
+
+func TestMetricsEndpoint(t *testing.T) {
+	stats := &model.Statistics{RequestsByIP: make(map[string]int), ApdexT: 100}
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for i, l := range []model.LogEntry{
+		{Method: "GET", URL: "/api/users/1", StatusCode: 200, ResponseTime: 3},
+		{Method: "GET", URL: "/api/users/2", StatusCode: 200, ResponseTime: 120},
+		{Method: "GET", URL: "/api/users/3", StatusCode: 404, ResponseTime: 40},
+		{Method: "POST", URL: "/api/orders", StatusCode: 503, ResponseTime: 12000},
+		{Method: "GET", URL: `/say"hi"`, StatusCode: 301, ResponseTime: 1000},
+	} {
+		l.Timestamp = start.Add(time.Duration(i) * time.Second)
+		l.IP = "10.0.0.1"
+		UpdateStatistics(stats, l)
+	}
+	RecordFiltered(stats)
+	RecordParseError(stats)
+	RecordParseError(stats)
+	RecordFailure(stats, 3)
+	SetPoolMetrics(stats, 4, 0)
+
+	server := httptest.NewServer(MetricsHandler(stats, MetricsOptions{QueueDepth: func() int { return 17 }}))
+	defer server.Close()
+	got := scrape(t, server.URL)
+
+	users := `method="GET",route="/api/users/:id"`
+	for series, want := range map[string]float64{
+		`logproc_requests_total{` + users + `,class="2xx"}`:                                    2,
+		`logproc_requests_total{` + users + `,class="4xx"}`:                                    1,
+		`logproc_requests_total{method="POST",route="/api/orders",class="5xx"}`:                1,
+		`logproc_requests_total{method="GET",route="/say\"hi\"",class="3xx"}`:                  1,
+		`logproc_errors_total{class="4xx"}`:                                                    1,
+		`logproc_errors_total{class="5xx"}`:                                                    1,
+		`logproc_request_duration_seconds_bucket{` + users + `,le="0.005"}`:                    1,
+		`logproc_request_duration_seconds_bucket{` + users + `,le="0.05"}`:                     2,
+		`logproc_request_duration_seconds_bucket{` + users + `,le="0.25"}`:                     3,
+		`logproc_request_duration_seconds_bucket{` + users + `,le="+Inf"}`:                     3,
+		`logproc_request_duration_seconds_count{` + users + `}`:                                3,
+		`logproc_request_duration_seconds_sum{` + users + `}`:                                  0.163,
+		`logproc_request_duration_seconds_bucket{method="GET",route="/say\"hi\"",le="1"}`:      1,
+		`logproc_request_duration_seconds_bucket{method="POST",route="/api/orders",le="10"}`:   0,
+		`logproc_request_duration_seconds_bucket{method="POST",route="/api/orders",le="+Inf"}`: 1,
+		`logproc_apdex_threshold_seconds`:                                                      0.1,
+		`logproc_apdex_score`:                                                                  0.3,
+		`logproc_endpoint_apdex_score{` + users + `}`:                                          0.5,
+		`logproc_rejected_total{reason="filtered"}`:                                            1,
+		`logproc_rejected_total{reason="parse_error"}`:                                         2,
+		`logproc_rejected_total{reason="failed"}`:                                              1,
+		`logproc_retries_total`:                                                                3,
+		`logproc_queue_depth`:                                                                  17,
+		`logproc_workers`:                                                                      4,
+		`logproc_processed_total`:                                                              5,
+		`logproc_processed_per_second`:                                                         0, // Первый опрос раньше чем через секунду
+		`logproc_last_entry_timestamp_seconds`:                                                 float64(start.Add(4 * time.Second).Unix()),
+	} {
+		if v, ok := got[series]; !ok || v != want {
+			t.Errorf("%s = %v (есть: %v), ожидалось %v", series, v, ok, want)
+		}
+	}
+
+	for series, v := range got { // Корзины гистограммы не убывают
+		if !strings.HasPrefix(series, "logproc_request_duration_seconds_bucket") || strings.Contains(series, "+Inf") {
+			continue
+		}
+		labels, _, _ := strings.Cut(series, `,le=`)
+		if v > got[labels+`,le="+Inf"}`] {
+			t.Errorf("Корзина %s больше +Inf", series)
+		}
+	}
+}
+
+// Время ответа от секунды хранится с округлением, но корзины гистограммы считаются точно
+func TestMetricsHistogramEdges(t *testing.T) {
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+	for _, ms := range []int{2500, 2504, 4996, 5000, 5004, 10000, 10040} {
+		UpdateStatistics(stats, model.LogEntry{IP: "10.0.0.1", Method: "GET", URL: "/slow", StatusCode: 200, ResponseTime: ms})
+	}
+	var b strings.Builder
+	if err := WriteMetrics(&b, stats, MetricsOptions{}); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	for _, want := range []string{
+		`logproc_request_duration_seconds_bucket{method="GET",route="/slow",le="2.5"} 1`,
+		`logproc_request_duration_seconds_bucket{method="GET",route="/slow",le="5"} 4`,
+		`logproc_request_duration_seconds_bucket{method="GET",route="/slow",le="10"} 6`,
+		`logproc_request_duration_seconds_bucket{method="GET",route="/slow",le="+Inf"} 7`,
+	} {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("В метриках нет %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestMetricsEmpty(t *testing.T) {
+	var b strings.Builder
+	if err := WriteMetrics(&b, &model.Statistics{RequestsByIP: make(map[string]int)}, MetricsOptions{}); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if !strings.Contains(b.String(), "logproc_processed_total 0\n") || strings.Contains(b.String(), "logproc_last_entry_timestamp_seconds") ||
+		strings.Contains(b.String(), "logproc_processed_per_second") {
+		t.Errorf("Неверные метрики без данных:\n%s", b.String())
+	}
+}
+
+func TestRateMeter(t *testing.T) {
+	m := &rateMeter{last: time.Now().Add(-2 * time.Second)}
+	if rate := m.update(100); rate < 45 || rate > 50 {
+		t.Errorf("Ожидалось около 50 записей в секунду, получили %v", rate)
+	}
+	if rate := m.update(1000); rate < 45 || rate > 50 { // Меньше секунды с прошлого замера — прежнее значение
+		t.Errorf("Скорость не должна пересчитываться чаще раза в секунду: %v", rate)
+	}
+}
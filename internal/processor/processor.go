@@ -1,20 +1,25 @@
 package processor
 
 import (
-	"context"      // Для управления таймаутами и отменой задач
-	"encoding/csv" // Для чтения CSV-файлов построчно
-	"fmt"          // Для форматирования строк и вывода ошибок
-	"io"           // Для работы с потоками ввода-вывода
-	"os"           // Для открытия файла
-	"sort"         // Для сортировки срезов
-	"strconv"      // Для преобразования строк в числа
-
-	// Для преобразования int → string
-	"sync" // Для синхронизации горутин (WaitGroup)
-	"time" // Для работы с датой и временем
-
-	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"   // Импортируем структуры LogEntry и Statistics из пакета internal/model
-	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits" // Импортируем вспомогательные функции internal/utilits
+	"context"  // Для управления таймаутами и отменой задач
+	"errors"   // Для проверки ошибки ErrSkip
+	"io"       // Для работы с потоками ввода-вывода
+	"log/slog" // Для журнала воркеров
+	"os"       // Для открытия файла
+	"sort"     // Для сортировки срезов
+	"sync"     // Для синхронизации горутин (WaitGroup)
+	"time"     // Для работы с датой и временем
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/alert"     // Импортируем правила оповещений из internal/alert
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/detect"    // Импортируем детекторы подозрительной активности из internal/detect
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/geoip"     // Импортируем подпись автономной системы из internal/geoip
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"      // Импортируем каталоги сообщений из internal/i18n
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/logging"   // Импортируем журнал диагностики из internal/logging
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"     // Импортируем структуры LogEntry и Statistics из пакета internal/model
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"   // Импортируем агрегацию IP по подсетям из internal/netaddr
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/slo"       // Импортируем вычисление SLO из internal/slo
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/useragent" // Импортируем имя правила неизвестных ботов из internal/useragent
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"   // Импортируем вспомогательные функции internal/utilits
 )
 
 // ================================================  Загрузка логов ================================================
@@ -23,57 +28,23 @@ import (
 func LoadLogs(filePath string) ([]model.LogEntry, error) {
 	file, err := os.Open(filePath) // Открытие файла по указанному пути
 	if err != nil {                // Обработка ошибки открытия файла
-		return nil, fmt.Errorf("Ошибка открытия файла: %v", err)
+		return nil, i18n.Errorf("load.open", err)
 	}
 	defer file.Close() // Откладываем закрытие файла до конца функции
 
-	reader := csv.NewReader(file) // Cоздаём CSV-ридер, который будет построчно считывать данные из файла
-	reader.Comma = ','            // Указываем символ-разделитель в файле
-
-	// Пропускаем заголовок
-	_, err = reader.Read()
+	reader, err := NewLogReader(file) // Создаём ридер, который будет построчно разбирать записи из файла
 	if err != nil {
-		return nil, fmt.Errorf("Ошибка чтения заголовка: %v", err)
+		return nil, err
 	}
 
 	var logs []model.LogEntry // Создаём пустой срез для хранения всех логов
 	for {
-		record, err := reader.Read()
-
+		log, err := reader.Read()
 		if err != nil {
 			if err == io.EOF { // Если достигнут конец файла — выходим из цикла
 				break
 			}
-			return nil, fmt.Errorf("Ошибка чтения строки: %v", err)
-		}
-
-		if len(record) != 6 { // Проверяем формат данных
-			return nil, fmt.Errorf("Неверное количество полей в строке: %v", record)
-		}
-
-		statusCode, err := strconv.Atoi(record[4]) // Преобразуем статус в число
-		if err != nil {
-			return nil, fmt.Errorf("Ошибка преобразования status: %v", err)
-		}
-
-		t, err := time.Parse("2006-01-02 15:04:05", record[0])
-		if err != nil {
-			return nil, fmt.Errorf("Ошибка парсинга времени: %v", err)
-		}
-
-		respTime, err := strconv.Atoi(record[5]) // Преобразуем время ответа в число
-		if err != nil {
-			return nil, fmt.Errorf("Ошибка преобразования response_time: %v", err)
-		}
-
-		// Создаём экземпляр структуры LogEntry и заполняем его значениями из текущей строки.
-		log := model.LogEntry{
-			Timestamp:    t,
-			IP:           record[1],
-			Method:       record[2],
-			URL:          record[3],
-			StatusCode:   statusCode,
-			ResponseTime: respTime,
+			return nil, err
 		}
 
 		logs = append(logs, log) // Добавляем структуру в срез
@@ -84,80 +55,174 @@ func LoadLogs(filePath string) ([]model.LogEntry, error) {
 
 // ================================================ Обработка логов ================================================
 
+// ProcessLogs запускает numWorkers воркеров с имитацией обработки и возвращает канал обработанных записей.
+// Записи, которые не удалось обработать, учитываются в stats.FailedCount
 func ProcessLogs(ctx context.Context, input <-chan model.LogEntry, numWorkers int, stats *model.Statistics) <-chan model.LogEntry {
-	output := make(chan model.LogEntry, 100) // Создаём буферезованный выходной канал, куда воркеры будут отправлять обработанные записи
-	var wg sync.WaitGroup                    // Создаём WaitGroup, чтобы знать, когда все воркеры закончили работу
-	wg.Add(numWorkers)                       // Увеличиваем счётчик на количество воркеров
-
-	for i := 0; i < numWorkers; i++ { // Запускаем параллельные горутины
-		go func(workerID int) {
-			defer wg.Done()               // Автоматически уменьшит счётчик WaitGroup после завершения воркера
-			for logEntry := range input { // Воркер читает каждую запись из входного канала input
-				select {
-				case <-ctx.Done(): // Проверяем контекст: если пришёл сигнал отмены, воркер завершает работу
-					return
-				default: // Продолжаем обработку, если отмены нет
-					start := time.Now()
-					fmt.Printf("[%s] Воркер %d начал обработку: %s\n",
-						start.Format("2006-01-02 15:04:05"), workerID, utilits.LogEntryToString(logEntry))
-
-					// Имитация обработки
-					time.Sleep(time.Millisecond * 10)
-
-					UpdateStatistics(stats, logEntry) // Обновляем статистику при каждой обработанной записи для func (s *Statistics)
-
-					end := time.Now()
-					fmt.Printf("[%s] Воркер %d закончил обработку: %s\n",
-						end.Format("2006-01-02 15:04:05"), workerID, utilits.LogEntryToString(logEntry))
-
-					// Отправляем результат в выходной канал
-					select {
-					case output <- logEntry:
-					case <-ctx.Done():
-						return
-					}
-				}
-			}
-		}(i + 1) // Для нумерации воркеров с 1
+	output, failed := ProcessLogsWithErrors(ctx, input, Options{
+		NumWorkers: numWorkers,
+		Stages:     []Stage{SimulateWork(10 * time.Millisecond)}, // Имитация обработки
+	}, stats)
+
+	go func() { // Вычитываем канал ошибок, чтобы воркеры не блокировались на отправке
+		for range failed {
+		}
+	}()
+
+	return output
+}
+
+// ProcessLogsWithErrors запускает пул воркеров, которые выполняют этапы opts.Stages над каждой записью.
+// Возвращает канал обработанных записей и канал ошибок (dead letter) с записями, которые обработать не удалось.
+// Вызывающий код должен читать оба канала, пока они не закроются
+func ProcessLogsWithErrors(ctx context.Context, input <-chan model.LogEntry, opts Options, stats *model.Statistics) (<-chan model.LogEntry, <-chan model.FailedEntry) {
+	p := &pool{
+		ctx:    ctx,
+		input:  input,
+		opts:   opts,
+		stats:  stats,
+		output: make(chan model.LogEntry, 100),    // Создаём буферезованный выходной канал, куда воркеры будут отправлять обработанные записи
+		failed: make(chan model.FailedEntry, 100), // Канал для записей, обработка которых завершилась ошибкой
+		log:    opts.Logger,
+	}
+	if p.log == nil {
+		p.log = logging.Discard()
+	}
+
+	if opts.Scaling != nil { // Размер пула меняется в зависимости от нагрузки
+		_, maxWorkers := opts.Scaling.bounds()
+		p.quit = make(chan struct{}, maxWorkers)
+		p.inputClosed = make(chan struct{})
+		p.wg.Add(1) // Контроллер тоже учитывается в WaitGroup, чтобы каналы не закрылись раньше него
+		go p.autoscale(*opts.Scaling)
+	} else {
+		for i := 0; i < opts.NumWorkers; i++ { // Запускаем параллельные горутины
+			p.startWorker()
+		}
+		SetPoolMetrics(stats, opts.NumWorkers, len(input))
 	}
 
 	// Создаём отдельную горутину, которая ждёт завершения всех воркеров
 	go func() {
-		wg.Wait()
-		close(output)
+		p.wg.Wait()
+		close(p.output)
+		close(p.failed)
 	}()
 
-	return output // Возвращаем канал с обработанными логами
+	return p.output, p.failed // Возвращаем каналы с обработанными логами и ошибками
 }
 
-// ================================================ Фильтрация логов ================================================
+// pool — состояние пула воркеров одного вызова ProcessLogsWithErrors
+type pool struct {
+	ctx    context.Context
+	input  <-chan model.LogEntry
+	opts   Options
+	stats  *model.Statistics
+	output chan model.LogEntry
+	failed chan model.FailedEntry
+	log    *slog.Logger
+
+	wg          sync.WaitGroup // Создаём WaitGroup, чтобы знать, когда все воркеры закончили работу
+	nextID      int            // номер следующего воркера (меняет только запускающая горутина)
+	quit        chan struct{}  // сигналы воркерам на завершение при уменьшении пула
+	inputClosed chan struct{}  // закрывается, когда воркер обнаружил закрытие входного канала
+	closeOnce   sync.Once
+	latency     latencyMeter // время обработки записей для контроллера пула
+}
 
-func FilterLogs(logs []model.LogEntry, minStatus int) (chan model.LogEntry, chan model.LogEntry, chan model.LogEntry) {
-	logs2xx := make(chan model.LogEntry, len(logs))
-	logs4xx := make(chan model.LogEntry, len(logs))
-	logs5xx := make(chan model.LogEntry, len(logs))
+// startWorker запускает ещё одного воркера
+func (p *pool) startWorker() {
+	p.nextID++
+	p.wg.Add(1) // Увеличиваем счётчик на одного воркера
+	go p.worker(p.nextID)
+}
 
-	go func() {
-		defer close(logs2xx)
-		defer close(logs4xx)
-		defer close(logs5xx)
+func (p *pool) worker(workerID int) {
+	defer p.wg.Done()                                  // Автоматически уменьшит счётчик WaitGroup после завершения воркера
+	logger := p.log.With(slog.Int("worker", workerID)) // Каждая запись журнала воркера помечена его номером
+	debug := logger.Enabled(p.ctx, slog.LevelDebug)    // Атрибуты записи собираются, только если их кто-то прочитает
+	logger.Debug(i18n.T("worker.started"))
+	defer logger.Debug(i18n.T("worker.stopped"))
+	for {
+		// Ждём запись и отмену одновременно: если отправитель перестал слать данные,
+		// но не закрыл канал, воркер всё равно завершится по сигналу отмены
+		var logEntry model.LogEntry
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.quit: // Контроллер уменьшает пул (канал nil, если пул фиксированный)
+			return
+		case entry, ok := <-p.input: // Воркер читает каждую запись из входного канала input
+			if !ok { // Канал закрыт — задач больше нет
+				if p.inputClosed != nil {
+					p.closeOnce.Do(func() { close(p.inputClosed) })
+				}
+				return
+			}
+			logEntry = entry
+		}
+
+		if debug {
+			logger.Debug(i18n.T("worker.entry_started"), logging.Entry(logEntry))
+		}
 
-		for _, logEntry := range logs {
-			if logEntry.StatusCode < minStatus { // Tсли код статуса меньше minStatus, лог не отправляется ни в один канал
-				continue
+		start := time.Now()
+		attempts, retries, err := runStages(p.ctx, p.opts.Stages, p.opts.Retry, &logEntry)
+		elapsed := time.Since(start)
+		p.latency.observe(elapsed)
+		if p.ctx.Err() != nil { // Обработку прервала отмена контекста — это не ошибка записи
+			return
+		}
+		if errors.Is(err, ErrSkip) { // Запись отброшена фильтром
+			RecordFiltered(p.stats)
+			if debug {
+				logger.Debug(i18n.T("worker.entry_filtered"), logging.Entry(logEntry))
 			}
-			switch { // Классифицируем лог по диапазону HTTP-кодов
-			case logEntry.StatusCode >= 200 && logEntry.StatusCode < 300:
-				logs2xx <- logEntry
-			case logEntry.StatusCode >= 400 && logEntry.StatusCode < 500:
-				logs4xx <- logEntry
-			case logEntry.StatusCode >= 500 && logEntry.StatusCode < 600:
-				logs5xx <- logEntry
+			continue
+		}
+		if err != nil { // Обработка не удалась — отправляем запись в канал ошибок
+			RecordFailure(p.stats, retries)
+			logger.Warn(i18n.T("worker.entry_failed"), logging.Entry(logEntry), slog.Int("attempts", attempts), slog.Any("error", err))
+			select {
+			case p.failed <- model.FailedEntry{Entry: logEntry, Err: err, Attempts: attempts}:
+			case <-p.ctx.Done():
+				return
 			}
+			continue
+		}
+
+		UpdateStatistics(p.stats, logEntry) // Обновляем статистику при каждой обработанной записи для func (s *Statistics)
+		if retries > 0 {
+			RecordRetries(p.stats, retries)
 		}
-	}()
 
-	return logs2xx, logs4xx, logs5xx // Возвращаем три канала
+		if debug {
+			logger.Debug(i18n.T("worker.entry_done"), logging.Entry(logEntry), slog.Duration("elapsed", elapsed), slog.Int("retries", retries))
+		}
+
+		// Отправляем результат в выходной канал
+		select {
+		case p.output <- logEntry:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// ================================================ Фильтрация логов ================================================
+
+// FilterLogs раскладывает логи по каналам 2xx, 4xx и 5xx (набор StatusSplitRouter).
+// Записи со статусом меньше minStatus, а также 1xx и 3xx не попадают ни в один канал —
+// для всех классов и произвольных условий используйте Router
+func FilterLogs(logs []model.LogEntry, minStatus int) (chan model.LogEntry, chan model.LogEntry, chan model.LogEntry) {
+	filtered := make([]model.LogEntry, 0, len(logs))
+	for _, logEntry := range logs {
+		if logEntry.StatusCode >= minStatus { // Tсли код статуса меньше minStatus, лог не отправляется ни в один канал
+			filtered = append(filtered, logEntry)
+		}
+	}
+
+	outputs := StatusSplitRouter().Route(filtered)        // Классифицируем лог по диапазону HTTP-кодов
+	return outputs["2xx"], outputs["4xx"], outputs["5xx"] // Возвращаем три канала
 }
 
 // ================================================ Вывод статистики ================================================
@@ -166,8 +231,30 @@ func UpdateStatistics(s *model.Statistics, log model.LogEntry) {
 	s.Mu.Lock()         // Блокирует доступ к статистике, чтобы другие горутины не могли изменять её одновременно
 	defer s.Mu.Unlock() // Гарантирует разблокировку после выхода из функции
 
-	s.TotalRequests++        // Увеличиваем общее количество запросов на 1
-	s.RequestsByIP[log.IP]++ // Увеличиваем счётчик для IP, с которого пришёл этот запрос
+	addEntry(s, log)
+}
+
+// UpdateStatisticsBatch учитывает в статистике сразу пачку записей, захватывая mutex один раз
+func UpdateStatisticsBatch(s *model.Statistics, batch []model.LogEntry) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	for _, log := range batch {
+		addEntry(s, log)
+	}
+}
+
+// addEntry добавляет запись в статистику. Вызывается под s.Mu
+func addEntry(s *model.Statistics, log model.LogEntry) {
+	s.TotalRequests++                       // Увеличиваем общее количество запросов на 1
+	s.RequestsByIP[netaddr.ClientIP(log)]++ // Увеличиваем счётчик для IP клиента (за прокси — из X-Forwarded-For)
+	if netaddr.Proxied(log) {               // Запрос пришёл через доверенный прокси
+		if s.RequestsByProxy == nil {
+			s.RequestsByProxy = make(map[string]int)
+		}
+		s.ProxiedRequests++
+		s.RequestsByProxy[log.IP]++
+	}
 
 	// 4xx Ошибки клиента
 	// 5xx Ошибки сервера
@@ -175,6 +262,26 @@ func UpdateStatistics(s *model.Statistics, log model.LogEntry) {
 	if log.StatusCode >= 400 {
 		s.ErrorCount++
 	}
+	if log.UserAgent != "" { // Разрез по клиентам — только если в логе есть User-Agent
+		s.ByClient = countRequest(s.ByClient, log.Client.Family(), log.StatusCode)
+		group := &s.HumanRequests
+		if log.Client.Bot {
+			group = &s.BotRequests
+		}
+		group.Requests++
+		if log.StatusCode >= 400 {
+			group.Errors++
+		}
+	}
+	if log.Country != "" { // Геоданные есть только при включённом GeoIP
+		s.ByCountry = countRequest(s.ByCountry, log.Country, log.StatusCode)
+	}
+	if log.ASN != 0 {
+		s.ByASN = countRequest(s.ByASN, geoip.Location{ASN: log.ASN, ASOrg: log.ASOrg}.ASLabel(), log.StatusCode)
+	}
+
+	addApdex(s, log)
+	addLatency(s, log)
 
 	n := float64(s.TotalRequests) // Чтобы можно было делить числа с плавающей точкой
 
@@ -183,9 +290,204 @@ func UpdateStatistics(s *model.Statistics, log model.LogEntry) {
 	s.AverageRespTime = ((s.AverageRespTime * (n - 1)) + float64(log.ResponseTime)) / n
 }
 
+// addApdex учитывает запрос в Apdex: общем, по маршруту и по интервалу времени. Вызывается под s.Mu
+func addApdex(s *model.Statistics, log model.LogEntry) {
+	t, bucket := ApdexSettings(s)
+	if s.ApdexByRoute == nil {
+		s.ApdexByRoute = make(map[string]model.ApdexCounter)
+	}
+	if s.ApdexByBucket == nil {
+		s.ApdexByBucket = make(map[time.Time]model.ApdexCounter)
+	}
+
+	s.Apdex.Add(log, t)
+	route := utilits.NormalizeRoute(log.URL)
+	c := s.ApdexByRoute[route]
+	c.Add(log, t)
+	s.ApdexByRoute[route] = c
+	start := log.Timestamp.Truncate(bucket)
+	c = s.ApdexByBucket[start]
+	c.Add(log, t)
+	s.ApdexByBucket[start] = c
+}
+
+// addLatency учитывает код ответа и время ответа: общие, по методу и маршруту и по интервалу времени. Вызывается под s.Mu
+func addLatency(s *model.Statistics, log model.LogEntry) {
+	if s.ByStatus == nil {
+		s.ByStatus = make(map[int]int)
+	}
+	if s.ByEndpoint == nil {
+		s.ByEndpoint = make(map[model.Endpoint]model.EndpointStats)
+	}
+	if s.Series == nil {
+		s.Series = make(map[time.Time]model.SeriesPoint)
+	}
+	if s.FirstSeen.IsZero() || log.Timestamp.Before(s.FirstSeen) {
+		s.FirstSeen = log.Timestamp
+	}
+	if log.Timestamp.After(s.LastSeen) {
+		s.LastSeen = log.Timestamp
+	}
+
+	isError := 0
+	if log.StatusCode >= 400 {
+		isError = 1
+	}
+	s.ByStatus[log.StatusCode]++
+	s.Latency.Add(log.ResponseTime)
+
+	t, bucket := ApdexSettings(s)
+	key := model.Endpoint{Method: log.Method, Route: utilits.NormalizeRoute(log.URL)}
+	e := s.ByEndpoint[key]
+	e.Requests++
+	e.Errors += isError
+	if class := log.StatusCode/100 - 1; class >= 0 && class < len(e.Classes) {
+		e.Classes[class]++
+	}
+	e.Latency.Add(log.ResponseTime)
+	if e.Histogram == nil {
+		e.Histogram = make([]int, len(LatencyBuckets)+1)
+	}
+	e.Histogram[histogramBucket(log.ResponseTime)]++
+	e.Apdex.Add(log, t)
+	s.ByEndpoint[key] = e
+
+	start := log.Timestamp.Truncate(bucket)
+	p := s.Series[start]
+	p.Requests++
+	p.Errors += isError
+	p.Latency.Add(log.ResponseTime)
+	s.Series[start] = p
+}
+
+// ApdexSettings возвращает порог T в мс и интервал Apdex с учётом значений по умолчанию
+func ApdexSettings(s *model.Statistics) (int, time.Duration) {
+	t, bucket := s.ApdexT, s.ApdexBucket
+	if t <= 0 {
+		t = model.DefaultApdexT
+	}
+	if bucket <= 0 {
+		bucket = model.DefaultApdexBucket
+	}
+	return t, bucket
+}
+
+// countRequest учитывает запрос в счётчике группы key; карта создаётся при первом использовании
+func countRequest(m map[string]model.RequestCounter, key string, status int) map[string]model.RequestCounter {
+	if m == nil {
+		m = make(map[string]model.RequestCounter)
+	}
+	c := m[key]
+	c.Requests++
+	if status >= 400 {
+		c.Errors++
+	}
+	m[key] = c
+	return m
+}
+
+// SetPoolMetrics сохраняет текущий размер пула воркеров и глубину входной очереди
+func SetPoolMetrics(s *model.Statistics, workers, queueDepth int) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	s.ActiveWorkers = workers
+	s.QueueDepth = queueDepth
+}
+
+// RecordFailure учитывает запись, обработка которой завершилась ошибкой, и сделанные для неё повторы
+func RecordFailure(s *model.Statistics, retries int) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	s.FailedCount++
+	s.RetryCount += retries
+}
+
+// RecordRetries учитывает повторные попытки для записи, которая в итоге обработана успешно
+func RecordRetries(s *model.Statistics, retries int) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	s.RetryCount += retries
+}
+
+// RecordFiltered учитывает запись, отброшенную фильтром
+func RecordFiltered(s *model.Statistics) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	s.FilteredCount++
+}
+
+// RecordParseError учитывает строку, которую не удалось разобрать
+func RecordParseError(s *model.Statistics) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	s.ParseErrors++
+}
+
+// MarkPartial помечает статистику как неполную (обработка была прервана до конца входных данных)
+func MarkPartial(s *model.Statistics) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	s.Partial = true
+}
+
+// ReportOptions — настройки итогового отчёта
+type ReportOptions struct {
+	TopN int // сколько IP (и подсетей) показать в топе
+
+	// Длина префикса для топа подсетей, например /24 для IPv4 и /64 для IPv6.
+	// Если обе равны 0, топ подсетей не выводится; если задана только одна, вторая берётся по умолчанию (24 или 64)
+	SubnetV4Bits int
+	SubnetV6Bits int
+
+	BruteForce *detect.BruteForceDetector // раздел о переборе паролей (nil — не выводится)
+	Scanners   *detect.ScannerDetector    // подозрительные клиенты рядом с топом IP (nil — не выводится)
+	Anomalies  *detect.AnomalyDetector    // хронология аномалий трафика (nil — не выводится)
+	SLO        *slo.Evaluator             // соответствие SLO и тревоги (nil — не выводится)
+	Alerts     *alert.Engine              // состояние правил оповещений (nil — не выводится)
+
+	Printer *i18n.Printer // язык отчёта (nil — язык по умолчанию, см. i18n.SetDefault)
+}
+
 // SummaryStatistics — возвращает красиво отформатированную статистику
 // topN — сколько IP показать в топе
 func SummaryStatistics(s *model.Statistics, topN int) string {
+	return SummaryReport(s, ReportOptions{TopN: topN})
+}
+
+// SummaryReport — то же, что SummaryStatistics, но с дополнительными разделами по настройкам opts
+func SummaryReport(s *model.Statistics, opts ReportOptions) string {
+	topN := opts.TopN
+	p := opts.Printer
+	if p == nil {
+		p = i18n.Default()
+	}
+	line := func(key string, args ...any) string { return p.Sprintf(key, args...) + "\n" }
+
+	// Разделы детекторов и движка оповещений собираются до блокировки статистики, как в BuildReport:
+	// движок сам читает статистику под своей блокировкой, и обратный порядок привёл бы к взаимоблокировке
+	var scanners, detectors string
+	if opts.Scanners != nil {
+		scanners = opts.Scanners.Report(p, topN)
+	}
+	if opts.BruteForce != nil {
+		detectors += opts.BruteForce.Report(p, topN)
+	}
+	if opts.Anomalies != nil {
+		detectors += opts.Anomalies.Report(p, 0)
+	}
+	if opts.SLO != nil {
+		detectors += opts.SLO.Report(p)
+	}
+	if opts.Alerts != nil {
+		detectors += opts.Alerts.Report(p)
+	}
+
 	s.Mu.Lock()         // Блокируем доступ к статистике, чтобы другие горутины не мешали
 	defer s.Mu.Unlock() // Разблокируем после выхода из функции
 
@@ -215,18 +517,157 @@ func SummaryStatistics(s *model.Statistics, topN int) string {
 	}
 
 	// Формируем результат в виде строки
-	result := fmt.Sprintf(
-		"Всего запросов: %d\n"+
-			"Ошибок (4xx/5xx): %d\n"+
-			"Среднее время ответа: %.2f мс\n"+
-			"Топ %d IP:\n",
-		s.TotalRequests, s.ErrorCount, s.AverageRespTime, topN,
-	)
+	result := ""
+	if s.Partial { // Явно предупреждаем, что отчёт построен не по всем данным
+		result += line("summary.partial")
+	}
+	result += line("summary.total", s.TotalRequests) +
+		line("summary.errors", s.ErrorCount) +
+		line("summary.average", s.AverageRespTime)
+	apdexT, apdexBucket := ApdexSettings(s)
+	if s.Apdex.Total() > 0 {
+		result += line("summary.apdex", apdexT, s.Apdex.Score(), s.Apdex.Satisfied, s.Apdex.Tolerating, s.Apdex.Frustrated)
+	}
+	result += line("summary.failed", s.FailedCount, s.RetryCount) +
+		line("summary.top_ips", topN)
 
 	// Добавляем построчно информацию о каждом IP из топа
 	for i, ip := range topIPs {
-		result += fmt.Sprintf("  %d. %s — %d запросов\n", i+1, ip.IP, ip.Count)
+		result += line("summary.requests_row", i+1, ip.IP, ip.Count)
 	}
+	if opts.Scanners != nil { // Сканеры часто не попадают в топ по объёму, поэтому показываем их отдельно рядом с ним
+		result += scanners
+	}
+
+	if s.ProxiedRequests > 0 { // Сколько клиентов определено по заголовкам прокси и через какие прокси они пришли
+		result += line("summary.proxied", s.ProxiedRequests)
+		proxies := make([]string, 0, len(s.RequestsByProxy))
+		for ip := range s.RequestsByProxy {
+			proxies = append(proxies, ip)
+		}
+		sort.Slice(proxies, func(i, j int) bool {
+			if s.RequestsByProxy[proxies[i]] != s.RequestsByProxy[proxies[j]] {
+				return s.RequestsByProxy[proxies[i]] > s.RequestsByProxy[proxies[j]]
+			}
+			return proxies[i] < proxies[j]
+		})
+		if len(proxies) > topN {
+			proxies = proxies[:topN]
+		}
+		for i, ip := range proxies {
+			result += line("summary.requests_row", i+1, ip, s.RequestsByProxy[ip])
+		}
+	}
+
+	if len(s.ByClient) > 0 {
+		result += line("summary.humans_bots", s.HumanRequests.Requests, s.HumanRequests.Errors, s.BotRequests.Requests, s.BotRequests.Errors)
+		result += line("summary.top_clients", topN)
+		result += formatCounters(p, s.ByClient, topN, clientName)
+	}
+	if len(s.ByCountry) > 0 {
+		result += line("summary.top_countries", topN)
+		result += formatCounters(p, s.ByCountry, topN, nil)
+	}
+	if len(s.ByASN) > 0 {
+		result += line("summary.top_asns", topN)
+		result += formatCounters(p, s.ByASN, topN, nil)
+	}
+
+	if len(s.ApdexByRoute) > 1 { // Для одного маршрута разрез повторяет общий Apdex
+		result += line("summary.worst_routes", topN)
+		result += formatApdex(p, s.ApdexByRoute, topN)
+	}
+	if len(s.ApdexByBucket) > 1 {
+		buckets := make(map[string]model.ApdexCounter, len(s.ApdexByBucket))
+		for start, c := range s.ApdexByBucket {
+			buckets[start.Format("2006-01-02 15:04:05")] = c
+		}
+		result += line("summary.worst_buckets", topN, apdexBucket)
+		result += formatApdex(p, buckets, topN)
+	}
+
+	if opts.SubnetV4Bits > 0 || opts.SubnetV6Bits > 0 { // Топ подсетей показывает «шумные» диапазоны, а не отдельные адреса
+		v4, v6 := opts.SubnetV4Bits, opts.SubnetV6Bits
+		if v4 == 0 {
+			v4 = 24
+		}
+		if v6 == 0 {
+			v6 = 64
+		}
+		result += line("summary.top_subnets", topN, v4, v6)
+		for i, subnet := range netaddr.TopSubnets(s.RequestsByIP, v4, v6, topN) {
+			result += line("summary.requests_row", i+1, subnet.Subnet, subnet.Count)
+		}
+	}
+
+	result += detectors
 
 	return result // Возвращаем готовую строку со статистикой
 }
+
+// formatCounters форматирует topN групп с наибольшим числом запросов. name переводит ключ группы
+// в подпись (nil — ключ печатается как есть)
+func formatCounters(p *i18n.Printer, m map[string]model.RequestCounter, topN int, name func(*i18n.Printer, string) string) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]].Requests != m[keys[j]].Requests {
+			return m[keys[i]].Requests > m[keys[j]].Requests
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > topN {
+		keys = keys[:topN]
+	}
+
+	result := ""
+	for i, key := range keys {
+		label := key
+		if name != nil {
+			label = name(p, key)
+		}
+		result += p.Sprintf("summary.counter_row", i+1, label, m[key].Requests, m[key].Errors) + "\n"
+	}
+	return result
+}
+
+// clientName — подпись семейства клиентов: общие группы переводятся, имена браузеров и ботов остаются как есть
+func clientName(p *i18n.Printer, family string) string {
+	switch family {
+	case model.FamilyOther:
+		return p.Sprintf("client.other")
+	case useragent.OtherBot:
+		return p.Sprintf("client.other_bot")
+	}
+	return family
+}
+
+// formatApdex форматирует topN групп с наименьшим Apdex; при равенстве первой идёт группа с большим числом запросов
+func formatApdex(p *i18n.Printer, m map[string]model.ApdexCounter, topN int) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := m[keys[i]], m[keys[j]]
+		if a.Score() != b.Score() {
+			return a.Score() < b.Score()
+		}
+		if a.Total() != b.Total() {
+			return a.Total() > b.Total()
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > topN {
+		keys = keys[:topN]
+	}
+
+	result := ""
+	for i, k := range keys {
+		c := m[k]
+		result += p.Sprintf("summary.apdex_row", i+1, k, c.Score(), c.Total(), c.Frustrated) + "\n"
+	}
+	return result
+}
@@ -1,13 +1,29 @@
 package processor
 
 import (
-	"context" // Для управления отменой/таймаутом горутин
-	"os"      // Для работы с файлами (создание временного CSV)
-	"strings" // Для работы со строками
-	"testing" // Cтандартная библиотека для тестов Go
-	"time"    // Для работы с датой и временем
+	"bytes"         // Для журнала и HTML-отчёта в памяти
+	"context"       // Для управления отменой/таймаутом горутин
+	"encoding/json" // Для разбора журнала в JSON
+	"errors"        // Для создания тестовых ошибок
+	"io"            // Для чтения перехваченного stdout
+	"log/slog"      // Для уровня журнала
+	"os"            // Для работы с файлами (создание временного CSV)
+	"runtime"       // Для снимка стеков горутин при поиске утечек
+	"strings"       // Для работы со строками
+	"testing"       // Cтандартная библиотека для тестов Go
+	"time"          // Для работы с датой и временем
+	"unicode"       // Для поиска кириллицы в английской сводке
 
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/alert"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/detect"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/geoip"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/geoip/mmdbtest"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/logging"
 	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/slo"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/useragent"
 )
 
 // ============================= Вспомогательная функция для создания тестового CSV ===================================
@@ -81,6 +97,342 @@ func TestProcessLogs(t *testing.T) {
 	}
 }
 
+// ================================================ Тест канала ошибок ================================================
+
+func TestProcessLogsWithErrors(t *testing.T) {
+	stats := &model.Statistics{
+		RequestsByIP: make(map[string]int),
+	}
+	logEntries := []model.LogEntry{ // Тестовые данные: вторая запись всегда падает, третья — падает временно
+		{IP: "1.1.1.1", URL: "/ok", StatusCode: 200},
+		{IP: "2.2.2.2", URL: "/broken", StatusCode: 200},
+		{IP: "3.3.3.3", URL: "/flaky", StatusCode: 200},
+	}
+
+	input := make(chan model.LogEntry, len(logEntries))
+	for _, l := range logEntries {
+		input <- l
+	}
+	close(input)
+
+	flakyCalls := 0
+	stage := func(ctx context.Context, entry *model.LogEntry) error {
+		switch entry.URL {
+		case "/broken":
+			return errors.New("битая запись")
+		case "/flaky":
+			flakyCalls++
+			if flakyCalls < 3 { // Первые две попытки завершаются временной ошибкой
+				return Transient(errors.New("сервис недоступен"))
+			}
+		}
+		return nil
+	}
+
+	output, failed := ProcessLogsWithErrors(context.Background(), input, Options{
+		NumWorkers: 1,
+		Stages:     []Stage{stage},
+		Retry:      RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+	}, stats)
+
+	var failedEntries []model.FailedEntry
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for f := range failed {
+			failedEntries = append(failedEntries, f)
+		}
+	}()
+
+	processed := 0
+	for range output {
+		processed++
+	}
+	<-done
+
+	if processed != 2 { // /ok и /flaky должны пройти
+		t.Errorf("Ожидалось обработать 2 записи, получили %d", processed)
+	}
+	if len(failedEntries) != 1 || failedEntries[0].Entry.URL != "/broken" || failedEntries[0].Attempts != 1 {
+		t.Fatalf("Канал ошибок не соответствует ожиданиям: %+v", failedEntries)
+	}
+	if stats.FailedCount != 1 || stats.RetryCount != 2 || stats.TotalRequests != 2 {
+		t.Errorf("Статистика не соответствует ожиданиям: %+v", stats)
+	}
+}
+
+func TestProcessLogsLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _, err := logging.New(logging.Options{Level: slog.LevelDebug, Format: logging.FormatJSON, Output: &buf})
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	input := make(chan model.LogEntry, 3)
+	for _, url := range []string{"/ok", "/broken", "/skip"} {
+		input <- model.LogEntry{IP: "1.1.1.1", URL: url, StatusCode: 200}
+	}
+	close(input)
+	stage := func(ctx context.Context, entry *model.LogEntry) error {
+		switch entry.URL {
+		case "/broken":
+			return errors.New("битая запись")
+		case "/skip":
+			return ErrSkip
+		}
+		return nil
+	}
+
+	// Журнал воркеров не попадает в stdout: там только результаты
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	output, failed := ProcessLogsWithErrors(context.Background(), input, Options{NumWorkers: 2, Stages: []Stage{stage}, Logger: logger},
+		&model.Statistics{RequestsByIP: make(map[string]int)})
+	go func() {
+		for range failed {
+		}
+	}()
+	for range output {
+	}
+	os.Stdout = stdout
+	w.Close()
+	if printed, _ := io.ReadAll(r); len(printed) != 0 {
+		t.Errorf("Воркеры не должны печатать в stdout: %q", printed)
+	}
+
+	messages := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record struct {
+			Level    string
+			Msg      string
+			Worker   int
+			Attempts int
+			Error    string
+			Entry    struct{ URL string }
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Строка журнала не в JSON: %q", line)
+		}
+		if record.Worker < 1 || record.Worker > 2 {
+			t.Errorf("Запись журнала без номера воркера: %s", line)
+		}
+		messages[record.Level+" "+record.Msg+" "+record.Entry.URL]++
+		if record.Level == "WARN" && (record.Entry.URL != "/broken" || record.Attempts != 1 || record.Error != "битая запись") {
+			t.Errorf("Неверная запись об ошибке: %s", line)
+		}
+	}
+	for _, want := range []string{
+		"DEBUG " + i18n.T("worker.started") + " ",
+		"DEBUG " + i18n.T("worker.entry_done") + " /ok",
+		"DEBUG " + i18n.T("worker.entry_filtered") + " /skip",
+		"WARN " + i18n.T("worker.entry_failed") + " /broken",
+	} {
+		if messages[want] == 0 {
+			t.Errorf("В журнале нет %q: %v", want, messages)
+		}
+	}
+	if messages["DEBUG "+i18n.T("worker.started")+" "] != 2 || messages["DEBUG "+i18n.T("worker.stopped")+" "] != 2 {
+		t.Errorf("Ожидался запуск и остановка двух воркеров: %v", messages)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	calls := 0
+	stage := func(ctx context.Context, entry *model.LogEntry) error {
+		calls++
+		return Transient(errors.New("таймаут"))
+	}
+
+	var entry model.LogEntry
+	attempts, err := runWithRetry(context.Background(), stage, RetryPolicy{MaxAttempts: 4, Backoff: time.Millisecond}, &entry)
+	if !IsTransient(err) || attempts != 4 || calls != 4 { // После исчерпания попыток возвращается последняя ошибка
+		t.Errorf("Ожидалось 4 попытки и временная ошибка, получили %d попыток, ошибка: %v", attempts, err)
+	}
+}
+
+func TestFilterStage(t *testing.T) {
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+	input := make(chan model.LogEntry, 3)
+	input <- model.LogEntry{IP: "1.1.1.1", StatusCode: 200}
+	input <- model.LogEntry{IP: "2.2.2.2", StatusCode: 500}
+	input <- model.LogEntry{IP: "3.3.3.3", StatusCode: 503}
+	close(input)
+
+	output, failed := ProcessLogsWithErrors(context.Background(), input, Options{
+		NumWorkers: 2,
+		Stages:     []Stage{FilterStage(StatusRange(500, 599))},
+	}, stats)
+	failedDone := drained(failed)
+
+	count := 0
+	for range output {
+		count++
+	}
+	<-failedDone
+
+	// Отфильтрованные записи не считаются ни результатом, ни ошибкой
+	if count != 2 || stats.TotalRequests != 2 || stats.FailedCount != 0 {
+		t.Errorf("Ожидалось 2 записи после фильтра без ошибок, получили %d: %+v", count, stats)
+	}
+}
+
+// ================================================ Тесты отмены и утечек горутин ================================================
+
+// verifyNoLeaks проверяет (в духе goleak), что после теста не осталось горутин пакета processor.
+// Горутины завершаются асинхронно, поэтому проверка повторяется до истечения времени ожидания
+func verifyNoLeaks(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		leaked := leakedGoroutines()
+		if len(leaked) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Обнаружены незавершённые горутины:\n%s", strings.Join(leaked, "\n\n"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// leakedGoroutines возвращает стеки горутин, которые выполняют код пакета processor (кроме самих тестов)
+func leakedGoroutines() []string {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+
+	var leaked []string
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		if strings.Contains(g, "testing.tRunner") || strings.Contains(g, "verifyNoLeaks") {
+			continue // Горутина самого теста
+		}
+		if strings.Contains(g, "Go-Log-Processor/internal/processor.") {
+			leaked = append(leaked, g)
+		}
+	}
+	return leaked
+}
+
+func TestProcessLogsCancelWithOpenInput(t *testing.T) {
+	defer verifyNoLeaks(t)
+
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+	input := make(chan model.LogEntry) // Канал никогда не закрывается и в него ничего не пишут
+	ctx, cancel := context.WithCancel(context.Background())
+
+	output := ProcessLogs(ctx, input, 3, stats)
+	cancel()
+
+	select {
+	case _, ok := <-output:
+		if ok {
+			t.Fatalf("Ожидалось закрытие выходного канала без записей")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Воркеры не завершились после отмены контекста")
+	}
+}
+
+func TestProcessLogsTimeout(t *testing.T) {
+	defer verifyNoLeaks(t)
+
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+	input := make(chan model.LogEntry)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	go func() { // Отправитель сам следит за контекстом и не блокируется после остановки воркеров
+		for {
+			select {
+			case input <- model.LogEntry{IP: "1.1.1.1", StatusCode: 200}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	slow := func(ctx context.Context, entry *model.LogEntry) error { // Этап дольше таймаута
+		select {
+		case <-time.After(time.Hour):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	output, failed := ProcessLogsWithErrors(ctx, input, Options{NumWorkers: 4, Stages: []Stage{slow}}, stats)
+
+	for range output {
+	}
+	for range failed {
+	}
+	if stats.FailedCount != 0 { // Прерванная по таймауту обработка не считается ошибкой записи
+		t.Errorf("Ожидалось 0 ошибок обработки, получили %d", stats.FailedCount)
+	}
+}
+
+func TestProcessLogsSlowConsumer(t *testing.T) {
+	defer verifyNoLeaks(t)
+
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+	input := make(chan model.LogEntry, 300)
+	for i := 0; i < 300; i++ { // Больше, чем вмещает буфер выходного канала
+		input <- model.LogEntry{IP: "1.1.1.1", StatusCode: 200}
+	}
+	close(input)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	output, failed := ProcessLogsWithErrors(ctx, input, Options{NumWorkers: 2}, stats)
+
+	<-output // Потребитель прочитал одну запись и «завис», воркеры упираются в заполненный буфер
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-drained(failed): // Закрытие канала ошибок означает, что все воркеры вышли
+	case <-time.After(time.Second):
+		t.Fatalf("Воркеры не завершились при медленном потребителе")
+	}
+	for range output { // Остаток буфера можно дочитать и после остановки
+	}
+}
+
+func TestProcessLogsCancelDuringBackoff(t *testing.T) {
+	defer verifyNoLeaks(t)
+
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+	input := make(chan model.LogEntry, 1)
+	input <- model.LogEntry{IP: "1.1.1.1"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stage := func(ctx context.Context, entry *model.LogEntry) error {
+		cancel() // Отменяем контекст, пока воркер ждёт следующей попытки
+		return Transient(errors.New("временно недоступно"))
+	}
+	output, failed := ProcessLogsWithErrors(ctx, input, Options{
+		NumWorkers: 1,
+		Stages:     []Stage{stage},
+		Retry:      RetryPolicy{MaxAttempts: 10, Backoff: time.Hour},
+	}, stats)
+
+	select {
+	case <-drained(output):
+	case <-time.After(time.Second):
+		t.Fatalf("Воркер не прервал ожидание повторной попытки")
+	}
+	for range failed {
+	}
+}
+
+// drained возвращает канал, который закрывается, когда ch полностью вычитан и закрыт
+func drained[T any](ch <-chan T) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range ch {
+		}
+	}()
+	return done
+}
+
 // ================================================ Тест фильтрации логов ===============================================
 
 func TestFilterLogsChannels(t *testing.T) {
@@ -129,6 +481,354 @@ func TestSummaryStatistics(t *testing.T) {
 	}
 }
 
+func TestSummaryStatisticsPartial(t *testing.T) {
+	stats := &model.Statistics{
+		RequestsByIP: map[string]int{"1.1.1.1": 1},
+	}
+	UpdateStatistics(stats, model.LogEntry{IP: "1.1.1.1", StatusCode: 200})
+
+	if contains(SummaryStatistics(stats, 1), "частичная") { // Без прерывания предупреждения быть не должно
+		t.Errorf("Полная статистика помечена как частичная")
+	}
+
+	MarkPartial(stats)
+	if result := SummaryStatistics(stats, 1); !contains(result, "статистика частичная") {
+		t.Errorf("Ожидалось предупреждение о частичной статистике:\n%s", result)
+	}
+}
+
+func TestSummaryReportSubnets(t *testing.T) {
+	stats := &model.Statistics{
+		RequestsByIP: map[string]int{
+			"10.0.0.1":    2,
+			"10.0.0.2":    2,
+			"10.0.9.1":    3,
+			"2001:db8::1": 1,
+		},
+	}
+
+	if contains(SummaryReport(stats, ReportOptions{TopN: 2}), "подсетей") { // Без длины префикса раздела нет
+		t.Errorf("Топ подсетей выведен без настроек")
+	}
+
+	result := SummaryReport(stats, ReportOptions{TopN: 2, SubnetV4Bits: 24})
+	if !contains(result, "/24 для IPv4, /64 для IPv6") || !contains(result, "1. 10.0.0.0/24 — 4 запросов") ||
+		!contains(result, "2. 10.0.9.0/24 — 3 запросов") {
+		t.Errorf("Неверный топ подсетей:\n%s", result)
+	}
+}
+
+func TestResolveClientIPStatistics(t *testing.T) {
+	trusted, _ := netaddr.ParseCIDRList([]string{"10.0.0.0/8"})
+	stage := ResolveClientIP(netaddr.ProxyResolver{Trusted: trusted})
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+
+	for _, entry := range []model.LogEntry{
+		{IP: "10.0.0.1", ForwardedFor: "198.51.100.7"},
+		{IP: "10.0.0.1", ForwardedFor: "198.51.100.7, 10.0.0.2"},
+		{IP: "10.0.0.2", ForwardedFor: "198.51.100.8"},
+		{IP: "203.0.113.5", ForwardedFor: "1.1.1.1"}, // Не прокси — заголовку не верим
+	} {
+		if err := stage(context.Background(), &entry); err != nil {
+			t.Fatalf("Этап вернул ошибку: %v", err)
+		}
+		UpdateStatistics(stats, entry)
+	}
+
+	if stats.RequestsByIP["198.51.100.7"] != 2 || stats.RequestsByIP["203.0.113.5"] != 1 || stats.RequestsByIP["10.0.0.1"] != 0 {
+		t.Errorf("Запросы должны считаться по IP клиента: %v", stats.RequestsByIP)
+	}
+	if stats.ProxiedRequests != 3 || stats.RequestsByProxy["10.0.0.1"] != 2 {
+		t.Errorf("Неверный учёт прокси: %d, %v", stats.ProxiedRequests, stats.RequestsByProxy)
+	}
+	if result := SummaryStatistics(stats, 5); !contains(result, "Запросов через доверенные прокси: 3") ||
+		!contains(result, "1. 10.0.0.1 — 2 запросов") {
+		t.Errorf("В сводке нет раздела о прокси:\n%s", result)
+	}
+}
+
+func TestGeoIPStageStatistics(t *testing.T) {
+	buf, err := mmdbtest.Build(mmdbtest.Options{}, []mmdbtest.Network{
+		{CIDR: "81.2.69.0/24", Data: map[string]any{
+			"country":                        map[string]any{"iso_code": "GB"},
+			"city":                           map[string]any{"names": map[string]any{"en": "London"}},
+			"autonomous_system_number":       uint32(20712),
+			"autonomous_system_organization": "Andrews & Arnold Ltd",
+		}},
+		{CIDR: "2001:db8::/32", Data: map[string]any{"country": map[string]any{"iso_code": "DE"}}},
+	})
+	if err != nil {
+		t.Fatalf("Ошибка сборки тестовой базы: %v", err)
+	}
+	reader, err := geoip.FromBytes(buf)
+	if err != nil {
+		t.Fatalf("FromBytes вернул ошибку: %v", err)
+	}
+	stage := GeoIPStage(geoip.New(reader))
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+
+	for _, entry := range []model.LogEntry{
+		{IP: "81.2.69.1", StatusCode: 200},
+		{IP: "81.2.69.2", StatusCode: 500},
+		{IP: "2001:db8::1", StatusCode: 404},
+		{IP: "8.8.8.8", StatusCode: 200}, // Нет в базе — в разрезах по странам не учитывается
+	} {
+		if err := stage(context.Background(), &entry); err != nil {
+			t.Fatalf("Этап вернул ошибку: %v", err)
+		}
+		if entry.IP == "81.2.69.1" && (entry.City != "London" || entry.ASN != 20712) {
+			t.Errorf("Запись не дополнена геоданными: %+v", entry)
+		}
+		UpdateStatistics(stats, entry)
+	}
+
+	if stats.ByCountry["GB"] != (model.RequestCounter{Requests: 2, Errors: 1}) || stats.ByCountry["DE"].Errors != 1 || len(stats.ByCountry) != 2 {
+		t.Errorf("Неверная статистика по странам: %v", stats.ByCountry)
+	}
+	result := SummaryStatistics(stats, 5)
+	if !contains(result, "1. GB — 2 запросов, ошибок: 1") || !contains(result, "1. AS20712 Andrews & Arnold Ltd — 2 запросов") {
+		t.Errorf("В сводке нет разделов по странам и AS:\n%s", result)
+	}
+}
+
+func TestUserAgentStatistics(t *testing.T) {
+	stage := UserAgentStage(useragent.Default())
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+
+	chrome := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	for _, entry := range []model.LogEntry{
+		{IP: "10.0.0.1", StatusCode: 200, UserAgent: chrome},
+		{IP: "10.0.0.1", StatusCode: 404, UserAgent: chrome},
+		{IP: "10.0.0.2", StatusCode: 404, UserAgent: "sqlmap/1.7.2#stable (https://sqlmap.org)"},
+		{IP: "10.0.0.3", StatusCode: 200, UserAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"},
+		{IP: "10.0.0.4", StatusCode: 200}, // Без User-Agent в разрез по клиентам не попадает
+	} {
+		if err := stage(context.Background(), &entry); err != nil {
+			t.Fatalf("Этап вернул ошибку: %v", err)
+		}
+		UpdateStatistics(stats, entry)
+	}
+
+	if stats.HumanRequests != (model.RequestCounter{Requests: 2, Errors: 1}) || stats.BotRequests != (model.RequestCounter{Requests: 2, Errors: 1}) {
+		t.Errorf("Неверное разделение людей и ботов: люди %+v, боты %+v", stats.HumanRequests, stats.BotRequests)
+	}
+	if stats.ByClient["Chrome"].Requests != 2 || stats.ByClient["sqlmap"].Errors != 1 || len(stats.ByClient) != 3 {
+		t.Errorf("Неверная статистика по клиентам: %v", stats.ByClient)
+	}
+	result := SummaryStatistics(stats, 5)
+	if !contains(result, "Люди: 2 запросов, ошибок: 1; боты: 2 запросов, ошибок: 1") || !contains(result, "1. Chrome — 2 запросов") {
+		t.Errorf("В сводке нет разделов по клиентам:\n%s", result)
+	}
+}
+
+// Неузнанные клиенты и боты хранятся под идентификаторами и переводятся только при выводе
+func TestClientNames(t *testing.T) {
+	stage := UserAgentStage(useragent.Default())
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+	for _, ua := range []string{"нечто", "SomeCustomCrawler/1.0"} {
+		entry := model.LogEntry{IP: "10.0.0.1", StatusCode: 200, UserAgent: ua}
+		if err := stage(context.Background(), &entry); err != nil {
+			t.Fatalf("Этап вернул ошибку: %v", err)
+		}
+		UpdateStatistics(stats, entry)
+	}
+	if stats.ByClient[model.FamilyOther].Requests != 1 || stats.ByClient[useragent.OtherBot].Requests != 1 {
+		t.Fatalf("Неверная статистика по клиентам: %v", stats.ByClient)
+	}
+
+	if result := SummaryStatistics(stats, 5); !contains(result, ". Другое — 1 запросов") || !contains(result, ". Другой бот — 1 запросов") {
+		t.Errorf("В сводке нет переведённых семейств:\n%s", result)
+	}
+	if result := SummaryReport(stats, ReportOptions{TopN: 5, Printer: i18n.New(i18n.EN)}); !contains(result, ". Other — 1 requests") || !contains(result, ". Other bot — 1 requests") {
+		t.Errorf("В английской сводке нет переведённых семейств:\n%s", result)
+	}
+	var buf bytes.Buffer
+	if err := renderHTML(&buf, BuildReport(stats, ReportOptions{TopN: 5}), time.Now(), i18n.New(i18n.EN)); err != nil || !contains(buf.String(), "<td>Other bot</td>") {
+		t.Errorf("В HTML-отчёте семейство должно переводиться: %v", err)
+	}
+}
+
+func TestObserveStageBruteForceReport(t *testing.T) {
+	detector := detect.NewBruteForceDetector(detect.BruteForceConfig{IPThreshold: 3})
+	stage := ObserveStage(detector)
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		entry := model.LogEntry{Timestamp: start.Add(time.Duration(i) * time.Second), IP: "192.168.1.100", Method: "POST", URL: "/api/login", StatusCode: 400}
+		if err := stage(context.Background(), &entry); err != nil {
+			t.Fatalf("Этап вернул ошибку: %v", err)
+		}
+		UpdateStatistics(stats, entry)
+	}
+
+	result := SummaryReport(stats, ReportOptions{TopN: 5, BruteForce: detector})
+	if !contains(result, "1. IP 192.168.1.100 — 3 неудачных попыток") {
+		t.Errorf("В сводке нет раздела о переборе паролей:\n%s", result)
+	}
+}
+
+func TestSummaryReportScanners(t *testing.T) {
+	scanners := detect.NewScannerDetector(detect.ScannerConfig{})
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for _, entry := range []model.LogEntry{
+		{Timestamp: start, IP: "192.168.1.100", Method: "GET", URL: "/api/users", StatusCode: 200},
+		{Timestamp: start.Add(time.Second), IP: "203.0.113.9", Method: "GET", URL: "/.env", StatusCode: 404},
+	} {
+		scanners.Observe(entry)
+		UpdateStatistics(stats, entry)
+	}
+
+	result := SummaryReport(stats, ReportOptions{TopN: 5, Scanners: scanners})
+	top, suspicious := strings.Index(result, "Топ 5 IP:"), strings.Index(result, "Подозрительные клиенты (сканеры):")
+	if top < 0 || suspicious < top || !contains(result, "1. 203.0.113.9 — оценка 21") {
+		t.Errorf("Раздел о сканерах должен идти сразу после топа IP:\n%s", result)
+	}
+}
+
+func TestSummaryReportSLO(t *testing.T) {
+	cfg, err := slo.Parse(strings.NewReader(`{"slos": [{"name": "orders", "route": "^/api/orders", "target": 99, "window": "30d"}]}`))
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	evaluator := slo.NewEvaluator(cfg, nil)
+	stage := ObserveStage(evaluator)
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for i, status := range []int{200, 200, 503, 404} {
+		entry := model.LogEntry{Timestamp: start.Add(time.Duration(i) * time.Second), IP: "192.168.1.100", Method: "GET", URL: "/api/orders/1", StatusCode: status}
+		if err := stage(context.Background(), &entry); err != nil {
+			t.Fatalf("Этап вернул ошибку: %v", err)
+		}
+		UpdateStatistics(stats, entry)
+	}
+
+	result := SummaryReport(stats, ReportOptions{TopN: 5, SLO: evaluator})
+	if !contains(result, "1. orders (^/api/orders;") || !contains(result, "75,000% — НЕ ВЫПОЛНЯЕТСЯ; хороших 3 из 4") {
+		t.Errorf("В сводке нет раздела SLO:\n%s", result)
+	}
+}
+
+func TestSummaryReportSectionsEnglish(t *testing.T) {
+	objectives, err := slo.Parse(strings.NewReader(`{"slos": [{"name": "orders", "route": "^/api/orders", "target": 99, "window": "30d"}]}`))
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	rules, err := alert.Parse(strings.NewReader(`{"rules": [{"name": "errors", "expr": "error_rate", "threshold": 0.9, "window": "5m"}]}`))
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	opts := ReportOptions{
+		TopN:       5,
+		Scanners:   detect.NewScannerDetector(detect.ScannerConfig{}),
+		BruteForce: detect.NewBruteForceDetector(detect.BruteForceConfig{IPThreshold: 3}),
+		Anomalies:  detect.NewAnomalyDetector(detect.AnomalyConfig{}),
+		SLO:        slo.NewEvaluator(objectives, nil),
+		Alerts:     alert.NewEngine(rules, alert.Options{}),
+		Printer:    i18n.New(i18n.EN),
+	}
+	stage := ObserveStage(opts.Scanners, opts.BruteForce, opts.Anomalies, opts.SLO, opts.Alerts)
+	stats := &model.Statistics{RequestsByIP: make(map[string]int)}
+
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for i, entry := range []model.LogEntry{
+		{IP: "192.168.1.100", Method: "POST", URL: "/api/login", StatusCode: 400},
+		{IP: "192.168.1.100", Method: "POST", URL: "/api/login", StatusCode: 400},
+		{IP: "192.168.1.100", Method: "POST", URL: "/api/login", StatusCode: 400},
+		{IP: "203.0.113.9", Method: "GET", URL: "/.env", StatusCode: 404},
+		{IP: "10.0.0.1", Method: "GET", URL: "/api/orders/1", StatusCode: 200},
+		{IP: "10.0.0.1", Method: "GET", URL: "/api/orders/2", StatusCode: 503},
+	} {
+		entry.Timestamp = start.Add(time.Duration(i) * time.Second)
+		if err := stage(context.Background(), &entry); err != nil {
+			t.Fatalf("Этап вернул ошибку: %v", err)
+		}
+		UpdateStatistics(stats, entry)
+	}
+	opts.Alerts.Close()
+
+	result := SummaryReport(stats, opts)
+	for _, want := range []string{
+		"Suspicious clients (scanners):\n  1. 203.0.113.9 — score 21:",
+		"Password brute force (window 5\u00a0min, threshold 3 per IP / 20 per subnet):\n  1. IP 192.168.1.100 — 3 failed attempts",
+		"Traffic anomalies: none found",
+		"SLO (windows end at the last entry):\n  1. orders (^/api/orders; availability, errors — status 500 and above; target 99.00% over 30d",
+		"50.000% — NOT MET; good 1 of 2",
+		"Alerts (evaluated every 30s of log time):\n  1. errors [warning] error_rate > 0.9 over 5m: ok",
+	} {
+		if !contains(result, want) {
+			t.Errorf("В английской сводке нет %q:\n%s", want, result)
+		}
+	}
+	for _, r := range result {
+		if unicode.Is(unicode.Cyrillic, r) {
+			t.Fatalf("В английской сводке остался русский текст:\n%s", result)
+		}
+	}
+}
+
+func TestApdexStatistics(t *testing.T) {
+	stats := &model.Statistics{RequestsByIP: make(map[string]int), ApdexT: 100, ApdexBucket: time.Minute}
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for _, entry := range []model.LogEntry{
+		{Timestamp: start, URL: "/api/users/1", StatusCode: 200, ResponseTime: 100},                       // удовлетворён
+		{Timestamp: start.Add(10 * time.Second), URL: "/api/users/2", StatusCode: 200, ResponseTime: 400}, // терпимо
+		{Timestamp: start.Add(20 * time.Second), URL: "/api/users/3", StatusCode: 200, ResponseTime: 401}, // разочарован
+		{Timestamp: start.Add(time.Minute), URL: "/api/orders", StatusCode: 500, ResponseTime: 10},        // ошибка — разочарован
+		{Timestamp: start.Add(time.Minute), URL: "/api/health", StatusCode: 200, ResponseTime: 5},
+	} {
+		UpdateStatistics(stats, entry)
+	}
+
+	if a := stats.Apdex; a.Satisfied != 2 || a.Tolerating != 1 || a.Frustrated != 2 || a.Score() != 0.5 {
+		t.Errorf("Неверный общий Apdex: %+v", a)
+	}
+	if got := stats.ApdexByRoute["/api/users/:id"].Score(); got != 0.5 {
+		t.Errorf("Ожидался Apdex маршрута 0.5, получили %.2f", got)
+	}
+	if got := stats.ApdexByBucket[start.Add(time.Minute)]; got.Total() != 2 || got.Score() != 0.5 {
+		t.Errorf("Неверный Apdex второй минуты: %+v", got)
+	}
+
+	result := SummaryStatistics(stats, 2)
+	for _, want := range []string{
+		"Apdex (T = 100 мс): 0,50 — удовлетворены 2, терпимо 1, разочарованы 2",
+		"Худшие 2 маршрутов по Apdex:\n  1. /api/orders — 0,00 (запросов: 1, разочарованы: 1)\n  2. /api/users/:id — 0,50",
+		"Худшие 2 интервалов по Apdex (по 1\u00a0мин):\n  1. 2024-01-15 10:30:00 — 0,50 (запросов: 3, разочарованы: 1)",
+	} {
+		if !contains(result, want) {
+			t.Errorf("В сводке нет %q:\n%s", want, result)
+		}
+	}
+
+	result = SummaryReport(stats, ReportOptions{TopN: 2, Printer: i18n.New(i18n.EN)})
+	for _, want := range []string{
+		"Total requests: 5\nErrors (4xx/5xx): 1\nAverage response time: 183.20 ms\n",
+		"Apdex (T = 100 ms): 0.50 — satisfied 2, tolerating 1, frustrated 2",
+		"Worst 2 intervals by Apdex (1\u00a0min each):\n  1. 2024-01-15 10:30:00 — 0.50 (requests: 3, frustrated: 1)",
+	} {
+		if !contains(result, want) {
+			t.Errorf("В английской сводке нет %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestSummaryStatisticsNumbers(t *testing.T) {
+	stats := &model.Statistics{RequestsByIP: map[string]int{"10.0.0.1": 12345}, TotalRequests: 1234567, AverageRespTime: 1234.5}
+
+	if result := SummaryStatistics(stats, 1); !contains(result, "Всего запросов: 1\u00a0234\u00a0567") ||
+		!contains(result, "Среднее время ответа: 1\u00a0234,50 мс") || !contains(result, "1. 10.0.0.1 — 12\u00a0345 запросов") {
+		t.Errorf("Числа должны печататься с разделителями разрядов:\n%s", result)
+	}
+	if result := SummaryReport(stats, ReportOptions{TopN: 1, Printer: i18n.New(i18n.EN)}); !contains(result, "Total requests: 1,234,567") ||
+		!contains(result, "Average response time: 1,234.50 ms") || !contains(result, "1. 10.0.0.1 — 12,345 requests") {
+		t.Errorf("Числа должны печататься по правилам английского:\n%s", result)
+	}
+}
+
 // Вспомогательная функция для поиска подстроки
 func contains(s, sub string) bool {
 	return len(s) >= len(sub) && (s == sub || (len(s) > len(sub) && (strings.Contains(s, sub))))
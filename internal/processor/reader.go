@@ -0,0 +1,190 @@
+package processor
+
+import (
+	"context"      // Для остановки потокового чтения
+	"encoding/csv" // Для чтения CSV-файлов построчно
+	"io"           // Для работы с потоками ввода-вывода
+	"strconv"      // Для преобразования строк в числа
+	"strings"      // Для разбора названий колонок
+	"time"         // Для разбора времени
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"
+)
+
+// ================================================ Потоковое чтение логов ================================================
+
+// LogReader построчно разбирает CSV-лог из любого io.Reader, не загружая весь файл в память.
+// Первые шесть колонок обязательны и идут в фиксированном порядке; после них в заголовке могут быть
+// необязательные колонки x_forwarded_for, x_real_ip и user_agent, остальные колонки пропускаются
+type LogReader struct {
+	csv          *csv.Reader
+	fields       int // сколько полей должно быть в каждой строке
+	forwardedFor int // индекс колонки X-Forwarded-For (-1 — нет)
+	realIP       int // индекс колонки X-Real-IP (-1 — нет)
+	userAgent    int // индекс колонки User-Agent (-1 — нет)
+}
+
+// optionalColumns — допустимые названия необязательных колонок
+var optionalColumns = map[string]string{
+	"x_forwarded_for": "forwarded_for",
+	"forwarded_for":   "forwarded_for",
+	"xff":             "forwarded_for",
+	"x_real_ip":       "real_ip",
+	"real_ip":         "real_ip",
+	"user_agent":      "user_agent",
+	"http_user_agent": "user_agent",
+	"ua":              "user_agent",
+}
+
+// NewLogReader создаёт ридер и разбирает строку заголовка
+func NewLogReader(r io.Reader) (*LogReader, error) {
+	reader := csv.NewReader(r) // Cоздаём CSV-ридер, который будет построчно считывать данные
+	reader.Comma = ','         // Указываем символ-разделитель в файле
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true // Строки разбираются сразу, поэтому буфер записи можно переиспользовать
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, i18n.Errorf("load.header", err)
+	}
+	if len(header) < 6 {
+		return nil, i18n.Errorf("load.header_columns", header)
+	}
+
+	lr := &LogReader{csv: reader, fields: len(header), forwardedFor: -1, realIP: -1, userAgent: -1}
+	for i := 6; i < len(header); i++ { // Ищем необязательные колонки по названию
+		name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(header[i])), "-", "_")
+		switch optionalColumns[name] {
+		case "forwarded_for":
+			lr.forwardedFor = i
+		case "real_ip":
+			lr.realIP = i
+		case "user_agent":
+			lr.userAgent = i
+		}
+	}
+	return lr, nil
+}
+
+// Read возвращает следующую запись лога или io.EOF, когда данные закончились
+func (r *LogReader) Read() (model.LogEntry, error) {
+	record, err := r.csv.Read()
+	if err != nil {
+		if err == io.EOF {
+			return model.LogEntry{}, io.EOF
+		}
+		return model.LogEntry{}, i18n.Errorf("load.line", err)
+	}
+	return r.parseRecord(record)
+}
+
+// parseLine разбирает одну строку CSV без перевода строки (для слежения за файлом)
+func (r *LogReader) parseLine(line string) (model.LogEntry, error) {
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.FieldsPerRecord = -1
+	record, err := reader.Read()
+	if err != nil {
+		return model.LogEntry{}, i18n.Errorf("load.line", err)
+	}
+	return r.parseRecord(record)
+}
+
+// parseRecord превращает поля CSV-строки в LogEntry
+func (r *LogReader) parseRecord(record []string) (model.LogEntry, error) {
+	if len(record) != r.fields { // Проверяем формат данных
+		return model.LogEntry{}, i18n.Errorf("load.fields", record)
+	}
+
+	statusCode, err := strconv.Atoi(record[4]) // Преобразуем статус в число
+	if err != nil {
+		return model.LogEntry{}, i18n.Errorf("load.status", err)
+	}
+
+	t, err := time.Parse("2006-01-02 15:04:05", record[0])
+	if err != nil {
+		return model.LogEntry{}, i18n.Errorf("load.time", err)
+	}
+
+	respTime, err := strconv.Atoi(record[5]) // Преобразуем время ответа в число
+	if err != nil {
+		return model.LogEntry{}, i18n.Errorf("load.response_time", err)
+	}
+
+	addr, err := netaddr.ParseAddr(record[1]) // Проверяем и разбираем IP клиента
+	if err != nil {
+		return model.LogEntry{}, err
+	}
+
+	// Создаём экземпляр структуры LogEntry и заполняем его значениями из текущей строки.
+	entry := model.LogEntry{
+		Timestamp:    t,
+		IP:           record[1],
+		Addr:         addr,
+		Method:       record[2],
+		URL:          record[3],
+		StatusCode:   statusCode,
+		ResponseTime: respTime,
+	}
+	if r.forwardedFor >= 0 {
+		entry.ForwardedFor = record[r.forwardedFor]
+	}
+	if r.realIP >= 0 {
+		entry.RealIP = record[r.realIP]
+	}
+	if r.userAgent >= 0 {
+		entry.UserAgent = record[r.userAgent]
+	}
+	return entry, nil
+}
+
+// ReadBatches читает лог потоком и отправляет записи пачками по batchSize штук.
+// Канал ошибок получает не больше одной ошибки; после неё чтение прекращается
+func ReadBatches(ctx context.Context, r io.Reader, batchSize int) (<-chan []model.LogEntry, <-chan error) {
+	batches := make(chan []model.LogEntry, 4)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(batches)
+		defer close(errs)
+
+		reader, err := NewLogReader(r)
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		batch := make([]model.LogEntry, 0, batchSize)
+		send := func() bool { // Отправляет накопленную пачку; false — если контекст отменён
+			select {
+			case batches <- batch:
+				batch = make([]model.LogEntry, 0, batchSize) // Отправленная пачка принадлежит получателю
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			entry, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			batch = append(batch, entry)
+			if len(batch) == batchSize && !send() {
+				return
+			}
+		}
+		if len(batch) > 0 { // Последняя неполная пачка
+			send()
+		}
+	}()
+
+	return batches, errs
+}
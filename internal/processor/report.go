@@ -0,0 +1,426 @@
+package processor
+
+import (
+	"encoding/json" // Для вывода отчёта в JSON
+	"io"            // Для записи в произвольный поток
+	"math"          // Для округления значений
+	"sort"          // Для стабильного порядка строк отчёта
+	"strconv"       // Для ключей кодов ответа
+	"time"          // Для интервалов и периода
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"   // Импортируем структуры статистики из internal/model
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr" // Импортируем агрегацию по подсетям из internal/netaddr
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits" // Импортируем форматирование длительностей из internal/utilits
+)
+
+// ================================================ Отчёт в JSON ================================================
+
+// ReportSchemaVersion — версия схемы JSON-отчёта. Добавление полей не меняет версию; переименование,
+// удаление или изменение смысла поля — меняет
+const ReportSchemaVersion = 1
+
+// Report — итоговый отчёт в виде, пригодном для скриптов и дашбордов. Содержит те же данные, что SummaryReport,
+// но все таблицы — полные (кроме топов IP, прокси и подсетей), а порядок строк стабилен
+type Report struct {
+	SchemaVersion int               `json:"schema_version"`
+	Partial       bool              `json:"partial"` // обработка была прервана, данные неполные
+	Period        *ReportPeriod     `json:"period,omitempty"`
+	Totals        ReportTotals      `json:"totals"`
+	Rejects       ReportRejects     `json:"rejects"`
+	StatusCodes   map[string]int    `json:"status_codes"` // код ответа → запросов
+	Latency       ReportLatency     `json:"latency_ms"`
+	Apdex         ReportApdex       `json:"apdex"`
+	TopIPs        []ReportCount     `json:"top_ips"`
+	Endpoints     []ReportEndpoint  `json:"endpoints"`
+	TimeSeries    ReportTimeSeries  `json:"time_series"`
+	Proxies       []ReportCount     `json:"proxies,omitempty"`
+	Clients       *ReportClients    `json:"clients,omitempty"`
+	Countries     []ReportGroup     `json:"countries,omitempty"`
+	ASNs          []ReportGroup     `json:"asns,omitempty"`
+	Subnets       []ReportCount     `json:"subnets,omitempty"`
+	Scanners      []ReportScanner   `json:"scanners,omitempty"`
+	BruteForce    []ReportOffender  `json:"brute_force,omitempty"`
+	Anomalies     []ReportAnomaly   `json:"anomalies,omitempty"`
+	SLO           []ReportSLO       `json:"slo,omitempty"`
+	Alerts        []ReportAlertRule `json:"alerts,omitempty"`
+}
+
+// ReportPeriod — время первой и последней записи
+type ReportPeriod struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// ReportTotals — общие счётчики
+type ReportTotals struct {
+	Requests        int     `json:"requests"`
+	Errors          int     `json:"errors"` // статус >= 400
+	ClientErrors    int     `json:"client_errors"`
+	ServerErrors    int     `json:"server_errors"`
+	ErrorRate       float64 `json:"error_rate"` // доля от 0 до 1, до четырёх знаков
+	ProxiedRequests int     `json:"proxied_requests"`
+}
+
+// ReportRejects — записи, не попавшие в статистику
+type ReportRejects struct {
+	Filtered    int `json:"filtered"`     // отброшены фильтрами
+	ParseErrors int `json:"parse_errors"` // строки не разобраны
+	Failed      int `json:"failed"`       // обработка завершилась ошибкой
+	Retries     int `json:"retries"`      // повторных попыток обработки
+}
+
+// ReportLatency — время ответа в мс, округлённое до сотых
+type ReportLatency struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  int     `json:"max"`
+}
+
+// ReportApdex — Apdex при пороге T
+type ReportApdex struct {
+	T          int     `json:"t_ms,omitempty"`
+	Score      float64 `json:"score"`
+	Satisfied  int     `json:"satisfied"`
+	Tolerating int     `json:"tolerating"`
+	Frustrated int     `json:"frustrated"`
+}
+
+// ReportCount — ключ и число запросов (IP, прокси, подсеть)
+type ReportCount struct {
+	Key      string `json:"key"`
+	Requests int    `json:"requests"`
+}
+
+// ReportGroup — группа с запросами и ошибками (клиент, страна, ASN)
+type ReportGroup struct {
+	Name     string `json:"name"`
+	Requests int    `json:"requests"`
+	Errors   int    `json:"errors"`
+}
+
+// ReportEndpoint — строка таблицы по методу и маршруту
+type ReportEndpoint struct {
+	Method    string        `json:"method"`
+	Route     string        `json:"route"`
+	Requests  int           `json:"requests"`
+	Errors    int           `json:"errors"`
+	ErrorRate float64       `json:"error_rate"`
+	Latency   ReportLatency `json:"latency_ms"`
+	Apdex     ReportApdex   `json:"apdex"`
+}
+
+// ReportTimeSeries — метрики по интервалам времени
+type ReportTimeSeries struct {
+	Bucket string        `json:"bucket"` // длина интервала, например "1m"
+	Points []ReportPoint `json:"points"`
+}
+
+// ReportPoint — один интервал
+type ReportPoint struct {
+	Start     time.Time     `json:"start"`
+	Requests  int           `json:"requests"`
+	Errors    int           `json:"errors"`
+	ErrorRate float64       `json:"error_rate"`
+	Latency   ReportLatency `json:"latency_ms"`
+	Apdex     ReportApdex   `json:"apdex"`
+}
+
+// ReportClients — разрез по клиентам (если в логе есть User-Agent)
+type ReportClients struct {
+	Humans   ReportGroup   `json:"humans"`
+	Bots     ReportGroup   `json:"bots"`
+	Families []ReportGroup `json:"families"`
+}
+
+// ReportScanner — подозрительный клиент
+type ReportScanner struct {
+	IP            string   `json:"ip"`
+	Score         int      `json:"score"`
+	Requests      int      `json:"requests"`
+	NotFound      int      `json:"not_found"`
+	DistinctPaths int      `json:"distinct_paths"`
+	Probes        []string `json:"probes,omitempty"`
+}
+
+// ReportOffender — источник перебора паролей
+type ReportOffender struct {
+	Kind      string    `json:"kind"` // ip или subnet
+	Key       string    `json:"key"`
+	Failures  int       `json:"failures"`
+	Peak      int       `json:"peak"`
+	PeakStart time.Time `json:"peak_start"`
+	PeakEnd   time.Time `json:"peak_end"`
+	Successes int       `json:"successes"`
+	SourceIPs int       `json:"source_ips,omitempty"`
+}
+
+// ReportAnomaly — аномалия трафика
+type ReportAnomaly struct {
+	Time     time.Time `json:"time"`
+	Route    string    `json:"route"` // пусто — весь трафик
+	Kind     string    `json:"kind"`
+	Value    float64   `json:"value"`
+	Baseline float64   `json:"baseline"`
+	Score    float64   `json:"score"`
+}
+
+// ReportSLO — соответствие одной цели
+type ReportSLO struct {
+	Name            string           `json:"name"`
+	Target          float64          `json:"target"`
+	Window          string           `json:"window"`
+	Total           int              `json:"total"`
+	Good            int              `json:"good"`
+	Compliance      float64          `json:"compliance"`
+	BudgetRemaining float64          `json:"budget_remaining"`
+	Met             bool             `json:"met"`
+	Alerts          []ReportSLOAlert `json:"alerts,omitempty"`
+}
+
+// ReportSLOAlert — период тревоги по скорости сжигания бюджета
+type ReportSLOAlert struct {
+	Severity string    `json:"severity"`
+	Factor   float64   `json:"factor"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Active   bool      `json:"active"`
+	LongBurn float64   `json:"long_burn"`
+}
+
+// ReportAlertRule — состояние правила оповещения
+type ReportAlertRule struct {
+	Name       string     `json:"name"`
+	Severity   string     `json:"severity"`
+	Condition  string     `json:"condition"`
+	Window     string     `json:"window"`
+	State      string     `json:"state"`
+	Value      *float64   `json:"value,omitempty"` // нет, если правило ни разу не проверялось
+	Since      *time.Time `json:"since,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	Fired      int        `json:"fired"`
+}
+
+// BuildReport собирает отчёт из статистики и детекторов, заданных в opts
+func BuildReport(s *model.Statistics, opts ReportOptions) Report {
+	s.Mu.Lock()
+	r := buildStatistics(s, opts)
+	s.Mu.Unlock()
+
+	if opts.Scanners != nil {
+		for _, c := range opts.Scanners.Suspicious() {
+			r.Scanners = append(r.Scanners, ReportScanner{
+				IP: c.IP, Score: c.Score, Requests: c.Requests, NotFound: c.NotFound, DistinctPaths: c.DistinctPaths, Probes: c.Probes,
+			})
+		}
+	}
+	if opts.BruteForce != nil {
+		for _, o := range opts.BruteForce.Offenders() {
+			r.BruteForce = append(r.BruteForce, ReportOffender{
+				Kind: o.Kind, Key: o.Key, Failures: o.Failures, Peak: o.Peak, PeakStart: o.PeakStart, PeakEnd: o.PeakEnd,
+				Successes: o.Successes, SourceIPs: o.SourceIPs,
+			})
+		}
+	}
+	if opts.Anomalies != nil {
+		for _, a := range opts.Anomalies.Anomalies() {
+			r.Anomalies = append(r.Anomalies, ReportAnomaly{
+				Time: a.Time, Route: a.Route, Kind: a.Kind, Value: a.Value, Baseline: a.Baseline, Score: a.Score,
+			})
+		}
+	}
+	if opts.SLO != nil {
+		for _, res := range opts.SLO.Results() {
+			o := res.Objective
+			item := ReportSLO{
+				Name: o.Name, Target: o.Target, Window: utilits.FormatDuration(time.Duration(o.Window)),
+				Total: res.Total, Good: res.Good, Compliance: res.Compliance, BudgetRemaining: res.BudgetRemaining, Met: res.Met,
+			}
+			for _, a := range res.Alerts {
+				item.Alerts = append(item.Alerts, ReportSLOAlert{
+					Severity: a.Rule.Severity, Factor: a.Rule.Factor, Start: a.Start, End: a.End, Active: a.Active, LongBurn: a.LongBurn,
+				})
+			}
+			r.SLO = append(r.SLO, item)
+		}
+	}
+	if opts.Alerts != nil {
+		for _, st := range opts.Alerts.Statuses() {
+			item := ReportAlertRule{
+				Name: st.Rule.Name, Severity: st.Rule.Severity, Condition: st.Rule.Condition(),
+				Window: utilits.FormatDuration(time.Duration(st.Rule.Window)), State: st.State, Fired: st.Fired,
+			}
+			if st.Evaluated {
+				value := st.Value
+				item.Value = &value
+			}
+			if !st.Since.IsZero() {
+				since := st.Since
+				item.Since = &since
+			}
+			if !st.ResolvedAt.IsZero() {
+				resolved := st.ResolvedAt
+				item.ResolvedAt = &resolved
+			}
+			r.Alerts = append(r.Alerts, item)
+		}
+	}
+	return r
+}
+
+// buildStatistics заполняет разделы отчёта, которые строятся из статистики. Вызывается под s.Mu
+func buildStatistics(s *model.Statistics, opts ReportOptions) Report {
+	apdexT, apdexBucket := ApdexSettings(s)
+	r := Report{
+		SchemaVersion: ReportSchemaVersion,
+		Partial:       s.Partial,
+		Totals: ReportTotals{
+			Requests:        s.TotalRequests,
+			Errors:          s.ErrorCount,
+			ErrorRate:       errorRate(s.ErrorCount, s.TotalRequests),
+			ProxiedRequests: s.ProxiedRequests,
+		},
+		Rejects:     ReportRejects{Filtered: s.FilteredCount, ParseErrors: s.ParseErrors, Failed: s.FailedCount, Retries: s.RetryCount},
+		StatusCodes: make(map[string]int, len(s.ByStatus)),
+		Latency:     reportLatency(s.Latency),
+		Apdex:       reportApdex(s.Apdex, apdexT),
+		TopIPs:      topCounts(s.RequestsByIP, opts.TopN),
+		Endpoints:   []ReportEndpoint{},
+		TimeSeries:  ReportTimeSeries{Bucket: utilits.FormatDuration(apdexBucket), Points: []ReportPoint{}},
+		Proxies:     topCounts(s.RequestsByProxy, opts.TopN),
+		Countries:   reportGroups(s.ByCountry),
+		ASNs:        reportGroups(s.ByASN),
+	}
+	if !s.FirstSeen.IsZero() {
+		r.Period = &ReportPeriod{From: s.FirstSeen, To: s.LastSeen}
+	}
+	for status, n := range s.ByStatus {
+		r.StatusCodes[strconv.Itoa(status)] = n
+		switch {
+		case status >= 500:
+			r.Totals.ServerErrors += n
+		case status >= 400:
+			r.Totals.ClientErrors += n
+		}
+	}
+
+	for key, e := range s.ByEndpoint {
+		r.Endpoints = append(r.Endpoints, ReportEndpoint{
+			Method: key.Method, Route: key.Route, Requests: e.Requests, Errors: e.Errors, ErrorRate: errorRate(e.Errors, e.Requests),
+			Latency: reportLatency(e.Latency), Apdex: reportApdex(e.Apdex, 0),
+		})
+	}
+	sort.Slice(r.Endpoints, func(i, j int) bool { // Самые нагруженные — первыми
+		a, b := r.Endpoints[i], r.Endpoints[j]
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		if a.Route != b.Route {
+			return a.Route < b.Route
+		}
+		return a.Method < b.Method
+	})
+
+	for start, p := range s.Series {
+		r.TimeSeries.Points = append(r.TimeSeries.Points, ReportPoint{
+			Start: start, Requests: p.Requests, Errors: p.Errors, ErrorRate: errorRate(p.Errors, p.Requests),
+			Latency: reportLatency(p.Latency), Apdex: reportApdex(s.ApdexByBucket[start], 0),
+		})
+	}
+	sort.Slice(r.TimeSeries.Points, func(i, j int) bool { return r.TimeSeries.Points[i].Start.Before(r.TimeSeries.Points[j].Start) })
+
+	if len(s.ByClient) > 0 {
+		r.Clients = &ReportClients{
+			Humans:   ReportGroup{Name: "humans", Requests: s.HumanRequests.Requests, Errors: s.HumanRequests.Errors},
+			Bots:     ReportGroup{Name: "bots", Requests: s.BotRequests.Requests, Errors: s.BotRequests.Errors},
+			Families: reportGroups(s.ByClient),
+		}
+	}
+	if opts.SubnetV4Bits > 0 || opts.SubnetV6Bits > 0 {
+		v4, v6 := opts.SubnetV4Bits, opts.SubnetV6Bits
+		if v4 == 0 {
+			v4 = 24
+		}
+		if v6 == 0 {
+			v6 = 64
+		}
+		for _, subnet := range netaddr.TopSubnets(s.RequestsByIP, v4, v6, opts.TopN) {
+			r.Subnets = append(r.Subnets, ReportCount{Key: subnet.Subnet.String(), Requests: subnet.Count})
+		}
+	}
+	return r
+}
+
+// WriteJSONReport пишет отчёт в JSON с отступами
+func WriteJSONReport(w io.Writer, s *model.Statistics, opts ReportOptions) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false) // Маршруты и условия оповещений читаются как есть
+	return enc.Encode(BuildReport(s, opts))
+}
+
+// errorRate — доля ошибок; без запросов — 0
+func errorRate(errs, requests int) float64 {
+	if requests == 0 {
+		return 0
+	}
+	return round(float64(errs)/float64(requests), 4)
+}
+
+// round округляет до digits знаков после запятой, чтобы в отчёте не было хвостов вроде 1122.0000000000005
+func round(v float64, digits int) float64 {
+	scale := math.Pow10(digits)
+	return math.Round(v*scale) / scale
+}
+
+// reportLatency — сводка распределения времени ответа
+func reportLatency(c model.LatencyCounter) ReportLatency {
+	return ReportLatency{
+		Mean: round(c.Mean(), 2),
+		P50:  round(c.Percentile(50), 2),
+		P90:  round(c.Percentile(90), 2),
+		P95:  round(c.Percentile(95), 2),
+		P99:  round(c.Percentile(99), 2),
+		Max:  c.Max,
+	}
+}
+
+// reportApdex — Apdex; t равен 0 во вложенных разделах, где порог тот же, что и в общем
+func reportApdex(a model.ApdexCounter, t int) ReportApdex {
+	return ReportApdex{T: t, Score: round(a.Score(), 4), Satisfied: a.Satisfied, Tolerating: a.Tolerating, Frustrated: a.Frustrated}
+}
+
+// topCounts — topN ключей с наибольшим числом запросов; при равенстве — по ключу
+func topCounts(m map[string]int, topN int) []ReportCount {
+	counts := make([]ReportCount, 0, len(m))
+	for key, n := range m {
+		counts = append(counts, ReportCount{Key: key, Requests: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Requests != counts[j].Requests {
+			return counts[i].Requests > counts[j].Requests
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	if topN > 0 && len(counts) > topN {
+		counts = counts[:topN]
+	}
+	return counts
+}
+
+// reportGroups — все группы по убыванию запросов
+func reportGroups(m map[string]model.RequestCounter) []ReportGroup {
+	groups := make([]ReportGroup, 0, len(m))
+	for name, c := range m {
+		groups = append(groups, ReportGroup{Name: name, Requests: c.Requests, Errors: c.Errors})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Requests != groups[j].Requests {
+			return groups[i].Requests > groups[j].Requests
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
@@ -0,0 +1,152 @@
+package processor
+
+import (
+	"bytes"         // Для сравнения с эталоном
+	"encoding/json" // Для проверки схемы эталона
+	"flag"          // Для флага -update
+	"math"          // Для сравнения дробных значений
+	"os"            // Для чтения и записи эталонов
+	"path/filepath" // Для путей к эталонам
+	"strings"       // Для описаний SLO и правил
+	"testing"       // Cтандартная библиотека для тестов Go
+	"time"          // Для работы с датой и временем
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/alert"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/detect"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/slo"
+)
+
+// go test ./internal/processor -run Report -update перезаписывает эталоны после намеренного изменения схемы
+var update = flag.Bool("update", false, "перезаписать эталонные JSON-отчёты")
+
+// checkGolden сравнивает отчёт с эталоном из internal/testdata/golden
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("..", "testdata", "golden", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Нет эталона (запустите тест с -update): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Отчёт отличается от эталона %s. Если схема изменена намеренно, увеличьте ReportSchemaVersion "+
+			"при несовместимых изменениях и обновите эталон флагом -update.\nПолучили:\n%s", path, got)
+	}
+
+	// Эталон читается обратно в Report без неизвестных полей и кодируется так же: схема и структура совпадают
+	var report Report
+	dec := json.NewDecoder(bytes.NewReader(want))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&report); err != nil {
+		t.Fatalf("Эталон не соответствует структуре Report: %v", err)
+	}
+	var again bytes.Buffer
+	enc := json.NewEncoder(&again)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(report); err != nil || !bytes.Equal(again.Bytes(), want) {
+		t.Errorf("Эталон %s меняется при повторном кодировании", path)
+	}
+}
+
+// ================================================ Тесты JSON-отчёта ================================================
+
+func TestJSONReportEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSONReport(&buf, &model.Statistics{RequestsByIP: make(map[string]int)}, ReportOptions{TopN: 5}); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	checkGolden(t, "report_empty.json", buf.Bytes())
+}
+
+func TestJSONReportFull(t *testing.T) {
+	sloCfg, err := slo.Parse(strings.NewReader(`{"slos": [{"name": "api", "route": "^/api/", "target": 99, "window": "1d"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alertCfg, err := alert.Parse(strings.NewReader(`{"evaluation_interval": "1m", "rules": [
+		{"name": "server-errors", "expr": "errors_5xx", "threshold": 0, "window": "1m", "severity": "critical"},
+		{"name": "slow", "expr": "p95_latency", "threshold": 5000, "window": "5m"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanners := detect.NewScannerDetector(detect.ScannerConfig{})
+	bruteForce := detect.NewBruteForceDetector(detect.BruteForceConfig{})
+	evaluator := slo.NewEvaluator(sloCfg, nil)
+	alerts := alert.NewEngine(alertCfg, alert.Options{Sinks: []alert.Sink{&alert.WriterSink{W: &bytes.Buffer{}}}})
+	stage := ObserveStage(scanners, bruteForce, evaluator, alerts)
+
+	stats := &model.Statistics{RequestsByIP: make(map[string]int), ApdexT: 100}
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	entries := []model.LogEntry{
+		{Timestamp: start, IP: "192.168.1.100", Method: "GET", URL: "/api/users/1", StatusCode: 200, ResponseTime: 50},
+		{Timestamp: start.Add(5 * time.Second), IP: "192.168.1.101", Method: "GET", URL: "/api/users/2", StatusCode: 200, ResponseTime: 150},
+		{Timestamp: start.Add(10 * time.Second), IP: "192.168.1.100", Method: "GET", URL: "/api/users/3", StatusCode: 200, ResponseTime: 1234},
+		{Timestamp: start.Add(20 * time.Second), IP: "192.168.1.102", Method: "POST", URL: "/api/orders", StatusCode: 500, ResponseTime: 80},
+		{Timestamp: start.Add(30 * time.Second), IP: "203.0.113.9", Method: "GET", URL: "/.env", StatusCode: 404, ResponseTime: 3},
+	}
+	for i := 0; i < 5; i++ { // Перебор паролей с одного адреса
+		entries = append(entries, model.LogEntry{
+			Timestamp: start.Add(time.Minute + time.Duration(i)*time.Second), IP: "198.51.100.7", Method: "POST", URL: "/login", StatusCode: 401, ResponseTime: 20,
+		})
+	}
+	entries = append(entries, model.LogEntry{Timestamp: start.Add(3 * time.Minute), IP: "192.168.1.101", Method: "GET", URL: "/api/users/4", StatusCode: 200, ResponseTime: 90})
+	for _, entry := range entries {
+		if err := stage(t.Context(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		UpdateStatistics(stats, entry)
+	}
+	alerts.Close()
+	RecordFiltered(stats)
+	RecordFiltered(stats)
+	RecordParseError(stats)
+	RecordFailure(stats, 2)
+
+	var buf bytes.Buffer
+	err = WriteJSONReport(&buf, stats, ReportOptions{
+		TopN: 3, SubnetV4Bits: 24, Scanners: scanners, BruteForce: bruteForce, SLO: evaluator, Alerts: alerts,
+	})
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	checkGolden(t, "report_full.json", buf.Bytes())
+
+	report := BuildReport(stats, ReportOptions{TopN: 3})
+	if report.Totals.Requests != 11 || report.Totals.ClientErrors != 6 || report.Totals.ServerErrors != 1 || report.Latency.Max != 1234 {
+		t.Errorf("Неверные итоги: %+v %+v", report.Totals, report.Latency)
+	}
+	if e := report.Endpoints[0]; e.Method != "POST" || e.Route != "/login" || e.Requests != 5 {
+		t.Errorf("Первым должен идти самый нагруженный маршрут: %+v", e)
+	}
+}
+
+func TestLatencyCounterPercentile(t *testing.T) {
+	var c model.LatencyCounter
+	values := []int{10, 20, 20, 30, 40, 50, 60, 70, 80, 1000}
+	for _, v := range values {
+		c.Add(v)
+	}
+	for p, want := range map[float64]float64{0: 10, 50: 45, 90: 172, 100: 1000} {
+		if got := c.Percentile(p); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Percentile(%v) = %v, ожидалось %v", p, got, want)
+		}
+	}
+	if c.Mean() != 138 || c.Max != 1000 {
+		t.Errorf("Неверные среднее и максимум: %v, %d", c.Mean(), c.Max)
+	}
+
+	c.Add(123456) // Большие значения округляются до трёх значащих цифр, но максимум и сумма точные
+	if c.Values[123000] != 1 || c.Max != 123456 || c.Sum != 1380+123456 {
+		t.Errorf("Неверное округление: %+v", c)
+	}
+}
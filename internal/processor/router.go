@@ -0,0 +1,256 @@
+package processor
+
+import (
+	"context" // Для остановки потоковой маршрутизации
+	"fmt"     // Для имён маршрутов по классам ответа
+	"regexp"  // Для шаблонов URL
+	"strings" // Для сравнения HTTP-методов
+	"time"    // Для фильтрации по времени
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"
+)
+
+// ================================================ Предикаты ================================================
+
+// Predicate — условие, которому должна удовлетворять запись лога
+type Predicate func(model.LogEntry) bool
+
+// StatusRange — статус в диапазоне [min, max] включительно
+func StatusRange(min, max int) Predicate {
+	return func(l model.LogEntry) bool {
+		return l.StatusCode >= min && l.StatusCode <= max
+	}
+}
+
+// MethodIs — HTTP-метод совпадает с одним из перечисленных (без учёта регистра)
+func MethodIs(methods ...string) Predicate {
+	return func(l model.LogEntry) bool {
+		for _, m := range methods {
+			if strings.EqualFold(l.Method, m) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// URLMatches — путь запроса подходит под регулярное выражение
+func URLMatches(pattern string) (Predicate, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, i18n.Errorf("router.bad_url", pattern, err)
+	}
+	return func(l model.LogEntry) bool {
+		return re.MatchString(l.URL)
+	}, nil
+}
+
+// IPInCIDR — IP соединения (за балансировщиком — адрес прокси) входит в одну из подсетей (например, "10.0.0.0/8" или "2001:db8::/32")
+func IPInCIDR(cidrs ...string) (Predicate, error) {
+	list, err := netaddr.ParseCIDRList(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	return func(l model.LogEntry) bool {
+		return list.Contains(netaddr.EntryAddr(l))
+	}, nil
+}
+
+// ClientIPInCIDR — реальный IP клиента (с учётом доверенных прокси) входит в одну из подсетей
+func ClientIPInCIDR(cidrs ...string) (Predicate, error) {
+	list, err := netaddr.ParseCIDRList(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	return func(l model.LogEntry) bool {
+		return list.Contains(netaddr.ClientAddr(l))
+	}, nil
+}
+
+// Access — реальный IP клиента проходит правила доступа (allow/deny списки подсетей)
+func Access(acl netaddr.AccessList) Predicate {
+	return func(l model.LogEntry) bool {
+		addr := netaddr.ClientAddr(l)
+		return addr.IsValid() && acl.Permits(addr)
+	}
+}
+
+// TimeBetween — время запроса в полуинтервале [from, to). Нулевая граница означает «без ограничения»
+func TimeBetween(from, to time.Time) Predicate {
+	return func(l model.LogEntry) bool {
+		if !from.IsZero() && l.Timestamp.Before(from) {
+			return false
+		}
+		if !to.IsZero() && !l.Timestamp.Before(to) {
+			return false
+		}
+		return true
+	}
+}
+
+// SlowerThan — время ответа больше ms миллисекунд
+func SlowerThan(ms int) Predicate {
+	return func(l model.LogEntry) bool {
+		return l.ResponseTime > ms
+	}
+}
+
+// And — выполняются все условия
+func And(preds ...Predicate) Predicate {
+	return func(l model.LogEntry) bool {
+		for _, p := range preds {
+			if !p(l) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or — выполняется хотя бы одно условие
+func Or(preds ...Predicate) Predicate {
+	return func(l model.LogEntry) bool {
+		for _, p := range preds {
+			if p(l) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not — условие не выполняется
+func Not(p Predicate) Predicate {
+	return func(l model.LogEntry) bool {
+		return !p(l)
+	}
+}
+
+// ================================================ Маршрутизация ================================================
+
+// Unmatched — имя выхода для записей, которые не подошли ни под один маршрут
+const Unmatched = "unmatched"
+
+// Router раскладывает записи по именованным маршрутам. Запись попадает в первый маршрут,
+// условие которого выполнилось, а если таких нет — в выход Unmatched
+type Router struct {
+	names []string
+	preds []Predicate
+}
+
+// NewRouter создаёт пустой маршрутизатор
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Add регистрирует маршрут. Маршруты проверяются в порядке добавления
+func (r *Router) Add(name string, match Predicate) error {
+	if name == Unmatched {
+		return i18n.Errorf("router.reserved", name)
+	}
+	for _, n := range r.names {
+		if n == name {
+			return i18n.Errorf("router.duplicate", name)
+		}
+	}
+	r.names = append(r.names, name)
+	r.preds = append(r.preds, match)
+	return nil
+}
+
+// Names возвращает имена выходов в порядке проверки, последним идёт Unmatched
+func (r *Router) Names() []string {
+	return append(append([]string(nil), r.names...), Unmatched)
+}
+
+// Match возвращает имя маршрута для записи
+func (r *Router) Match(l model.LogEntry) string {
+	for i, p := range r.preds {
+		if p(l) {
+			return r.names[i]
+		}
+	}
+	return Unmatched
+}
+
+// Route раскладывает срез записей по каналам маршрутов. Каналы буферизованы под все записи,
+// поэтому их можно читать по очереди
+func (r *Router) Route(logs []model.LogEntry) map[string]chan model.LogEntry {
+	outputs := make(map[string]chan model.LogEntry, len(r.names)+1)
+	for _, name := range r.Names() {
+		outputs[name] = make(chan model.LogEntry, len(logs))
+	}
+
+	go func() {
+		defer func() {
+			for _, ch := range outputs {
+				close(ch)
+			}
+		}()
+
+		for _, logEntry := range logs {
+			outputs[r.Match(logEntry)] <- logEntry
+		}
+	}()
+
+	return outputs
+}
+
+// RouteStream раскладывает поток записей по каналам маршрутов по мере поступления.
+// Каналы нужно читать параллельно: заполненный канал одного маршрута задерживает остальные
+func (r *Router) RouteStream(ctx context.Context, input <-chan model.LogEntry, buffer int) map[string]<-chan model.LogEntry {
+	channels := make(map[string]chan model.LogEntry, len(r.names)+1)
+	outputs := make(map[string]<-chan model.LogEntry, len(r.names)+1)
+	for _, name := range r.Names() {
+		channels[name] = make(chan model.LogEntry, buffer)
+		outputs[name] = channels[name]
+	}
+
+	go func() {
+		defer func() {
+			for _, ch := range channels {
+				close(ch)
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case logEntry, ok := <-input:
+				if !ok {
+					return
+				}
+				select {
+				case channels[r.Match(logEntry)] <- logEntry:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return outputs
+}
+
+// ================================================ Готовые наборы маршрутов ================================================
+
+// StatusClassRouter — маршруты по всем классам HTTP-статусов: "1xx", "2xx", "3xx", "4xx", "5xx"
+func StatusClassRouter() *Router {
+	r := NewRouter()
+	for class := 1; class <= 5; class++ {
+		r.Add(fmt.Sprintf("%dxx", class), StatusRange(class*100, class*100+99))
+	}
+	return r
+}
+
+// StatusSplitRouter — исторический набор FilterLogs: только "2xx", "4xx" и "5xx", остальное в Unmatched
+func StatusSplitRouter() *Router {
+	r := NewRouter()
+	r.Add("2xx", StatusRange(200, 299))
+	r.Add("4xx", StatusRange(400, 499))
+	r.Add("5xx", StatusRange(500, 599))
+	return r
+}
@@ -0,0 +1,137 @@
+package processor
+
+import (
+	"context" // Для потоковой маршрутизации
+	"sync"    // Для параллельного чтения выходов
+	"testing" // Cтандартная библиотека для тестов Go
+	"time"    // Для работы с датой и временем
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"
+)
+
+// ================================================ Тесты предикатов ================================================
+
+func TestPredicates(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	entry := model.LogEntry{
+		Timestamp: base, IP: "10.1.2.3", Method: "post", URL: "/api/orders/42", StatusCode: 503, ResponseTime: 1200,
+	}
+
+	api, err := URLMatches(`^/api/orders`)
+	if err != nil {
+		t.Fatalf("URLMatches вернул ошибку: %v", err)
+	}
+	private, err := IPInCIDR("192.168.0.0/16", "10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("IPInCIDR вернул ошибку: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pred     Predicate
+		expected bool
+	}{
+		{"статус в диапазоне", StatusRange(500, 599), true},
+		{"статус вне диапазона", StatusRange(200, 299), false},
+		{"метод без учёта регистра", MethodIs("GET", "POST"), true},
+		{"шаблон URL", api, true},
+		{"IP в подсети", private, true},
+		{"время в интервале", TimeBetween(base, base.Add(time.Minute)), true},
+		{"правая граница не включается", TimeBetween(time.Time{}, base), false},
+		{"медленный запрос", SlowerThan(1000), true},
+		{"комбинация", And(StatusRange(500, 599), Not(MethodIs("GET")), Or(SlowerThan(5000), api)), true},
+	}
+	for _, tt := range tests {
+		if got := tt.pred(entry); got != tt.expected {
+			t.Errorf("%s: ожидалось %v, получили %v", tt.name, tt.expected, got)
+		}
+	}
+
+	deny, _ := netaddr.ParseCIDRList([]string{"10.1.0.0/16"})
+	if Access(netaddr.AccessList{Deny: deny})(entry) {
+		t.Errorf("Access: адрес из запрещённой подсети не должен проходить")
+	}
+	if Access(netaddr.AccessList{})(model.LogEntry{IP: "не адрес"}) {
+		t.Errorf("Access: запись с неверным IP не должна проходить")
+	}
+
+	if _, err := IPInCIDR("10.0.0.0/33"); err == nil {
+		t.Errorf("Ожидалась ошибка для неверной подсети")
+	}
+	if _, err := URLMatches("("); err == nil {
+		t.Errorf("Ожидалась ошибка для неверного шаблона")
+	}
+}
+
+// ================================================ Тесты маршрутизатора ================================================
+
+func TestStatusClassRouter(t *testing.T) {
+	logs := []model.LogEntry{
+		{StatusCode: 101}, {StatusCode: 200}, {StatusCode: 302},
+		{StatusCode: 404}, {StatusCode: 500}, {StatusCode: 0},
+	}
+
+	outputs := StatusClassRouter().Route(logs)
+
+	expected := map[string]int{"1xx": 1, "2xx": 1, "3xx": 1, "4xx": 1, "5xx": 1, Unmatched: 1}
+	for name, want := range expected {
+		count := 0
+		for range outputs[name] {
+			count++
+		}
+		if count != want {
+			t.Errorf("Ожидалось %d записей в %s, получили %d", want, name, count)
+		}
+	}
+}
+
+func TestRouterFirstMatchAndDuplicates(t *testing.T) {
+	r := NewRouter()
+	if err := r.Add("slow", SlowerThan(1000)); err != nil {
+		t.Fatalf("Add вернул ошибку: %v", err)
+	}
+	r.Add("errors", StatusRange(500, 599))
+
+	if got := r.Match(model.LogEntry{StatusCode: 500, ResponseTime: 2000}); got != "slow" { // Побеждает первый маршрут
+		t.Errorf("Ожидался маршрут slow, получили %s", got)
+	}
+	if err := r.Add("slow", SlowerThan(1)); err == nil {
+		t.Errorf("Ожидалась ошибка при повторной регистрации маршрута")
+	}
+	if err := r.Add(Unmatched, SlowerThan(1)); err == nil {
+		t.Errorf("Ожидалась ошибка для зарезервированного имени")
+	}
+}
+
+func TestRouteStream(t *testing.T) {
+	input := make(chan model.LogEntry)
+	go func() {
+		defer close(input)
+		for _, status := range []int{200, 404, 500, 302, 201} {
+			input <- model.LogEntry{StatusCode: status}
+		}
+	}()
+
+	outputs := StatusSplitRouter().RouteStream(context.Background(), input, 0)
+
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	var wg sync.WaitGroup
+	for name, ch := range outputs { // Небуферизованные выходы читаем параллельно
+		wg.Add(1)
+		go func(name string, ch <-chan model.LogEntry) {
+			defer wg.Done()
+			for range ch {
+				mu.Lock()
+				counts[name]++
+				mu.Unlock()
+			}
+		}(name, ch)
+	}
+	wg.Wait()
+
+	if counts["2xx"] != 2 || counts["4xx"] != 1 || counts["5xx"] != 1 || counts[Unmatched] != 1 {
+		t.Errorf("Неверное распределение по маршрутам: %v", counts)
+	}
+}
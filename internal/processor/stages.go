@@ -0,0 +1,176 @@
+package processor
+
+import (
+	"context"  // Для управления таймаутами и отменой задач
+	"errors"   // Для сравнения ошибок
+	"log/slog" // Для журнала воркеров
+	"time"     // Для задержек между повторными попытками
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/geoip"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/useragent"
+)
+
+// ================================================ Этапы обработки ================================================
+
+// Stage — один этап обработки записи. Этап может изменять запись и вернуть ошибку,
+// тогда запись попадёт в канал ошибок (если ошибка не временная или попытки закончились)
+type Stage func(ctx context.Context, entry *model.LogEntry) error
+
+// RetryPolicy — настройки повторных попыток для временных ошибок
+type RetryPolicy struct {
+	MaxAttempts int           // максимальное количество попыток (0 или 1 — без повторов)
+	Backoff     time.Duration // задержка перед первой повторной попыткой, дальше удваивается
+	MaxBackoff  time.Duration // верхняя граница задержки (0 — без ограничения)
+}
+
+// Options — настройки пула воркеров
+type Options struct {
+	NumWorkers int          // количество воркеров
+	Stages     []Stage      // этапы, которые выполняются над каждой записью по порядку
+	Retry      RetryPolicy  // политика повторов для временных ошибок
+	Logger     *slog.Logger // журнал воркеров: запись взята и обработана (debug), ошибка (warn), размер пула (info); nil — не пишется
+
+	Scaling *ScalingPolicy // если задано, размер пула меняется по нагрузке, а NumWorkers игнорируется
+}
+
+// ErrTransient — признак временной ошибки, после которой имеет смысл повторить обработку
+var ErrTransient error = i18n.Error("stage.transient")
+
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string        { return e.err.Error() }
+func (e *transientError) Unwrap() error        { return e.err }
+func (e *transientError) Is(target error) bool { return target == ErrTransient }
+
+// Transient помечает ошибку как временную: такие ошибки повторяются согласно RetryPolicy
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err}
+}
+
+// IsTransient сообщает, помечена ли ошибка как временная
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrTransient)
+}
+
+// ErrSkip — этап возвращает её, чтобы отбросить запись: она не попадёт ни в результат, ни в канал ошибок
+var ErrSkip error = i18n.Error("stage.skipped")
+
+// FilterStage — этап-фильтр: записи, не подходящие под условие, отбрасываются
+func FilterStage(match Predicate) Stage {
+	return func(ctx context.Context, entry *model.LogEntry) error {
+		if !match(*entry) {
+			return ErrSkip
+		}
+		return nil
+	}
+}
+
+// ResolveClientIP — этап, определяющий реальный IP клиента по X-Forwarded-For/X-Real-IP.
+// Должен идти раньше фильтров, чтобы они видели адрес клиента, а не прокси
+func ResolveClientIP(resolver netaddr.ProxyResolver) Stage {
+	return func(ctx context.Context, entry *model.LogEntry) error {
+		entry.ClientAddr = resolver.Resolve(netaddr.EntryAddr(*entry), entry.ForwardedFor, entry.RealIP)
+		return nil
+	}
+}
+
+// GeoIPStage — этап, дополняющий запись страной, городом и автономной системой клиента из локальной базы.
+// Должен идти после ResolveClientIP, чтобы искать адрес клиента, а не прокси
+func GeoIPStage(db *geoip.DB) Stage {
+	return func(ctx context.Context, entry *model.LogEntry) error {
+		loc, err := db.Lookup(netaddr.ClientAddr(*entry))
+		if err != nil {
+			return err
+		}
+		entry.Country, entry.City, entry.ASN, entry.ASOrg = loc.Country, loc.City, loc.ASN, loc.ASOrg
+		return nil
+	}
+}
+
+// UserAgentStage — этап, разбирающий User-Agent записи: браузер, ОС, тип устройства и признак бота
+func UserAgentStage(parser *useragent.Parser) Stage {
+	return func(ctx context.Context, entry *model.LogEntry) error {
+		entry.Client = parser.Parse(entry.UserAgent)
+		return nil
+	}
+}
+
+// Observer — получатель обработанных записей: детекторы, агрегаторы
+type Observer interface {
+	Observe(l model.LogEntry)
+}
+
+// ObserveStage — этап, передающий запись наблюдателям. Обычно идёт последним, когда запись уже дополнена
+func ObserveStage(observers ...Observer) Stage {
+	return func(ctx context.Context, entry *model.LogEntry) error {
+		for _, o := range observers {
+			o.Observe(*entry)
+		}
+		return nil
+	}
+}
+
+// SimulateWork — этап, имитирующий обработку записи задержкой
+func SimulateWork(d time.Duration) Stage {
+	return func(ctx context.Context, entry *model.LogEntry) error {
+		select {
+		case <-time.After(d):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// runStages выполняет все этапы над записью с учётом политики повторов.
+// Возвращает количество попыток последнего выполненного этапа, число повторов и ошибку
+func runStages(ctx context.Context, stages []Stage, policy RetryPolicy, entry *model.LogEntry) (int, int, error) {
+	attempts, retries := 0, 0
+	for _, stage := range stages {
+		var err error
+		attempts, err = runWithRetry(ctx, stage, policy, entry)
+		retries += attempts - 1
+		if err != nil {
+			return attempts, retries, err
+		}
+	}
+	return attempts, retries, nil
+}
+
+// runWithRetry выполняет один этап, повторяя его при временных ошибках
+func runWithRetry(ctx context.Context, stage Stage, policy RetryPolicy, entry *model.LogEntry) (int, error) {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	backoff := policy.Backoff
+
+	for attempt := 1; ; attempt++ {
+		err := stage(ctx, entry)
+		if err == nil {
+			return attempt, nil
+		}
+		if !IsTransient(err) || attempt >= maxAttempts { // Постоянная ошибка или попытки закончились
+			return attempt, err
+		}
+
+		select { // Ждём перед следующей попыткой, но не дольше, чем живёт контекст
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		}
+
+		backoff *= 2 // Экспоненциальная задержка
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
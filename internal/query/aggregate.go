@@ -0,0 +1,314 @@
+package query
+
+import (
+	"context"        // Для остановки чтения потока
+	"fmt"            // Для форматирования значений
+	"math"           // Для min/max
+	"sort"           // Для сортировки результата
+	"strings"        // Для сборки ключей групп и таблицы
+	"sync"           // Для защиты агрегатора при параллельной обработке
+	"text/tabwriter" // Для выравнивания колонок таблицы
+	"time"           // Для форматирования времени
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"
+)
+
+// ================================================ Потоковая агрегация ================================================
+
+// Aggregator выполняет SELECT по мере поступления записей: хранит только состояние групп,
+// а не сами записи (кроме значений для перцентилей и простой выборки без агрегатов).
+// Безопасен для вызова Add из нескольких воркеров
+type Aggregator struct {
+	sel *Select
+
+	mu     sync.Mutex
+	groups map[string]*group
+	order  []string // ключи групп в порядке появления
+	rows   [][]Cell // строки простой выборки (без агрегатов и GROUP BY)
+	plain  bool     // запрос без агрегатов и группировки
+}
+
+// group — накопленное состояние одной группы
+type group struct {
+	keys   []Cell    // значения полей группировки для колонок-полей
+	count  []int     // количество значений по каждой колонке
+	sum    []float64 // сумма
+	min    []float64
+	max    []float64
+	values [][]int // значения для перцентилей
+}
+
+// Cell — ячейка результата: число или строка
+type Cell struct {
+	Num   float64
+	Str   string
+	IsNum bool
+}
+
+func (c Cell) String() string {
+	if !c.IsNum {
+		return c.Str
+	}
+	if c.Num == math.Trunc(c.Num) && math.Abs(c.Num) < 1e15 {
+		return fmt.Sprintf("%d", int64(c.Num))
+	}
+	return fmt.Sprintf("%.2f", c.Num)
+}
+
+// Result — таблица результата запроса
+type Result struct {
+	Columns []string
+	Rows    [][]Cell
+}
+
+// NewAggregator создаёт агрегатор для разобранного запроса
+func (s *Select) NewAggregator() *Aggregator {
+	plain := len(s.groupBy) == 0
+	for _, col := range s.columns {
+		plain = plain && col.agg == ""
+	}
+	return &Aggregator{sel: s, groups: make(map[string]*group), plain: plain}
+}
+
+// Add учитывает запись, если она проходит условие WHERE
+func (a *Aggregator) Add(l model.LogEntry) {
+	if a.sel.where != nil && !a.sel.where.match(l) {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.plain { // Простая выборка: сохраняем строку целиком
+		row := make([]Cell, len(a.sel.columns))
+		for i, col := range a.sel.columns {
+			row[i] = cellOf(col.kind, col.get(l))
+		}
+		a.rows = append(a.rows, row)
+		return
+	}
+
+	key := a.groupKey(l)
+	g, ok := a.groups[key]
+	if !ok {
+		g = a.newGroup(l)
+		a.groups[key] = g
+		a.order = append(a.order, key)
+	}
+
+	for i, col := range a.sel.columns {
+		if col.agg == "" {
+			continue
+		}
+		if col.agg == "apdex" { // Сумма оценок запросов: 1 — удовлетворён, 0.5 — терпимо, 0 — разочарован
+			var c model.ApdexCounter
+			c.Add(l, col.threshold)
+			g.count[i]++
+			g.sum[i] += c.Score()
+			continue
+		}
+		if col.get == nil { // count(*)
+			g.count[i]++
+			continue
+		}
+		v := float64(col.get(l).i)
+		g.count[i]++
+		g.sum[i] += v
+		g.min[i] = math.Min(g.min[i], v)
+		g.max[i] = math.Max(g.max[i], v)
+		if col.agg == "pct" {
+			g.values[i] = append(g.values[i], int(v))
+		}
+	}
+}
+
+// groupKey строит ключ группы из значений полей GROUP BY
+func (a *Aggregator) groupKey(l model.LogEntry) string {
+	parts := make([]string, len(a.sel.groupBy))
+	for i, g := range a.sel.groupBy {
+		parts[i] = formatValue(g.kind, g.get(l))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func (a *Aggregator) newGroup(l model.LogEntry) *group {
+	g := emptyGroup(len(a.sel.columns))
+	for i, col := range a.sel.columns {
+		if col.agg == "" {
+			g.keys[i] = cellOf(col.kind, col.get(l))
+		}
+	}
+	return g
+}
+
+// emptyGroup создаёт группу без значений для n колонок
+func emptyGroup(n int) *group {
+	g := &group{
+		keys:   make([]Cell, n),
+		count:  make([]int, n),
+		sum:    make([]float64, n),
+		min:    make([]float64, n),
+		max:    make([]float64, n),
+		values: make([][]int, n),
+	}
+	for i := range g.min {
+		g.min[i], g.max[i] = math.Inf(1), math.Inf(-1)
+	}
+	return g
+}
+
+// Result собирает итоговую таблицу: вычисляет агрегаты, сортирует и применяет LIMIT
+func (a *Aggregator) Result() *Result {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	res := &Result{}
+	for _, col := range a.sel.columns {
+		res.Columns = append(res.Columns, col.label)
+	}
+
+	if a.plain {
+		res.Rows = append(res.Rows, a.rows...)
+	} else {
+		for _, key := range a.order {
+			res.Rows = append(res.Rows, a.finish(a.groups[key]))
+		}
+		if len(res.Rows) == 0 && len(a.sel.groupBy) == 0 { // Агрегаты без GROUP BY дают одну строку даже без данных
+			res.Rows = append(res.Rows, a.finish(emptyGroup(len(a.sel.columns))))
+		}
+	}
+
+	if len(a.sel.orderBy) > 0 {
+		sort.SliceStable(res.Rows, func(i, j int) bool {
+			for _, k := range a.sel.orderBy {
+				cmp := compareCells(res.Rows[i][k.column], res.Rows[j][k.column])
+				if cmp == 0 {
+					continue
+				}
+				if k.desc {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+			return false
+		})
+	}
+	if a.sel.limit > 0 && len(res.Rows) > a.sel.limit {
+		res.Rows = res.Rows[:a.sel.limit]
+	}
+	return res
+}
+
+// finish вычисляет значения агрегатных функций группы
+func (a *Aggregator) finish(g *group) []Cell {
+	row := make([]Cell, len(a.sel.columns))
+	for i, col := range a.sel.columns {
+		var v float64
+		switch col.agg {
+		case "":
+			row[i] = g.keys[i]
+			continue
+		case "count":
+			v = float64(g.count[i])
+		case "sum":
+			v = g.sum[i]
+		case "avg":
+			if g.count[i] > 0 {
+				v = g.sum[i] / float64(g.count[i])
+			}
+		case "min":
+			if g.count[i] > 0 {
+				v = g.min[i]
+			}
+		case "max":
+			if g.count[i] > 0 {
+				v = g.max[i]
+			}
+		case "apdex":
+			v = 1
+			if g.count[i] > 0 {
+				v = g.sum[i] / float64(g.count[i])
+			}
+		case "pct":
+			sorted := append([]int(nil), g.values[i]...) // Result можно вызывать повторно, пока данные ещё поступают
+			sort.Ints(sorted)
+			v = utilits.Percentile(sorted, col.percentile)
+		}
+		row[i] = Cell{Num: v, IsNum: true}
+	}
+	return row
+}
+
+// Run выполняет запрос над потоком записей, пока канал не закроется или не отменён контекст
+func (s *Select) Run(ctx context.Context, input <-chan model.LogEntry) (*Result, error) {
+	agg := s.NewAggregator()
+	for {
+		select {
+		case <-ctx.Done():
+			return agg.Result(), ctx.Err()
+		case l, ok := <-input:
+			if !ok {
+				return agg.Result(), nil
+			}
+			agg.Add(l)
+		}
+	}
+}
+
+// String форматирует результат в виде выровненной текстовой таблицы
+func (r *Result) String() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(r.Columns, "\t"))
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			cells[i] = c.String()
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// ================================================ Вспомогательные функции ================================================
+
+// cellOf превращает значение поля в ячейку результата
+func cellOf(k kind, v value) Cell {
+	if k == kindInt {
+		return Cell{Num: float64(v.i), IsNum: true}
+	}
+	return Cell{Str: formatValue(k, v)}
+}
+
+// formatValue форматирует значение поля как строку
+func formatValue(k kind, v value) string {
+	switch k {
+	case kindInt:
+		return fmt.Sprintf("%d", v.i)
+	case kindTime:
+		return v.t.Format(time.DateTime)
+	case kindAddr:
+		if !v.a.IsValid() {
+			return ""
+		}
+		return v.a.String()
+	case kindBool:
+		return fmt.Sprintf("%t", v.b)
+	}
+	return v.s
+}
+
+func compareCells(a, b Cell) int {
+	if a.IsNum && b.IsNum {
+		switch {
+		case a.Num < b.Num:
+			return -1
+		case a.Num > b.Num:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a.String(), b.String())
+}
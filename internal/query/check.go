@@ -0,0 +1,232 @@
+package query
+
+import (
+	"net/netip" // Для разбора адресов и подсетей
+	"regexp"    // Для операторов ~ и !~
+	"strconv"   // Для разбора чисел
+	"strings"   // Для подсказок в ошибках
+	"time"      // Для разбора времени
+)
+
+// ================================================ Проверка типов ================================================
+
+// Форматы времени, которые понимают строковые литералы при сравнении с timestamp
+var timeLayouts = []string{"2006-01-02 15:04:05", time.RFC3339, "2006-01-02"}
+
+// checker проверяет типы в дереве разбора и строит из него вычисляемое условие
+type checker struct {
+	src string
+}
+
+// checkCond проверяет узел, который должен давать логическое значение
+func (c *checker) checkCond(n node) (cond, error) {
+	switch n := n.(type) {
+	case *binaryNode:
+		switch n.op.kind {
+		case tokAnd, tokOr:
+			left, err := c.checkCond(n.left)
+			if err != nil {
+				return nil, err
+			}
+			right, err := c.checkCond(n.right)
+			if err != nil {
+				return nil, err
+			}
+			if n.op.kind == tokAnd {
+				return &andCond{left, right}, nil
+			}
+			return &orCond{left, right}, nil
+		}
+		return c.checkComparison(n)
+
+	case *notNode:
+		operand, err := c.checkCond(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		return &notCond{operand}, nil
+
+	case *fieldNode:
+		f, err := c.lookup(n)
+		if err != nil {
+			return nil, err
+		}
+		if f.kind != kindBool {
+			return nil, errorf(c.src, n.tok.pos, "query.not_bool", n.tok.text, f.kind)
+		}
+		return &boolFieldCond{f.get}, nil
+	}
+	return nil, errorf(c.src, n.position(), "query.want_condition")
+}
+
+// lookup находит описание поля
+func (c *checker) lookup(n *fieldNode) (field, error) {
+	f, ok := fields[strings.ToLower(n.tok.text)]
+	if !ok {
+		return field{}, errorf(c.src, n.tok.pos, "query.unknown_field",
+			n.tok.text, strings.Join(Fields(), ", "))
+	}
+	return f, nil
+}
+
+// checkComparison проверяет сравнение «поле оператор значение»
+func (c *checker) checkComparison(n *binaryNode) (cond, error) {
+	fn, ok := n.left.(*fieldNode)
+	if !ok {
+		return nil, errorf(c.src, n.left.position(), "query.left_field", n.op.text)
+	}
+	f, err := c.lookup(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op.kind {
+	case tokMatch, tokNotMatch:
+		if f.kind != kindString {
+			return nil, errorf(c.src, n.op.pos, "query.regex_kind", n.op.text, fn.tok.text, f.kind)
+		}
+		lit, ok := n.right.(*literalNode)
+		if !ok || lit.tok.kind != tokString {
+			return nil, errorf(c.src, n.right.position(), "query.want_regex", n.op.text)
+		}
+		re, err := regexp.Compile(lit.tok.text)
+		if err != nil {
+			return nil, errorf(c.src, lit.tok.pos, "query.bad_regex", err)
+		}
+		return &matchCond{get: f.get, re: re, negate: n.op.kind == tokNotMatch}, nil
+
+	case tokIn:
+		return c.checkIn(n, fn, f)
+
+	case tokLt, tokLe, tokGt, tokGe:
+		if f.kind == kindAddr || f.kind == kindBool {
+			return nil, errorf(c.src, n.op.pos, "query.op_kind", n.op.text, fn.tok.text, f.kind)
+		}
+	}
+
+	right, err := c.operand(n.right, f.kind)
+	if err != nil {
+		return nil, err
+	}
+	return &compareCond{op: n.op.kind, kind: f.kind, left: fieldOperand{f.get}, right: right}, nil
+}
+
+// checkIn проверяет оператор in: список значений или подсеть для IP-адреса
+func (c *checker) checkIn(n *binaryNode, fn *fieldNode, f field) (cond, error) {
+	var items []*literalNode
+	switch r := n.right.(type) {
+	case *listNode:
+		items = r.items
+	case *literalNode:
+		if f.kind != kindAddr {
+			return nil, errorf(c.src, r.tok.pos, "query.in_brackets")
+		}
+		items = []*literalNode{r}
+	default:
+		return nil, errorf(c.src, n.right.position(), "query.in_list")
+	}
+
+	if f.kind == kindAddr { // Для адресов список состоит из подсетей (одиночный адрес — подсеть /32 или /128)
+		prefixes := make([]netip.Prefix, 0, len(items))
+		for _, item := range items {
+			prefix, err := c.prefix(item)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix)
+		}
+		return &prefixCond{get: f.get, prefixes: prefixes}, nil
+	}
+
+	if f.kind == kindTime || f.kind == kindBool {
+		return nil, errorf(c.src, n.op.pos, "query.in_kind", fn.tok.text, f.kind)
+	}
+	set := make([]value, 0, len(items))
+	for _, item := range items {
+		v, err := c.literal(item, f.kind)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, v)
+	}
+	return &inCond{get: f.get, kind: f.kind, set: set}, nil
+}
+
+// operand проверяет правую часть сравнения: литерал нужного типа или поле того же типа
+func (c *checker) operand(n node, want kind) (operand, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		v, err := c.literal(n, want)
+		if err != nil {
+			return nil, err
+		}
+		return constOperand{v}, nil
+	case *fieldNode:
+		f, err := c.lookup(n)
+		if err != nil {
+			return nil, err
+		}
+		if f.kind != want {
+			return nil, errorf(c.src, n.tok.pos, "query.field_kind", n.tok.text, f.kind, want)
+		}
+		return fieldOperand{f.get}, nil
+	}
+	return nil, errorf(c.src, n.position(), "query.want_value", want)
+}
+
+// literal приводит литерал к типу поля, с которым его сравнивают
+func (c *checker) literal(n *literalNode, want kind) (value, error) {
+	tok := n.tok
+	switch want {
+	case kindInt:
+		if tok.kind == tokNumber {
+			i, err := strconv.ParseInt(tok.text, 10, 64)
+			if err == nil {
+				return value{i: i}, nil
+			}
+		}
+	case kindString:
+		if tok.kind == tokString {
+			return value{s: tok.text}, nil
+		}
+	case kindTime:
+		if tok.kind == tokString {
+			for _, layout := range timeLayouts {
+				if t, err := time.Parse(layout, tok.text); err == nil {
+					return value{t: t}, nil
+				}
+			}
+			return value{}, errorf(c.src, tok.pos, "query.bad_time", tok.text)
+		}
+	case kindAddr:
+		if tok.kind == tokAddr || tok.kind == tokString {
+			addr, err := netip.ParseAddr(tok.text)
+			if err != nil {
+				return value{}, errorf(c.src, tok.pos, "query.bad_ip", tok.text)
+			}
+			return value{a: addr.Unmap()}, nil
+		}
+	}
+	return value{}, errorf(c.src, tok.pos, "query.value_kind", tok.text, want)
+}
+
+// prefix разбирает подсеть или одиночный адрес для оператора in
+func (c *checker) prefix(n *literalNode) (netip.Prefix, error) {
+	tok := n.tok
+	if tok.kind != tokAddr && tok.kind != tokString {
+		return netip.Prefix{}, errorf(c.src, tok.pos, "query.want_subnet", tok.text)
+	}
+	if strings.Contains(tok.text, "/") {
+		prefix, err := netip.ParsePrefix(tok.text)
+		if err != nil {
+			return netip.Prefix{}, errorf(c.src, tok.pos, "query.bad_subnet", tok.text)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(tok.text)
+	if err != nil {
+		return netip.Prefix{}, errorf(c.src, tok.pos, "query.bad_ip", tok.text)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,176 @@
+package query
+
+import (
+	"net/netip" // Для проверки вхождения в подсеть
+	"regexp"    // Для операторов ~ и !~
+	"strings"   // Для сравнения строк
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Запрос ================================================
+
+// Query — разобранный и проверенный запрос, готовый к вычислению
+type Query struct {
+	src  string
+	cond cond
+}
+
+// Compile разбирает запрос и проверяет типы. Ошибка имеет тип *Error с позицией проблемного токена
+func Compile(src string) (*Query, error) {
+	tree, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &checker{src: src}
+	cond, err := c.checkCond(tree)
+	if err != nil {
+		return nil, err
+	}
+	return &Query{src: src, cond: cond}, nil
+}
+
+// Match сообщает, подходит ли запись под запрос. Сигнатура совместима с processor.Predicate
+func (q *Query) Match(l model.LogEntry) bool {
+	return q.cond.match(l)
+}
+
+// String возвращает исходный текст запроса
+func (q *Query) String() string {
+	return q.src
+}
+
+// ================================================ Вычисление ================================================
+
+// cond — вычисляемое логическое условие
+type cond interface {
+	match(l model.LogEntry) bool
+}
+
+// operand — значение, вычисляемое для записи (поле или константа)
+type operand interface {
+	value(l model.LogEntry) value
+}
+
+type fieldOperand struct{ get func(model.LogEntry) value }
+type constOperand struct{ v value }
+
+func (o fieldOperand) value(l model.LogEntry) value { return o.get(l) }
+func (o constOperand) value(model.LogEntry) value   { return o.v }
+
+type andCond struct{ left, right cond }
+type orCond struct{ left, right cond }
+type notCond struct{ operand cond }
+type boolFieldCond struct{ get func(model.LogEntry) value }
+
+func (c *andCond) match(l model.LogEntry) bool       { return c.left.match(l) && c.right.match(l) }
+func (c *orCond) match(l model.LogEntry) bool        { return c.left.match(l) || c.right.match(l) }
+func (c *notCond) match(l model.LogEntry) bool       { return !c.operand.match(l) }
+func (c *boolFieldCond) match(l model.LogEntry) bool { return c.get(l).b }
+
+// compareCond — сравнение двух значений одного типа
+type compareCond struct {
+	op          tokenKind
+	kind        kind
+	left, right operand
+}
+
+func (c *compareCond) match(l model.LogEntry) bool {
+	a, b := c.left.value(l), c.right.value(l)
+	if c.kind == kindAddr && (!a.a.IsValid() || !b.a.IsValid()) {
+		return c.op == tokNe // Нераспознанный адрес не равен ничему
+	}
+
+	cmp := compare(c.kind, a, b)
+	switch c.op {
+	case tokEq:
+		return cmp == 0
+	case tokNe:
+		return cmp != 0
+	case tokLt:
+		return cmp < 0
+	case tokLe:
+		return cmp <= 0
+	case tokGt:
+		return cmp > 0
+	case tokGe:
+		return cmp >= 0
+	}
+	return false
+}
+
+// compare сравнивает значения одного типа: -1, 0 или 1
+func compare(k kind, a, b value) int {
+	switch k {
+	case kindInt:
+		switch {
+		case a.i < b.i:
+			return -1
+		case a.i > b.i:
+			return 1
+		}
+		return 0
+	case kindString:
+		return strings.Compare(a.s, b.s)
+	case kindTime:
+		return a.t.Compare(b.t)
+	case kindAddr:
+		return a.a.Compare(b.a)
+	case kindBool:
+		if a.b == b.b {
+			return 0
+		}
+		if !a.b {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+// matchCond — проверка строки регулярным выражением
+type matchCond struct {
+	get    func(model.LogEntry) value
+	re     *regexp.Regexp
+	negate bool
+}
+
+func (c *matchCond) match(l model.LogEntry) bool {
+	return c.re.MatchString(c.get(l).s) != c.negate
+}
+
+// inCond — значение входит в список
+type inCond struct {
+	get  func(model.LogEntry) value
+	kind kind
+	set  []value
+}
+
+func (c *inCond) match(l model.LogEntry) bool {
+	v := c.get(l)
+	for _, item := range c.set {
+		if compare(c.kind, v, item) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixCond — IP-адрес входит в одну из подсетей
+type prefixCond struct {
+	get      func(model.LogEntry) value
+	prefixes []netip.Prefix
+}
+
+func (c *prefixCond) match(l model.LogEntry) bool {
+	addr := c.get(l).a
+	if !addr.IsValid() {
+		return false
+	}
+	for _, p := range c.prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
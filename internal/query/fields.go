@@ -0,0 +1,92 @@
+package query
+
+import (
+	"net/netip" // Для IP-адресов
+	"sort"      // Для списка полей в подсказке
+	"time"      // Для времени запроса
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"
+)
+
+// ================================================ Типы значений ================================================
+
+type kind int
+
+const (
+	kindInt kind = iota
+	kindString
+	kindTime
+	kindAddr
+	kindBool
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindInt:
+		return i18n.T("query.kind.number")
+	case kindString:
+		return i18n.T("query.kind.string")
+	case kindTime:
+		return i18n.T("query.kind.time")
+	case kindAddr:
+		return i18n.T("query.kind.ip")
+	case kindBool:
+		return i18n.T("query.kind.bool")
+	}
+	return i18n.T("query.kind.unknown")
+}
+
+// value — значение поля или литерала; заполнено только поле, соответствующее типу
+type value struct {
+	i int64
+	s string
+	t time.Time
+	a netip.Addr
+	b bool
+}
+
+// ================================================ Поля записи ================================================
+
+// field описывает поле model.LogEntry, доступное в запросах
+type field struct {
+	kind kind
+	get  func(model.LogEntry) value
+}
+
+var fields = map[string]field{
+	"timestamp":     {kindTime, func(l model.LogEntry) value { return value{t: l.Timestamp} }},
+	"minute":        {kindTime, func(l model.LogEntry) value { return value{t: l.Timestamp.Truncate(time.Minute)} }}, // для группировки по интервалам
+	"hour":          {kindTime, func(l model.LogEntry) value { return value{t: l.Timestamp.Truncate(time.Hour)} }},
+	"ip":            {kindAddr, func(l model.LogEntry) value { return value{a: netaddr.EntryAddr(l)} }},
+	"client_ip":     {kindAddr, func(l model.LogEntry) value { return value{a: netaddr.ClientAddr(l)} }},
+	"forwarded_for": {kindString, func(l model.LogEntry) value { return value{s: l.ForwardedFor} }},
+	"country":       {kindString, func(l model.LogEntry) value { return value{s: l.Country} }},
+	"city":          {kindString, func(l model.LogEntry) value { return value{s: l.City} }},
+	"asn":           {kindInt, func(l model.LogEntry) value { return value{i: int64(l.ASN)} }},
+	"as_org":        {kindString, func(l model.LogEntry) value { return value{s: l.ASOrg} }},
+	"user_agent":    {kindString, func(l model.LogEntry) value { return value{s: l.UserAgent} }},
+	"browser":       {kindString, func(l model.LogEntry) value { return value{s: l.Client.Browser} }},
+	"os":            {kindString, func(l model.LogEntry) value { return value{s: l.Client.OS} }},
+	"device":        {kindString, func(l model.LogEntry) value { return value{s: l.Client.Device} }},
+	"client":        {kindString, func(l model.LogEntry) value { return value{s: l.Client.Family()} }},
+	"bot":           {kindBool, func(l model.LogEntry) value { return value{b: l.Client.Bot} }},
+	"bot_category":  {kindString, func(l model.LogEntry) value { return value{s: l.Client.BotCategory} }},
+	"method":        {kindString, func(l model.LogEntry) value { return value{s: l.Method} }},
+	"url":           {kindString, func(l model.LogEntry) value { return value{s: l.URL} }},
+	"route":         {kindString, func(l model.LogEntry) value { return value{s: utilits.NormalizeRoute(l.URL)} }},
+	"status":        {kindInt, func(l model.LogEntry) value { return value{i: int64(l.StatusCode)} }},
+	"response_time": {kindInt, func(l model.LogEntry) value { return value{i: int64(l.ResponseTime)} }},
+}
+
+// Fields возвращает отсортированный список полей, доступных в запросах
+func Fields() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
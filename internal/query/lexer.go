@@ -0,0 +1,201 @@
+// Язык запросов для фильтрации записей лога:
+//
+//	status >= 500 and url ~ "^/api/orders" and response_time > 1000 and ip in 10.0.0.0/8
+//
+// Лексер разбивает строку на токены, парсер строит дерево, проверка типов превращает его
+// в условие, которое вычисляется для каждой записи model.LogEntry.
+
+package query
+
+import (
+	"fmt"          // Для форматирования ошибок
+	"strconv"      // Для раскавычивания строк
+	"strings"      // Для работы со строками
+	"unicode"      // Для классификации символов
+	"unicode/utf8" // Для подсчёта позиции в символах
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+)
+
+// ================================================ Токены ================================================
+
+type tokenKind int
+
+const (
+	tokEOF      tokenKind = iota
+	tokIdent              // имя поля
+	tokNumber             // целое число
+	tokString             // строка в кавычках
+	tokAddr               // IP-адрес или подсеть без кавычек: 10.0.0.1, 10.0.0.0/8
+	tokAnd                // and, &&
+	tokOr                 // or, ||
+	tokNot                // not, !
+	tokIn                 // in
+	tokEq                 // ==, =
+	tokNe                 // !=
+	tokLt                 // <
+	tokLe                 // <=
+	tokGt                 // >
+	tokGe                 // >=
+	tokMatch              // ~
+	tokNotMatch           // !~
+	tokLParen             // (
+	tokRParen             // )
+	tokLBrack             // [
+	tokRBrack             // ]
+	tokComma              // ,
+	tokStar               // * (только в SELECT)
+)
+
+// token — лексема с позицией (смещение в байтах от начала строки)
+type token struct {
+	kind tokenKind
+	text string // исходный текст (для строк — уже раскавыченное значение)
+	pos  int
+}
+
+var keywords = map[string]tokenKind{
+	"and": tokAnd,
+	"or":  tokOr,
+	"not": tokNot,
+	"in":  tokIn,
+}
+
+// ================================================ Ошибки ================================================
+
+// Error — ошибка разбора или проверки запроса с указанием позиции проблемного токена
+type Error struct {
+	Src string // исходный текст запроса
+	Pos int    // смещение проблемного токена в байтах
+	Msg string // описание ошибки
+}
+
+// Column возвращает номер символа (с 1), на который указывает ошибка
+func (e *Error) Column() int {
+	return utf8.RuneCountInString(e.Src[:e.Pos]) + 1
+}
+
+func (e *Error) Error() string {
+	return i18n.T("query.error", e.Column(), e.Msg)
+}
+
+// Pretty возвращает запрос и строку с указателем «^» под проблемным токеном
+func (e *Error) Pretty() string {
+	return fmt.Sprintf("%s\n%s^ %s", e.Src, strings.Repeat(" ", e.Column()-1), e.Msg)
+}
+
+// errorf создаёт ошибку с сообщением key на языке по умолчанию
+func errorf(src string, pos int, key string, args ...any) *Error {
+	return &Error{Src: src, Pos: pos, Msg: i18n.T(key, args...)}
+}
+
+// ================================================ Лексер ================================================
+
+// lex разбивает запрос на токены; последний токен всегда tokEOF
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+
+		case r == '"' || r == '\'': // Строка в кавычках
+			end := i + 1
+			for end < len(src) && src[end] != src[i] {
+				if src[end] == '\\' {
+					end++ // Пропускаем экранированный символ
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, errorf(src, start, "query.unclosed_string")
+			}
+			raw := src[i : end+1]
+			if r == '\'' { // strconv.Unquote понимает одинарные кавычки только для одного символа
+				raw = `"` + strings.ReplaceAll(raw[1:len(raw)-1], `"`, `\"`) + `"`
+			}
+			text, err := strconv.Unquote(raw)
+			if err != nil {
+				return nil, errorf(src, start, "query.bad_string", src[i:end+1])
+			}
+			tokens = append(tokens, token{kind: tokString, text: text, pos: start})
+			i = end + 1
+			continue
+
+		case r >= '0' && r <= '9': // Число, IP-адрес или подсеть
+			end := i
+			for end < len(src) && isAddrChar(src[end]) {
+				end++
+			}
+			text := src[i:end]
+			kind := tokNumber
+			if strings.ContainsAny(text, ".:/") {
+				kind = tokAddr
+			} else if _, err := strconv.Atoi(text); err != nil {
+				return nil, errorf(src, start, "query.bad_number", text)
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: start})
+			i = end
+			continue
+
+		case unicode.IsLetter(r) || r == '_': // Имя поля или ключевое слово
+			end := i
+			for end < len(src) {
+				c, n := utf8.DecodeRuneInString(src[end:])
+				if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' {
+					break
+				}
+				end += n
+			}
+			text := src[i:end]
+			kind, ok := keywords[strings.ToLower(text)]
+			if !ok {
+				kind = tokIdent
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: start})
+			i = end
+			continue
+		}
+
+		// Операторы и скобки
+		kind, width := operator(src[i:])
+		if width == 0 {
+			return nil, errorf(src, start, "query.unexpected_char", r)
+		}
+		tokens = append(tokens, token{kind: kind, text: src[i : i+width], pos: start})
+		i += width
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// isAddrChar — символы, из которых состоят числа, IPv4/IPv6-адреса и подсети
+func isAddrChar(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') || c == '.' || c == ':' || c == '/'
+}
+
+// operator распознаёт оператор в начале строки и возвращает его вид и длину (0 — не оператор)
+func operator(s string) (tokenKind, int) {
+	two := map[string]tokenKind{
+		"==": tokEq, "!=": tokNe, "<=": tokLe, ">=": tokGe, "!~": tokNotMatch, "&&": tokAnd, "||": tokOr,
+	}
+	if len(s) >= 2 {
+		if kind, ok := two[s[:2]]; ok {
+			return kind, 2
+		}
+	}
+
+	one := map[byte]tokenKind{
+		'=': tokEq, '<': tokLt, '>': tokGt, '~': tokMatch, '!': tokNot,
+		'(': tokLParen, ')': tokRParen, '[': tokLBrack, ']': tokRBrack, ',': tokComma, '*': tokStar,
+	}
+	if kind, ok := one[s[0]]; ok {
+		return kind, 1
+	}
+	return tokEOF, 0
+}
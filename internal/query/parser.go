@@ -0,0 +1,207 @@
+package query
+
+import "github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+
+// ================================================ Дерево разбора ================================================
+
+// node — узел дерева разбора
+type node interface {
+	position() int
+}
+
+// binaryNode — логическая операция (and/or) или сравнение
+type binaryNode struct {
+	op          token
+	left, right node
+}
+
+// notNode — логическое отрицание
+type notNode struct {
+	op      token
+	operand node
+}
+
+// fieldNode — ссылка на поле записи
+type fieldNode struct {
+	tok token
+}
+
+// literalNode — число, строка или адрес
+type literalNode struct {
+	tok token
+}
+
+// listNode — список значений в квадратных скобках: [500, 502, 504]
+type listNode struct {
+	tok   token
+	items []*literalNode
+}
+
+func (n *binaryNode) position() int  { return n.left.position() }
+func (n *notNode) position() int     { return n.op.pos }
+func (n *fieldNode) position() int   { return n.tok.pos }
+func (n *literalNode) position() int { return n.tok.pos }
+func (n *listNode) position() int    { return n.tok.pos }
+
+// ================================================ Парсер ================================================
+
+// Грамматика (по убыванию приоритета снизу вверх):
+//
+//	expr       = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | comparison
+//	comparison = operand [ op operand ] | "(" expr ")"
+//	operand    = field | number | string | addr | "[" literal { "," literal } "]"
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+// parse строит дерево разбора запроса
+func parse(src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{src: src, tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, errorf(src, 0, "query.empty")
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorf(src, tok.pos, "query.extra_token", tok.text)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) unexpected(tok token, want string) error {
+	if tok.kind == tokEOF {
+		return errorf(p.src, tok.pos, "query.truncated", want)
+	}
+	return errorf(p.src, tok.pos, "query.unexpected_token", tok.text, want)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		op := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		op := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind == tokNot {
+		op := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{op: op, operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	if p.peek().kind == tokLParen {
+		open := p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokRParen {
+			if tok.kind == tokEOF {
+				return nil, errorf(p.src, open.pos, "query.unclosed_paren")
+			}
+			return nil, p.unexpected(tok, i18n.T("query.want.close_paren"))
+		}
+		return n, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.peek().kind {
+	case tokEq, tokNe, tokLt, tokLe, tokGt, tokGe, tokMatch, tokNotMatch, tokIn:
+		op := p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil // Одиночный операнд: например, логическое поле bot
+}
+
+func (p *parser) parseOperand() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokIdent:
+		return &fieldNode{tok: tok}, nil
+	case tokNumber, tokString, tokAddr:
+		return &literalNode{tok: tok}, nil
+	case tokLBrack:
+		list := &listNode{tok: tok}
+		for {
+			item := p.next()
+			switch item.kind {
+			case tokNumber, tokString, tokAddr:
+				list.items = append(list.items, &literalNode{tok: item})
+			default:
+				return nil, p.unexpected(item, i18n.T("query.want.list_value"))
+			}
+
+			sep := p.next()
+			if sep.kind == tokRBrack {
+				return list, nil
+			}
+			if sep.kind != tokComma {
+				return nil, p.unexpected(sep, i18n.T("query.want.list_sep"))
+			}
+		}
+	}
+	return nil, p.unexpected(tok, i18n.T("query.want.operand"))
+}
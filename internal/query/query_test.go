@@ -0,0 +1,126 @@
+package query
+
+import (
+	"errors"    // Для извлечения *Error
+	"net/netip" // Для адреса клиента за прокси
+	"strings"   // Для проверки текста ошибок
+	"testing"   // Cтандартная библиотека для тестов Go
+	"time"      // Для работы с датой и временем
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Тест вычисления запросов ================================================
+
+func TestCompileAndMatch(t *testing.T) {
+	entry := model.LogEntry{
+		Timestamp:    time.Date(2024, 1, 15, 10, 30, 5, 0, time.UTC),
+		IP:           "10.1.2.3",
+		Method:       "POST",
+		URL:          "/api/orders/42",
+		StatusCode:   502,
+		ResponseTime: 1500,
+		ForwardedFor: "198.51.100.7, 10.0.0.9",
+		ClientAddr:   netip.MustParseAddr("198.51.100.7"),
+		Country:      "GB",
+		ASN:          20712,
+		Client:       model.ClientInfo{Browser: "Firefox", OS: "Linux", Device: "desktop"},
+	}
+
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{`status >= 500 and url ~ "^/api/orders" and response_time > 1000 and ip in 10.0.0.0/8`, true},
+		{`status == 200 or method == "POST"`, true},
+		{`not (status < 500)`, true},
+		{`status in [500, 502, 504] && method in ["GET", 'POST']`, true},
+		{`url !~ "^/api/"`, false},
+		{`ip == 10.1.2.3 and ip != "10.1.2.4"`, true},
+		{`ip in [192.168.0.0/16, 10.1.2.3]`, true},
+		{`ip in 2001:db8::/32`, false},
+		{`timestamp >= "2024-01-15 10:30:00" and timestamp < "2024-01-15 10:31:00"`, true},
+		{`timestamp > "2024-01-16"`, false},
+		{`STATUS = 502 AND Method = "POST"`, true},
+		{`response_time > status`, true},
+		{`client_ip == 198.51.100.7 and ip in 10.0.0.0/8`, true},
+		{`client_ip in 10.0.0.0/8`, false},
+		{`country in ["GB", "IE"] and asn == 20712 and city == ""`, true},
+		{`not bot and browser == "Firefox" and device != "mobile"`, true},
+		{`bot or client == "curl"`, false},
+		{`forwarded_for ~ "10\\.0\\.0\\.9$"`, true},
+	}
+
+	for _, tt := range tests {
+		q, err := Compile(tt.query)
+		if err != nil {
+			t.Errorf("Compile(%q) вернул ошибку: %v", tt.query, err)
+			continue
+		}
+		if got := q.Match(entry); got != tt.expected {
+			t.Errorf("%q: ожидалось %v, получили %v", tt.query, tt.expected, got)
+		}
+	}
+}
+
+// ================================================ Тест сообщений об ошибках ================================================
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		query   string
+		column  int    // на какой символ должна указывать ошибка
+		message string // фрагмент текста ошибки
+	}{
+		{`stauts >= 500`, 1, `неизвестное поле "stauts"`},
+		{`status >= "abc"`, 11, `ожидался тип «число»`},
+		{`status >= 500 and`, 18, `запрос оборвался`},
+		{`status ~ "5.."`, 8, `только к строковым полям`},
+		{`url ~ "(("`, 7, `неверное регулярное выражение`},
+		{`ip in 10.0.0.0/33`, 7, `неверная подсеть`},
+		{`method == GET`, 11, `неизвестное поле "GET"`},
+		{`(status == 200`, 1, `незакрытая скобка`},
+		{`status == 200 200`, 15, `лишний токен "200"`},
+		{`url == "/api`, 8, `незакрытая строка`},
+		{`status`, 1, `не логический`},
+		{`status $ 1`, 8, `неожиданный символ`},
+		{`ip > 10.0.0.1`, 4, `не применим`},
+		{`timestamp > "вчера"`, 13, `неверное время`},
+	}
+
+	for _, tt := range tests {
+		_, err := Compile(tt.query)
+		var qerr *Error
+		if !errors.As(err, &qerr) {
+			t.Errorf("%q: ожидалась ошибка *Error, получили %v", tt.query, err)
+			continue
+		}
+		if qerr.Column() != tt.column || !strings.Contains(qerr.Msg, tt.message) {
+			t.Errorf("%q: ожидалась ошибка на символе %d с текстом %q, получили символ %d: %s",
+				tt.query, tt.column, tt.message, qerr.Column(), qerr.Msg)
+		}
+	}
+}
+
+func TestErrorPretty(t *testing.T) {
+	_, err := Compile(`status >= 500 and urll ~ "x"`)
+	var qerr *Error
+	if !errors.As(err, &qerr) {
+		t.Fatalf("Ожидалась ошибка *Error, получили %v", err)
+	}
+
+	lines := strings.Split(qerr.Pretty(), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], strings.Repeat(" ", 18)+"^") {
+		t.Errorf("Указатель должен стоять под полем urll:\n%s", qerr.Pretty())
+	}
+}
+
+func TestErrorEnglish(t *testing.T) {
+	defer i18n.SetDefault(i18n.Default().Lang())
+	i18n.SetDefault(i18n.EN)
+
+	_, err := Compile(`status >= "abc"`)
+	if want := `Query error (character 11): value "abc" does not fit: expected type “number”`; err == nil || err.Error() != want {
+		t.Errorf("Ожидалась ошибка %q, получили %v", want, err)
+	}
+}
@@ -0,0 +1,314 @@
+package query
+
+import (
+	"regexp"  // Для распознавания функций перцентилей pNN
+	"strconv" // Для разбора чисел
+	"strings" // Для сравнения ключевых слов
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Разбор SELECT ================================================
+
+// Select — разобранный агрегирующий запрос:
+//
+//	SELECT route, count(*), p95(response_time) FROM logs WHERE status >= 400
+//	GROUP BY route ORDER BY 2 DESC LIMIT 10
+type Select struct {
+	src     string
+	columns []column
+	where   cond // nil — без условия
+	groupBy []groupKey
+	orderBy []orderKey
+	limit   int // 0 — без ограничения
+}
+
+// column — выражение в списке SELECT: поле группировки или агрегатная функция
+type column struct {
+	label string // заголовок колонки (псевдоним после AS или текст выражения)
+	pos   int
+
+	field string                     // имя поля (для поля группировки или аргумента функции)
+	kind  kind                       // тип поля
+	get   func(model.LogEntry) value // значение поля (nil для count(*))
+
+	agg        string  // имя агрегатной функции: count, sum, avg, min, max, pct, apdex; пусто — поле
+	percentile float64 // для pct — какой перцентиль считать
+	threshold  int     // для apdex — порог T в мс
+}
+
+type groupKey struct {
+	field string
+	kind  kind
+	get   func(model.LogEntry) value
+}
+
+type orderKey struct {
+	column int // индекс колонки
+	desc   bool
+}
+
+var percentileFunc = regexp.MustCompile(`^p(\d{1,2}(\.\d+)?)$`)
+
+// ParseSelect разбирает агрегирующий запрос. Ошибка имеет тип *Error с позицией проблемного токена
+func ParseSelect(src string) (*Select, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	c := &checker{src: src}
+	sel := &Select{src: src}
+
+	if err := p.keyword("select"); err != nil {
+		return nil, err
+	}
+	for {
+		col, err := p.parseColumn(c)
+		if err != nil {
+			return nil, err
+		}
+		sel.columns = append(sel.columns, col)
+		if p.peek().kind != tokComma {
+			break
+		}
+		p.next()
+	}
+
+	if err := p.keyword("from"); err != nil {
+		return nil, err
+	}
+	table := p.next()
+	if table.kind != tokIdent || !strings.EqualFold(table.text, "logs") {
+		return nil, errorf(src, table.pos, "query.unknown_table", table.text)
+	}
+
+	if p.isKeyword("where") {
+		p.next()
+		tree, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if sel.where, err = c.checkCond(tree); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isKeyword("group") {
+		p.next()
+		if err := p.keyword("by"); err != nil {
+			return nil, err
+		}
+		for {
+			tok := p.next()
+			if tok.kind != tokIdent {
+				return nil, p.unexpected(tok, i18n.T("query.want.group_field"))
+			}
+			f, err := c.lookup(&fieldNode{tok: tok})
+			if err != nil {
+				return nil, err
+			}
+			sel.groupBy = append(sel.groupBy, groupKey{field: strings.ToLower(tok.text), kind: f.kind, get: f.get})
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.isKeyword("order") {
+		p.next()
+		if err := p.keyword("by"); err != nil {
+			return nil, err
+		}
+		for {
+			key, err := p.parseOrderKey(sel)
+			if err != nil {
+				return nil, err
+			}
+			sel.orderBy = append(sel.orderBy, key)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.isKeyword("limit") {
+		p.next()
+		tok := p.next()
+		n, err := strconv.Atoi(tok.text)
+		if tok.kind != tokNumber || err != nil || n <= 0 {
+			return nil, errorf(src, tok.pos, "query.bad_limit")
+		}
+		sel.limit = n
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorf(src, tok.pos, "query.extra_token", tok.text)
+	}
+	return sel, sel.validate()
+}
+
+// validate проверяет, что поля без агрегатной функции перечислены в GROUP BY
+func (s *Select) validate() error {
+	hasAgg := false
+	for _, col := range s.columns {
+		hasAgg = hasAgg || col.agg != ""
+	}
+	if !hasAgg && len(s.groupBy) == 0 {
+		return nil // Простая выборка полей
+	}
+
+	for _, col := range s.columns {
+		if col.agg != "" {
+			continue
+		}
+		grouped := false
+		for _, g := range s.groupBy {
+			grouped = grouped || g.field == col.field
+		}
+		if !grouped {
+			return errorf(s.src, col.pos, "query.not_grouped", col.field)
+		}
+	}
+	return nil
+}
+
+// String возвращает исходный текст запроса
+func (s *Select) String() string {
+	return s.src
+}
+
+// isKeyword — следующий токен является указанным ключевым словом SQL
+func (p *parser) isKeyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokIdent && strings.EqualFold(tok.text, word)
+}
+
+// keyword требует, чтобы следующим токеном было указанное ключевое слово
+func (p *parser) keyword(word string) error {
+	if !p.isKeyword(word) {
+		return p.unexpected(p.peek(), strings.ToUpper(word))
+	}
+	p.next()
+	return nil
+}
+
+// parseColumn разбирает выражение списка SELECT с необязательным псевдонимом AS
+func (p *parser) parseColumn(c *checker) (column, error) {
+	tok := p.next()
+	if tok.kind != tokIdent {
+		return column{}, p.unexpected(tok, i18n.T("query.want.column"))
+	}
+
+	var col column
+	if p.peek().kind == tokLParen { // Агрегатная функция
+		var err error
+		if col, err = p.parseAggregate(c, tok); err != nil {
+			return column{}, err
+		}
+	} else {
+		f, err := c.lookup(&fieldNode{tok: tok})
+		if err != nil {
+			return column{}, err
+		}
+		col = column{label: strings.ToLower(tok.text), pos: tok.pos, field: strings.ToLower(tok.text), kind: f.kind, get: f.get}
+	}
+
+	if p.isKeyword("as") {
+		p.next()
+		alias := p.next()
+		if alias.kind != tokIdent && alias.kind != tokString {
+			return column{}, p.unexpected(alias, i18n.T("query.want.alias"))
+		}
+		col.label = alias.text
+	}
+	return col, nil
+}
+
+// parseAggregate разбирает вызов count(*), count(поле), sum, avg, min, max, pNN(поле) или apdex(T)
+func (p *parser) parseAggregate(c *checker, name token) (column, error) {
+	fn := strings.ToLower(name.text)
+	col := column{pos: name.pos, agg: fn}
+	if m := percentileFunc.FindStringSubmatch(fn); m != nil {
+		col.agg = "pct"
+		col.percentile, _ = strconv.ParseFloat(m[1], 64)
+	} else if fn != "count" && fn != "sum" && fn != "avg" && fn != "min" && fn != "max" && fn != "apdex" {
+		return column{}, errorf(p.src, name.pos, "query.unknown_function", name.text)
+	}
+	p.next() // «(»
+
+	arg := p.next()
+	switch {
+	case fn == "apdex": // Аргумент — порог T в мс; считается по response_time и status
+		t, err := strconv.Atoi(arg.text)
+		if arg.kind != tokNumber || err != nil || t <= 0 {
+			return column{}, errorf(p.src, arg.pos, "query.apdex_arg")
+		}
+		col.field, col.threshold = arg.text, t
+	case arg.kind == tokStar && fn == "count":
+		col.field = "*"
+	case arg.kind == tokIdent:
+		f, err := c.lookup(&fieldNode{tok: arg})
+		if err != nil {
+			return column{}, err
+		}
+		if fn != "count" && f.kind != kindInt {
+			return column{}, errorf(p.src, arg.pos, "query.function_kind", fn, arg.text, f.kind)
+		}
+		col.field, col.kind, col.get = strings.ToLower(arg.text), f.kind, f.get
+	default:
+		return column{}, p.unexpected(arg, i18n.T("query.want.argument"))
+	}
+
+	if tok := p.next(); tok.kind != tokRParen {
+		return column{}, p.unexpected(tok, i18n.T("query.want.close_paren"))
+	}
+	col.label = fn + "(" + col.field + ")"
+	return col, nil
+}
+
+// parseOrderKey разбирает ключ сортировки: номер колонки (с 1) или её заголовок, затем ASC/DESC
+func (p *parser) parseOrderKey(sel *Select) (orderKey, error) {
+	tok := p.next()
+	key := orderKey{column: -1}
+
+	switch tok.kind {
+	case tokNumber:
+		n, _ := strconv.Atoi(tok.text)
+		if n < 1 || n > len(sel.columns) {
+			return orderKey{}, errorf(p.src, tok.pos, "query.column_range", n, len(sel.columns))
+		}
+		key.column = n - 1
+	case tokIdent, tokString:
+		label := tok.text
+		if tok.kind == tokIdent && p.peek().kind == tokLParen { // ORDER BY count(*)
+			p.next()
+			arg := p.next()
+			if closing := p.next(); closing.kind != tokRParen {
+				return orderKey{}, p.unexpected(closing, i18n.T("query.want.close_paren"))
+			}
+			label = strings.ToLower(tok.text) + "(" + strings.ToLower(arg.text) + ")"
+		}
+		for i, col := range sel.columns {
+			if strings.EqualFold(col.label, label) {
+				key.column = i
+			}
+		}
+		if key.column < 0 {
+			return orderKey{}, errorf(p.src, tok.pos, "query.unknown_column", label)
+		}
+	default:
+		return orderKey{}, p.unexpected(tok, i18n.T("query.want.order_key"))
+	}
+
+	if p.isKeyword("desc") {
+		p.next()
+		key.desc = true
+	} else if p.isKeyword("asc") {
+		p.next()
+	}
+	return key, nil
+}
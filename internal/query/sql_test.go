@@ -0,0 +1,138 @@
+package query
+
+import (
+	"context" // Для потокового выполнения запроса
+	"errors"  // Для извлечения *Error
+	"strings" // Для проверки текста ошибок
+	"testing" // Cтандартная библиотека для тестов Go
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Тесты агрегирующих запросов ================================================
+
+func sqlTestLogs() []model.LogEntry {
+	return []model.LogEntry{
+		{IP: "10.0.0.1", Method: "GET", URL: "/api/users/1", StatusCode: 200, ResponseTime: 100},
+		{IP: "10.0.0.1", Method: "GET", URL: "/api/users/2", StatusCode: 404, ResponseTime: 50},
+		{IP: "10.0.0.2", Method: "GET", URL: "/api/users/3", StatusCode: 500, ResponseTime: 900},
+		{IP: "10.0.0.3", Method: "POST", URL: "/api/orders", StatusCode: 503, ResponseTime: 2000},
+		{IP: "10.0.0.3", Method: "POST", URL: "/api/orders?retry=1", StatusCode: 201, ResponseTime: 300},
+		{IP: "10.0.0.4", Method: "GET", URL: "/api/health", StatusCode: 200, ResponseTime: 5},
+	}
+}
+
+// runSQL выполняет запрос над тестовыми логами через канал, как в потоковом режиме
+func runSQL(t *testing.T, src string) *Result {
+	t.Helper()
+	sel, err := ParseSelect(src)
+	if err != nil {
+		t.Fatalf("ParseSelect(%q) вернул ошибку: %v", src, err)
+	}
+
+	input := make(chan model.LogEntry)
+	go func() {
+		defer close(input)
+		for _, l := range sqlTestLogs() {
+			input <- l
+		}
+	}()
+	res, err := sel.Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run вернул ошибку: %v", err)
+	}
+	return res
+}
+
+func TestSelectGroupBy(t *testing.T) {
+	res := runSQL(t, `SELECT route, count(*), p95(response_time) AS p95 FROM logs WHERE status >= 400 GROUP BY route ORDER BY 2 DESC, route LIMIT 10`)
+
+	if strings.Join(res.Columns, ",") != "route,count(*),p95" {
+		t.Errorf("Неверные колонки: %v", res.Columns)
+	}
+	// /api/users/:id — 2 ошибки (404, 500), /api/orders — 1 (503)
+	expected := [][]string{{"/api/users/:id", "2", "857.50"}, {"/api/orders", "1", "2000"}}
+	if len(res.Rows) != len(expected) {
+		t.Fatalf("Ожидалось %d строк, получили:\n%s", len(expected), res)
+	}
+	for i, row := range expected {
+		for j, cell := range row {
+			if got := res.Rows[i][j].String(); got != cell {
+				t.Errorf("Строка %d, колонка %d: ожидалось %s, получили %s", i+1, j+1, cell, got)
+			}
+		}
+	}
+}
+
+func TestSelectAggregatesWithoutGroup(t *testing.T) {
+	res := runSQL(t, `select count(*), sum(response_time), avg(response_time), min(response_time), max(response_time), count(url) from logs where method == "GET"`)
+
+	got := make([]string, len(res.Rows[0]))
+	for i, c := range res.Rows[0] {
+		got[i] = c.String()
+	}
+	if strings.Join(got, " ") != "4 1055 263.75 5 900 4" {
+		t.Errorf("Неверные агрегаты: %v", got)
+	}
+
+	empty := runSQL(t, `SELECT count(*) FROM logs WHERE status == 999`)
+	if len(empty.Rows) != 1 || empty.Rows[0][0].String() != "0" { // Агрегат без групп даёт одну строку
+		t.Errorf("Ожидалась одна строка с нулём, получили:\n%s", empty)
+	}
+}
+
+func TestSelectPlainAndOrderByLabel(t *testing.T) {
+	res := runSQL(t, `SELECT ip, response_time FROM logs ORDER BY response_time DESC LIMIT 2`)
+	if len(res.Rows) != 2 || res.Rows[0][0].String() != "10.0.0.3" || res.Rows[1][1].String() != "900" {
+		t.Errorf("Неверная простая выборка:\n%s", res)
+	}
+
+	res = runSQL(t, `SELECT method, count(*) FROM logs GROUP BY method ORDER BY count(*) ASC`)
+	if res.Rows[0][0].String() != "POST" || res.Rows[1][0].String() != "GET" {
+		t.Errorf("Неверная сортировка по count(*):\n%s", res)
+	}
+}
+
+func TestSelectApdex(t *testing.T) {
+	// T = 300: ошибки разочаровывают всегда; при T = 50 ответ за 100 мс — терпимый
+	res := runSQL(t, `SELECT route, apdex(300), apdex(50) FROM logs GROUP BY route ORDER BY 2`)
+	expected := [][]string{{"/api/users/:id", "0.33", "0.17"}, {"/api/orders", "0.50", "0"}, {"/api/health", "1", "1"}}
+	for i, row := range expected {
+		for j, cell := range row {
+			if got := res.Rows[i][j].String(); got != cell {
+				t.Errorf("Строка %d, колонка %d: ожидалось %s, получили %s", i+1, j+1, cell, got)
+			}
+		}
+	}
+
+	empty := runSQL(t, `SELECT apdex(500) FROM logs WHERE status == 999`)
+	if empty.Rows[0][0].String() != "1" {
+		t.Errorf("Apdex без запросов должен быть 1, получили %s", empty.Rows[0][0])
+	}
+}
+
+func TestParseSelectErrors(t *testing.T) {
+	tests := []struct {
+		query   string
+		message string
+	}{
+		{`SELECT route, count(*) FROM logs`, `должно быть в GROUP BY`},
+		{`SELECT count(*) FROM events`, `неизвестная таблица`},
+		{`SELECT median(response_time) FROM logs`, `неизвестная функция`},
+		{`SELECT avg(url) FROM logs`, `только к числовым полям`},
+		{`SELECT apdex(response_time) FROM logs`, `порог T в миллисекундах`},
+		{`SELECT apdex(0) FROM logs`, `порог T в миллисекундах`},
+		{`SELECT count(*) FROM logs ORDER BY 3`, `вне диапазона`},
+		{`SELECT count(*) FROM logs LIMIT 0`, `положительное число`},
+		{`SELECT count(*) logs`, `ожидалось FROM`},
+		{`SELECT count(*) FROM logs WHERE stat > 1`, `неизвестное поле "stat"`},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseSelect(tt.query)
+		var qerr *Error
+		if !errors.As(err, &qerr) || !strings.Contains(qerr.Msg, tt.message) {
+			t.Errorf("%q: ожидалась ошибка с текстом %q, получили %v", tt.query, tt.message, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Цели уровня обслуживания (SLO): доля успешных или быстрых запросов за окно, остаток бюджета ошибок
+// и тревоги по скорости его сжигания в двух окнах (методика Google SRE Workbook).
+
+package slo
+
+import (
+	"encoding/json" // Для разбора файла с описанием SLO
+	"io"            // Для чтения описания из произвольного источника
+	"os"            // Для открытия файла
+	"regexp"        // Для шаблонов маршрутов
+	"strings"       // Для приведения метода к верхнему регистру
+	"time"          // Для окон
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"
+)
+
+// ================================================ Описание SLO ================================================
+
+// Objective — одна цель. Если LatencyMs равен 0, это цель доступности (запрос хороший, если его статус
+// меньше ErrorStatus), иначе — цель по задержке (хороший, если ответ не дольше LatencyMs)
+type Objective struct {
+	Name        string           `json:"name"`
+	Route       string           `json:"route,omitempty"`        // регулярное выражение маршрута (пусто — все запросы)
+	Method      string           `json:"method,omitempty"`       // HTTP-метод (пусто — любой)
+	Target      float64          `json:"target"`                 // доля хороших запросов в процентах, например 99.5
+	Window      utilits.Duration `json:"window"`                 // окно соответствия, например "30d"
+	LatencyMs   int              `json:"latency_ms,omitempty"`   // порог задержки для цели по задержке
+	ErrorStatus int              `json:"error_status,omitempty"` // минимальный статус ошибки для цели доступности (по умолчанию 500)
+}
+
+// BurnRateRule — тревога по скорости сжигания бюджета: срабатывает, когда и в длинном, и в коротком окне
+// ошибки тратят бюджет не медленнее чем в Factor раз быстрее допустимого
+type BurnRateRule struct {
+	Long     utilits.Duration `json:"long"`
+	Short    utilits.Duration `json:"short"`
+	Factor   float64          `json:"factor"`
+	Severity string           `json:"severity,omitempty"` // например page или ticket (по умолчанию page)
+}
+
+// DefaultBurnRateRules — правила из Google SRE Workbook для 30-дневного окна
+var DefaultBurnRateRules = []BurnRateRule{
+	{Long: utilits.Duration(time.Hour), Short: utilits.Duration(5 * time.Minute), Factor: 14.4, Severity: "page"},
+	{Long: utilits.Duration(6 * time.Hour), Short: utilits.Duration(30 * time.Minute), Factor: 6, Severity: "page"},
+	{Long: utilits.Duration(3 * 24 * time.Hour), Short: utilits.Duration(6 * time.Hour), Factor: 1, Severity: "ticket"},
+}
+
+// Config — файл с описанием SLO
+type Config struct {
+	Objectives []Objective      `json:"slos"`
+	Alerts     []BurnRateRule   `json:"burn_rate_alerts,omitempty"` // по умолчанию DefaultBurnRateRules
+	Resolution utilits.Duration `json:"resolution,omitempty"`       // шаг, с которым проверяются тревоги (по умолчанию 1m)
+	routes     []*regexp.Regexp // скомпилированные шаблоны маршрутов
+}
+
+// Load читает описание SLO из файла
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, i18n.Errorf("slo.config.open", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse читает описание SLO в формате JSON, проверяет его и заполняет значения по умолчанию
+func Parse(r io.Reader) (*Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields() // Опечатка в названии поля не должна молча отключать настройку
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, i18n.Errorf("slo.config.read", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// validate проверяет описание и заполняет значения по умолчанию
+func (c *Config) validate() error {
+	if len(c.Objectives) == 0 {
+		return i18n.Errorf("slo.config.empty")
+	}
+	names := make(map[string]bool)
+	c.routes = make([]*regexp.Regexp, len(c.Objectives))
+	for i := range c.Objectives {
+		o := &c.Objectives[i]
+		switch {
+		case o.Name == "":
+			return i18n.Errorf("slo.config.no_name", i+1)
+		case names[o.Name]:
+			return i18n.Errorf("slo.config.duplicate", o.Name)
+		case o.Target <= 0 || o.Target >= 100:
+			return i18n.Errorf("slo.config.target", o.Name, o.Target)
+		case o.Window <= 0:
+			return i18n.Errorf("slo.config.no_window", o.Name)
+		case o.LatencyMs < 0:
+			return i18n.Errorf("slo.config.latency", o.Name)
+		}
+		names[o.Name] = true
+		if o.ErrorStatus == 0 {
+			o.ErrorStatus = 500
+		}
+		o.Method = strings.ToUpper(o.Method)
+		if o.Route != "" {
+			re, err := regexp.Compile(o.Route)
+			if err != nil {
+				return i18n.Errorf("slo.config.route", o.Name, err)
+			}
+			c.routes[i] = re
+		}
+	}
+
+	if len(c.Alerts) == 0 {
+		c.Alerts = append([]BurnRateRule(nil), DefaultBurnRateRules...)
+	}
+	for i := range c.Alerts {
+		rule := &c.Alerts[i]
+		if rule.Long <= 0 || rule.Short <= 0 || rule.Short > rule.Long || rule.Factor <= 0 {
+			return i18n.Errorf("slo.config.burn_rate", i+1)
+		}
+		if rule.Severity == "" {
+			rule.Severity = "page"
+		}
+	}
+	if c.Resolution <= 0 {
+		c.Resolution = utilits.Duration(time.Minute)
+	}
+	return nil
+}
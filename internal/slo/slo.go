@@ -0,0 +1,361 @@
+package slo
+
+import (
+	"math"    // Для пустых ячеек кольца
+	"strings" // Для сборки отчёта
+	"sync"    // Для параллельных вызовов Observe
+	"time"    // Для окон
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/utilits"
+)
+
+// ================================================ Подсчёт ================================================
+
+// counts — запросы одного интервала
+type counts struct {
+	total int
+	bad   int
+}
+
+// Alert — период, когда скорость сжигания бюджета превышала порог правила и в длинном, и в коротком окне
+type Alert struct {
+	SLO       string
+	Rule      BurnRateRule
+	Start     time.Time // первая проверка, на которой правило сработало
+	End       time.Time // последняя проверка, на которой правило сработало
+	Active    bool      // правило срабатывает на момент последней записи
+	LongBurn  float64   // максимальная скорость сжигания в длинном окне за период
+	ShortBurn float64   // скорость сжигания в коротком окне при срабатывании
+	Message   string    // описание для человека
+}
+
+// Result — состояние одной цели на момент последней записи
+type Result struct {
+	Objective       Objective
+	Total           int           // запросов в окне
+	Good            int           // из них хороших
+	Compliance      float64       // доля хороших в процентах
+	BudgetRemaining float64       // остаток бюджета ошибок: 1 — не тронут, 0 — израсходован, меньше 0 — превышен
+	Met             bool          // цель выполняется
+	Coverage        time.Duration // за сколько времени в окне есть данные
+	Alerts          []Alert       // периоды срабатывания тревог в хронологическом порядке
+}
+
+// Evaluator считает хорошие и плохие запросы каждой цели по интервалам Resolution. Окна отсчитываются
+// от времени последней записи, а не от часов машины. Хранятся только интервалы самого длинного окна
+// (кольцо фиксированного размера), более старые записи отбрасываются, поэтому память не растёт
+// в режиме слежения. Безопасен для вызова из нескольких воркеров
+type Evaluator struct {
+	cfg     *Config
+	res     time.Duration
+	span    int // размер кольца: интервалов в самом длинном окне целей и правил
+	quiet   int // через сколько интервалов без записей ни одно правило уже не может срабатывать
+	onAlert func(Alert)
+
+	mu      sync.Mutex
+	origin  time.Time  // начало первого интервала — от него отсчитываются номера интервалов
+	first   time.Time  // начало самого раннего интервала с записями (для покрытия)
+	last    time.Time  // начало самого позднего интервала с записями
+	keys    []int64    // номер интервала в каждой ячейке кольца
+	buckets [][]counts // кольца интервалов по целям
+	firing  [][]*Alert // текущие срабатывания по целям и правилам
+	closed  [][]Alert  // закончившиеся тревоги по целям
+}
+
+// NewEvaluator создаёт вычислитель. onAlert (может быть nil) вызывается, когда тревога начинает или перестаёт
+// срабатывать; проверка идёт по мере поступления записей, когда очередной интервал закончился
+func NewEvaluator(cfg *Config, onAlert func(Alert)) *Evaluator {
+	e := &Evaluator{cfg: cfg, res: time.Duration(cfg.Resolution), onAlert: onAlert}
+	var longest, longestShort time.Duration
+	for _, o := range cfg.Objectives {
+		longest = max(longest, time.Duration(o.Window))
+	}
+	for _, rule := range cfg.Alerts {
+		longest = max(longest, time.Duration(rule.Long))
+		longestShort = max(longestShort, time.Duration(rule.Short))
+	}
+	e.span = int((longest+e.res-1)/e.res) + 1
+	e.quiet = int((longestShort+e.res-1)/e.res) + 1
+
+	e.keys = make([]int64, e.span)
+	for j := range e.keys {
+		e.keys[j] = math.MinInt64 // Ячейка пуста
+	}
+	e.buckets = make([][]counts, len(cfg.Objectives))
+	e.firing = make([][]*Alert, len(cfg.Objectives))
+	e.closed = make([][]Alert, len(cfg.Objectives))
+	for i := range e.buckets {
+		e.buckets[i] = make([]counts, e.span)
+		e.firing[i] = make([]*Alert, len(cfg.Alerts))
+	}
+	return e
+}
+
+// Observe учитывает запись во всех целях, которым она подходит
+func (e *Evaluator) Observe(l model.LogEntry) {
+	route := utilits.NormalizeRoute(l.URL)
+	start := l.Timestamp.Truncate(e.res)
+
+	e.mu.Lock()
+	var changed []Alert
+	if !e.last.IsZero() && start.After(e.last) { // Предыдущие интервалы закончились — проверяем тревоги на конце каждого
+		// После quiet пустых интервалов короткие окна всех правил пусты и состояние больше не меняется
+		for end, n := e.last.Add(e.res), 0; !end.After(start) && n <= e.quiet; end, n = end.Add(e.res), n+1 {
+			changed = append(changed, e.checkAlerts(end)...)
+		}
+	}
+	if e.last.IsZero() || start.After(e.last) {
+		e.last = start
+	}
+	if e.first.IsZero() || start.Before(e.first) {
+		e.first = start
+	}
+
+	idx, ok := e.index(start)
+	for i, o := range e.cfg.Objectives {
+		if !ok { // Запись старше самого длинного окна
+			break
+		}
+		if !e.matches(i, route, l.Method) {
+			continue
+		}
+		c := &e.buckets[i][idx]
+		c.total++
+		if !o.good(l) {
+			c.bad++
+		}
+	}
+	e.mu.Unlock()
+
+	if e.onAlert != nil {
+		for _, a := range changed {
+			e.onAlert(a)
+		}
+	}
+}
+
+// matches — подходит ли запрос цели i
+func (e *Evaluator) matches(i int, route, method string) bool {
+	o := e.cfg.Objectives[i]
+	if o.Method != "" && !strings.EqualFold(o.Method, method) {
+		return false
+	}
+	return e.cfg.routes[i] == nil || e.cfg.routes[i].MatchString(route)
+}
+
+// good — хороший ли запрос для цели
+func (o Objective) good(l model.LogEntry) bool {
+	if o.LatencyMs > 0 {
+		return l.ResponseTime <= o.LatencyMs
+	}
+	return l.StatusCode < o.ErrorStatus
+}
+
+// key — номер интервала, который начинается в start. Вызывается под e.mu
+func (e *Evaluator) key(start time.Time) int64 {
+	if e.origin.IsZero() {
+		e.origin = start
+	}
+	return int64(start.Sub(e.origin) / e.res)
+}
+
+// slot — ячейка кольца для интервала k
+func (e *Evaluator) slot(k int64) int {
+	return int((k%int64(e.span) + int64(e.span)) % int64(e.span))
+}
+
+// index возвращает ячейку кольца для интервала start, очищая её, если там лежал более старый интервал.
+// false — интервал уже вышел из самого длинного окна. Вызывается под e.mu
+func (e *Evaluator) index(start time.Time) (int, bool) {
+	k := e.key(start)
+	if k <= e.key(e.last)-int64(e.span) {
+		return 0, false
+	}
+	j := e.slot(k)
+	if e.keys[j] != k {
+		e.keys[j] = k
+		for i := range e.buckets {
+			e.buckets[i][j] = counts{}
+		}
+	}
+	return j, true
+}
+
+// sum — запросы цели i в интервалах, которые начинаются в [end-window, end). Вызывается под e.mu
+func (e *Evaluator) sum(i int, end time.Time, window time.Duration) counts {
+	var c counts
+	if e.origin.IsZero() {
+		return c
+	}
+	to := e.key(end)
+	from := max(e.key(end.Add(-window)), to-int64(e.span))
+	for k := from; k < to; k++ {
+		if j := e.slot(k); e.keys[j] == k {
+			c.total += e.buckets[i][j].total
+			c.bad += e.buckets[i][j].bad
+		}
+	}
+	return c
+}
+
+// burnRate — во сколько раз быстрее допустимого тратится бюджет: доля плохих запросов / (1 − цель)
+func (o Objective) burnRate(c counts) float64 {
+	if c.total == 0 {
+		return 0
+	}
+	return float64(c.bad) / float64(c.total) / (1 - o.Target/100)
+}
+
+// checkAlerts проверяет правила на момент end и возвращает тревоги, которые начались или закончились. Вызывается под e.mu
+func (e *Evaluator) checkAlerts(end time.Time) []Alert {
+	var changed []Alert
+	for i, o := range e.cfg.Objectives {
+		for r, rule := range e.cfg.Alerts {
+			long := o.burnRate(e.sum(i, end, time.Duration(rule.Long)))
+			short := o.burnRate(e.sum(i, end, time.Duration(rule.Short)))
+			current := e.firing[i][r]
+			switch {
+			case long >= rule.Factor && short >= rule.Factor:
+				if current == nil {
+					current = &Alert{SLO: o.Name, Rule: rule, Start: end, ShortBurn: short, Active: true}
+					e.firing[i][r] = current
+					current.End, current.LongBurn = end, long
+					current.Message = current.describe()
+					changed = append(changed, *current)
+				}
+				current.End = end
+				current.LongBurn = max(current.LongBurn, long)
+			case current != nil:
+				current.Active = false
+				current.Message = current.describe()
+				changed = append(changed, *current)
+				e.closed[i] = append(e.closed[i], *current)
+				e.firing[i][r] = nil
+			}
+		}
+	}
+	return changed
+}
+
+// describe — описание тревоги для человека
+func (a Alert) describe() string {
+	state := i18n.T("slo.alert_ended")
+	if a.Active {
+		state = i18n.T("slo.alert_firing")
+	}
+	return i18n.T("slo.alert_message",
+		a.SLO, a.LongBurn, a.Rule.Factor, utilits.FormatDuration(time.Duration(a.Rule.Long)), utilits.FormatDuration(time.Duration(a.Rule.Short)), a.Rule.Severity, state)
+}
+
+// ================================================ Итоги ================================================
+
+// Results возвращает состояние всех целей на момент последней записи и историю тревог
+func (e *Evaluator) Results() []Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	results := make([]Result, len(e.cfg.Objectives))
+	end := e.last.Add(e.res)
+	for i, o := range e.cfg.Objectives {
+		window := time.Duration(o.Window)
+		c := e.sum(i, end, window)
+		r := Result{Objective: o, Total: c.total, Good: c.total - c.bad, Compliance: 100, BudgetRemaining: 1, Met: true}
+		if c.total > 0 {
+			r.Compliance = 100 * float64(r.Good) / float64(c.total)
+			r.BudgetRemaining = 1 - o.burnRate(c)
+			r.Met = r.Compliance >= o.Target
+		}
+		if !e.first.IsZero() {
+			r.Coverage = min(end.Sub(e.first), window)
+		}
+		r.Alerts = e.history(i, end)
+		results[i] = r
+	}
+	return results
+}
+
+// history возвращает закончившиеся тревоги цели i и состояние правил на момент end, как если бы
+// последний интервал уже закончился. Вызывается под e.mu
+func (e *Evaluator) history(i int, end time.Time) []Alert {
+	o := e.cfg.Objectives[i]
+	alerts := append([]Alert(nil), e.closed[i]...)
+	for r, rule := range e.cfg.Alerts {
+		long := o.burnRate(e.sum(i, end, time.Duration(rule.Long)))
+		short := o.burnRate(e.sum(i, end, time.Duration(rule.Short)))
+		firing := long >= rule.Factor && short >= rule.Factor
+
+		var a Alert
+		switch current := e.firing[i][r]; {
+		case current != nil:
+			a = *current
+			a.Active = firing
+			if firing {
+				a.End, a.LongBurn = end, max(a.LongBurn, long)
+			}
+		case firing:
+			a = Alert{SLO: o.Name, Rule: rule, Start: end, End: end, LongBurn: long, ShortBurn: short, Active: true}
+		default:
+			continue
+		}
+		a.Message = a.describe()
+		alerts = append(alerts, a)
+	}
+
+	// Правила проверялись по очереди — упорядочиваем периоды по времени начала
+	for a := 1; a < len(alerts); a++ {
+		for b := a; b > 0 && alerts[b].Start.Before(alerts[b-1].Start); b-- {
+			alerts[b], alerts[b-1] = alerts[b-1], alerts[b]
+		}
+	}
+	return alerts
+}
+
+// Report форматирует раздел отчёта на языке p (nil — язык по умолчанию): соответствие целям, бюджет ошибок и тревоги
+func (e *Evaluator) Report(p *i18n.Printer) string {
+	var sb strings.Builder
+	sb.WriteString(p.Sprintf("slo.title") + "\n")
+	for n, r := range e.Results() {
+		o := r.Objective
+		what := p.Sprintf("slo.availability", o.ErrorStatus)
+		if o.LatencyMs > 0 {
+			what = p.Sprintf("slo.latency", o.LatencyMs)
+		}
+		scope := p.Sprintf("slo.all_requests")
+		if o.Route != "" || o.Method != "" {
+			scope = strings.TrimSpace(o.Method + " " + o.Route)
+		}
+		sb.WriteString(p.Sprintf("slo.objective", n+1, o.Name, scope, what, o.Target, utilits.FormatDuration(time.Duration(o.Window))))
+		if r.Coverage < time.Duration(o.Window) {
+			sb.WriteString(p.Sprintf("slo.coverage", utilits.FormatDuration(r.Coverage)))
+		}
+		sb.WriteString(")")
+
+		if r.Total == 0 {
+			sb.WriteString(p.Sprintf("slo.no_requests") + "\n")
+			continue
+		}
+		state := p.Sprintf("slo.met")
+		if !r.Met {
+			state = p.Sprintf("slo.not_met")
+		}
+		sb.WriteString(p.Sprintf("slo.compliance", r.Compliance, state, r.Good, r.Total))
+		if r.BudgetRemaining >= 0 {
+			sb.WriteString(p.Sprintf("slo.budget_left", 100*r.BudgetRemaining) + "\n")
+		} else {
+			sb.WriteString(p.Sprintf("slo.budget_over", -100*r.BudgetRemaining) + "\n")
+		}
+
+		for _, a := range r.Alerts {
+			sb.WriteString(p.Sprintf("slo.alert",
+				a.Rule.Severity, a.Rule.Factor, utilits.FormatDuration(time.Duration(a.Rule.Long)), utilits.FormatDuration(time.Duration(a.Rule.Short)),
+				a.Start.Format("2006-01-02 15:04"), a.End.Format("2006-01-02 15:04"), a.LongBurn))
+			if a.Active {
+				sb.WriteString(p.Sprintf("slo.alert_active"))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
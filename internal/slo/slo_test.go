@@ -0,0 +1,182 @@
+package slo
+
+import (
+	"strings" // Для проверки отчёта и чтения описаний
+	"testing" // Cтандартная библиотека для тестов Go
+	"time"    // Для работы с датой и временем
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+var base = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+// requests отправляет n запросов за минуту minute, из них bad с ошибкой 503 и временем ответа 1000 мс
+func requests(e *Evaluator, url string, minute, n, bad int) {
+	for i := 0; i < n; i++ {
+		status, latency := 200, 100
+		if i < bad {
+			status, latency = 503, 1000
+		}
+		at := base.Add(time.Duration(minute)*time.Minute + time.Duration(i)*time.Minute/time.Duration(n))
+		e.Observe(model.LogEntry{Timestamp: at, Method: "GET", URL: url, StatusCode: status, ResponseTime: latency})
+	}
+}
+
+func mustParse(t *testing.T, text string) *Config {
+	t.Helper()
+	cfg, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	return cfg
+}
+
+// ================================================ Тесты описания SLO ================================================
+
+func TestParseConfig(t *testing.T) {
+	cfg := mustParse(t, `{"slos": [{"name": "a", "route": "^/api/orders", "method": "post", "target": 99.5, "window": "30d"}]}`)
+	o := cfg.Objectives[0]
+	if o.ErrorStatus != 500 || o.Method != "POST" || time.Duration(o.Window) != 30*24*time.Hour {
+		t.Errorf("Неверно заполнены значения по умолчанию: %+v", o)
+	}
+	if len(cfg.Alerts) != 3 || cfg.Alerts[0].Factor != 14.4 || time.Duration(cfg.Resolution) != time.Minute {
+		t.Errorf("Ожидались правила тревог по умолчанию: %+v", cfg.Alerts)
+	}
+
+	for text, want := range map[string]string{
+		`{"slos": []}`: "нет ни одной цели",
+		`{"slos": [{"name": "a", "target": 100, "window": "1d"}]}`:                                                                  "больше 0 и меньше 100%",
+		`{"slos": [{"name": "a", "target": 99, "window": "1d"}, {"name": "a", "target": 99, "window": "1d"}]}`:                      "повторяется",
+		`{"slos": [{"name": "a", "target": 99}]}`:                                                                                   "не задано окно",
+		`{"slos": [{"name": "a", "target": 99, "window": "1d", "route": "("}]}`:                                                     "неверный шаблон",
+		`{"slos": [{"name": "a", "target": 99, "window": "1d", "windw": "1d"}]}`:                                                    "unknown field",
+		`{"slos": [{"name": "a", "target": 99, "window": "1w"}]}`:                                                                   "неверная длительность",
+		`{"slos": [{"name": "a", "target": 99, "window": "1d"}], "burn_rate_alerts": [{"long": "5m", "short": "1h", "factor": 2}]}`: "long ≥ short",
+	} {
+		if _, err := Parse(strings.NewReader(text)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Для %s ожидалась ошибка с %q, получили %v", text, want, err)
+		}
+	}
+
+	if _, err := Load("../testdata/slo.json"); err != nil {
+		t.Errorf("Пример описания SLO не читается: %v", err)
+	}
+}
+
+// ================================================ Тесты вычисления SLO ================================================
+
+func TestComplianceAndBudget(t *testing.T) {
+	cfg := mustParse(t, `{"slos": [
+		{"name": "orders", "route": "^/api/orders", "target": 99, "window": "1h"},
+		{"name": "orders-latency", "route": "^/api/orders", "target": 90, "window": "30d", "latency_ms": 300},
+		{"name": "post-only", "method": "POST", "target": 99, "window": "1d"}
+	]}`)
+	e := NewEvaluator(cfg, nil)
+
+	requests(e, "/api/orders/1", 0, 100, 5) // Выходит за часовое окно
+	for minute := 60; minute < 70; minute++ {
+		requests(e, "/api/orders/2", minute, 100, 0)
+	}
+	requests(e, "/api/orders/3", 70, 100, 5)
+	requests(e, "/api/users", 70, 100, 50) // Другой маршрут
+
+	results := e.Results()
+	r := results[0]
+	if r.Total != 1100 || r.Good != 1095 || !r.Met {
+		t.Errorf("Неверное соответствие цели: %+v", r)
+	}
+	if r.BudgetRemaining < 0.54 || r.BudgetRemaining > 0.55 { // Допустимо 11 ошибок, потрачено 5
+		t.Errorf("Ожидался остаток бюджета около 55%%, получили %.3f", r.BudgetRemaining)
+	}
+	if r.Coverage != time.Hour {
+		t.Errorf("Ожидалось покрытие 1h, получили %v", r.Coverage)
+	}
+
+	latency := results[1]
+	if latency.Total != 1200 || latency.Good != 1190 || latency.Coverage != 71*time.Minute {
+		t.Errorf("Неверное соответствие цели по задержке: %+v", latency)
+	}
+	if results[2].Total != 0 || !results[2].Met {
+		t.Errorf("Цель без подходящих запросов должна выполняться: %+v", results[2])
+	}
+
+	report := e.Report(nil)
+	for _, want := range []string{
+		"1. orders (^/api/orders; доступность, ошибка — статус от 500; цель 99,00% за 1h): 99,545% — выполняется; хороших 1\u00a0095 из 1\u00a0100, бюджет ошибок: осталось 55%",
+		"2. orders-latency (^/api/orders; задержка до 300 мс; цель 90,00% за 30d, данные за 1h11m)",
+		"3. post-only (POST; доступность, ошибка — статус от 500; цель 99,00% за 1d, данные за 1h11m): запросов нет",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("В отчёте нет %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestBurnRateAlerts(t *testing.T) {
+	cfg := mustParse(t, `{
+		"slos": [{"name": "api", "target": 99, "window": "30d"}],
+		"burn_rate_alerts": [{"long": "1h", "short": "5m", "factor": 14.4}]
+	}`)
+	var live []Alert
+	e := NewEvaluator(cfg, func(a Alert) { live = append(live, a) })
+
+	for minute := 0; minute < 60; minute++ { // Час без ошибок
+		requests(e, "/api", minute, 100, 0)
+	}
+	for minute := 60; minute < 70; minute++ { // 10 минут все запросы с ошибкой
+		requests(e, "/api", minute, 100, 100)
+	}
+	for minute := 70; minute < 90; minute++ { // Снова без ошибок
+		requests(e, "/api", minute, 100, 0)
+	}
+
+	results := e.Results()
+	if results[0].Met || results[0].BudgetRemaining >= 0 {
+		t.Errorf("Ожидалось невыполнение цели и превышение бюджета: %+v", results[0])
+	}
+	alerts := results[0].Alerts
+	if len(alerts) != 1 {
+		t.Fatalf("Ожидался один период тревоги, получили %+v", alerts)
+	}
+	a := alerts[0]
+	// Длинное окно доходит до порога после 9 минут ошибок (900 из 6000 = ×15); короткое гаснет через 5 минут после них
+	if a.Active || !a.Start.Equal(base.Add(69*time.Minute)) || !a.End.Equal(base.Add(74*time.Minute)) || a.LongBurn < 16.6 {
+		t.Errorf("Неверный период тревоги: %+v", a)
+	}
+
+	if len(live) != 2 || !live[0].Active || live[1].Active || !live[0].Start.Equal(a.Start) || !live[1].End.Equal(a.End) {
+		t.Errorf("Обработчик должен получить начало и конец тревоги, получили %+v", live)
+	}
+	if !strings.Contains(e.Report(nil), "тревога page (×14,4 за 1h и 5m): 2024-01-15 11:09 – 2024-01-15 11:14, до ×16,7") {
+		t.Errorf("В отчёте нет тревоги:\n%s", e.Report(nil))
+	}
+}
+
+// Интервалы хранятся только за самое длинное окно: запись из далёкого будущего не раздувает память,
+// а записи старше окна отбрасываются
+func TestBucketsBounded(t *testing.T) {
+	cfg := mustParse(t, `{
+		"slos": [{"name": "api", "target": 99, "window": "1h"}],
+		"burn_rate_alerts": [{"long": "30m", "short": "5m", "factor": 10}]
+	}`)
+	e := NewEvaluator(cfg, nil)
+
+	requests(e, "/api", 10, 100, 0)
+	requests(e, "/api", 9, 100, 50) // Не по порядку, раньше первой записи
+	if r := e.Results()[0]; r.Total != 200 || r.Good != 150 || r.Coverage != 2*time.Minute {
+		t.Errorf("Неверное соответствие цели: %+v", r)
+	}
+
+	requests(e, "/api", 10*365*24*60, 10, 0) // Через 10 лет
+	requests(e, "/api", 10, 100, 100)        // Старше окна — отбрасывается
+	if len(e.buckets[0]) != 61 || len(e.keys) != 61 {
+		t.Errorf("Кольцо должно вмещать 61 интервал, получили %d", len(e.buckets[0]))
+	}
+	if r := e.Results()[0]; r.Total != 10 || r.Good != 10 || r.Coverage != time.Hour {
+		t.Errorf("Старые интервалы не должны попадать в окно: %+v", r)
+	}
+	if alerts := e.Results()[0].Alerts; len(alerts) != 1 || alerts[0].Active ||
+		!alerts[0].Start.Equal(base.Add(11*time.Minute)) || !alerts[0].End.Equal(base.Add(14*time.Minute)) {
+		t.Errorf("Тревога по ошибкам в 10:09 должна закончиться до перерыва: %+v", alerts)
+	}
+}
@@ -0,0 +1,615 @@
+// Пакет tui — полноэкранная панель для режима слежения: обновляется на месте раз в секунду.
+
+package tui
+
+import (
+	"bytes"   // Для разбора сообщений по строкам
+	"context" // Для остановки панели
+	"fmt"     // Для форматирования строк панели
+	"io"      // Для ввода клавиш и вывода кадров
+	"os"      // Для терминала
+	"sort"    // Для сортировки таблиц
+	"strings" // Для сборки кадра и фильтра
+	"sync"    // Для защиты состояния панели
+	"time"    // Для частоты обновления и скорости
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/netaddr"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/processor"
+)
+
+// ================================================ Панель ================================================
+
+// Порядок сортировки маршрутов; переключается клавишей s
+const (
+	SortRequests = iota // больше запросов — выше
+	SortErrors          // больше ошибок — выше
+	SortLatency         // медленнее по p95 — выше
+	SortApdex           // хуже Apdex — выше
+	sortModes
+)
+
+// Options — настройки панели
+type Options struct {
+	Stats      *model.Statistics
+	QueueDepth func() int    // текущая глубина входной очереди; nil — последний замер пула из статистики
+	Title      string        // строка заголовка, например имя файла
+	Refresh    time.Duration // частота обновления (0 — раз в секунду)
+	History    int           // точек в графиках скорости и ошибок (0 — 60)
+	Recent     int           // сколько последних ответов 5xx хранить (0 — 100)
+	Events     int           // сколько последних сообщений хранить (0 — 50)
+	Printer    *i18n.Printer // язык панели (nil — язык по умолчанию, см. i18n.SetDefault)
+}
+
+// Dashboard — панель с графиками скорости и доли ошибок, перцентилями, топами маршрутов и IP,
+// последними ответами 5xx и сообщениями программы. Записи получает как processor.Observer,
+// сообщения — как io.Writer
+type Dashboard struct {
+	opts Options
+
+	mu      sync.Mutex
+	recent  []model.LogEntry // последние ответы 5xx, новые в конце
+	events  []string         // последние сообщения, новые в конце
+	partial []byte           // недописанная строка сообщения
+	closed  io.Writer        // куда идут сообщения после закрытия панели
+	rates   []float64        // запросов в секунду по замерам
+	errors  []float64        // доля ошибок в процентах по замерам
+	last    time.Time        // время прошлого замера
+	total   int              // запросов на момент прошлого замера
+	errs    int              // ошибок на момент прошлого замера
+	snap    snapshot         // данные, которые сейчас на экране
+
+	sortBy  int
+	filter  string
+	editing bool   // вводится фильтр
+	input   []rune // набранный фильтр
+	paused  bool   // экран заморожен, данные продолжают собираться
+}
+
+// snapshot — копия данных для одного кадра, снятая под s.Mu
+type snapshot struct {
+	at                 time.Time
+	total, errors      int
+	p50, p95, p99      float64
+	apdex              float64
+	queue, workers     int
+	rejected           int
+	endpoints          []endpointRow
+	ips                []ipRow
+	recent             []model.LogEntry
+	events             []string
+	rates, errorShares []float64
+}
+
+type endpointRow struct {
+	method, route    string
+	requests, errors int
+	p95, apdex       float64
+}
+
+type ipRow struct {
+	ip       string
+	requests int
+}
+
+// maxSnapshotIPs — сколько самых активных IP попадает в кадр; фильтр применяется к ним
+const maxSnapshotIPs = 500
+
+// New создаёт панель
+func New(opts Options) *Dashboard {
+	if opts.Refresh <= 0 {
+		opts.Refresh = time.Second
+	}
+	if opts.History <= 0 {
+		opts.History = 60
+	}
+	if opts.Recent <= 0 {
+		opts.Recent = 100
+	}
+	if opts.Events <= 0 {
+		opts.Events = 50
+	}
+	return &Dashboard{opts: opts}
+}
+
+// Observe запоминает ответы 5xx для списка последних ошибок
+func (d *Dashboard) Observe(l model.LogEntry) {
+	if l.StatusCode < 500 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.recent = appendLimited(d.recent, l, d.opts.Recent)
+}
+
+// Write добавляет сообщения программы (по одному на строку) в список сообщений панели.
+// После закрытия панели сообщения печатаются в терминал как обычно
+func (d *Dashboard) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed != nil {
+		return d.closed.Write(p)
+	}
+	d.partial = append(d.partial, p...)
+	for {
+		i := bytes.IndexByte(d.partial, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(d.partial[:i])); line != "" {
+			d.events = appendLimited(d.events, line, d.opts.Events)
+		}
+		d.partial = d.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// appendLimited добавляет элемент, оставляя не больше limit последних
+func appendLimited[T any](list []T, v T, limit int) []T {
+	list = append(list, v)
+	if len(list) > limit {
+		list = append(list[:0], list[len(list)-limit:]...)
+	}
+	return list
+}
+
+// sample замеряет скорость и долю ошибок с прошлого замера и, если панель не на паузе, снимает новый кадр
+func (d *Dashboard) sample(now time.Time) {
+	s := d.opts.Stats
+	s.Mu.Lock()
+	total, errs := s.TotalRequests, s.ErrorCount
+	s.Mu.Unlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.last.IsZero() {
+		if elapsed := now.Sub(d.last).Seconds(); elapsed > 0 {
+			d.rates = appendLimited(d.rates, float64(total-d.total)/elapsed, d.opts.History)
+			share := 0.0
+			if n := total - d.total; n > 0 {
+				share = float64(errs-d.errs) * 100 / float64(n)
+			}
+			d.errors = appendLimited(d.errors, share, d.opts.History)
+		}
+	}
+	d.last, d.total, d.errs = now, total, errs
+	if !d.paused {
+		d.snap = d.takeSnapshot(now)
+	}
+}
+
+// takeSnapshot копирует данные для кадра. Вызывается под d.mu
+func (d *Dashboard) takeSnapshot(now time.Time) snapshot {
+	s := d.opts.Stats
+	s.Mu.Lock()
+	snap := snapshot{
+		at:      now,
+		total:   s.TotalRequests,
+		errors:  s.ErrorCount,
+		p50:     s.Latency.Percentile(50),
+		p95:     s.Latency.Percentile(95),
+		p99:     s.Latency.Percentile(99),
+		apdex:   s.Apdex.Score(),
+		queue:   s.QueueDepth,
+		workers: s.ActiveWorkers,
+
+		rejected: s.FilteredCount + s.ParseErrors + s.FailedCount,
+	}
+	for key, e := range s.ByEndpoint {
+		snap.endpoints = append(snap.endpoints, endpointRow{
+			method: key.Method, route: key.Route, requests: e.Requests, errors: e.Errors,
+			p95: e.Latency.Percentile(95), apdex: e.Apdex.Score(),
+		})
+	}
+	for ip, n := range s.RequestsByIP {
+		if d.filter == "" || matches(d.filter, ip) {
+			snap.ips = append(snap.ips, ipRow{ip: ip, requests: n})
+		}
+	}
+	s.Mu.Unlock()
+	if d.opts.QueueDepth != nil { // Фиксированный пул замеряет очередь только при запуске
+		snap.queue = d.opts.QueueDepth()
+	}
+
+	sort.Slice(snap.ips, func(i, j int) bool {
+		if snap.ips[i].requests != snap.ips[j].requests {
+			return snap.ips[i].requests > snap.ips[j].requests
+		}
+		return snap.ips[i].ip < snap.ips[j].ip
+	})
+	if len(snap.ips) > maxSnapshotIPs {
+		snap.ips = snap.ips[:maxSnapshotIPs]
+	}
+	snap.recent = append([]model.LogEntry(nil), d.recent...)
+	snap.events = append([]string(nil), d.events...)
+	snap.rates = append([]float64(nil), d.rates...)
+	snap.errorShares = append([]float64(nil), d.errors...)
+	return snap
+}
+
+// matches — есть ли подстрока filter в одном из полей без учёта регистра
+func matches(filter string, fields ...string) bool {
+	filter = strings.ToLower(filter)
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// ================================================ Клавиши ================================================
+
+// Keys — подсказка по клавишам в нижней строке
+func Keys(p *i18n.Printer) string {
+	return p.Sprintf("tui.keys")
+}
+
+// HandleKey обрабатывает нажатие и сообщает, нужно ли закрыть панель
+func (d *Dashboard) HandleKey(key rune) (quit bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.editing { // Ввод фильтра: Enter применяет, Esc отменяет, Backspace стирает
+		switch key {
+		case '\r', '\n':
+			d.filter, d.editing = strings.TrimSpace(string(d.input)), false
+			d.refilter()
+		case 27:
+			d.editing = false
+		case 127, 8:
+			if len(d.input) > 0 {
+				d.input = d.input[:len(d.input)-1]
+			}
+		default:
+			if key >= ' ' {
+				d.input = append(d.input, key)
+			}
+		}
+		return false
+	}
+
+	switch key {
+	case 'q', 'Q', 3: // 3 — Ctrl+C, если сигналы отключены
+		return true
+	case 's', 'S':
+		d.sortBy = (d.sortBy + 1) % sortModes
+	case '/', 'f':
+		d.editing, d.input = true, []rune(d.filter)
+	case 27:
+		d.filter = ""
+		d.refilter()
+	case 'p', 'P', ' ':
+		d.paused = !d.paused
+		if !d.paused {
+			d.snap = d.takeSnapshot(time.Now())
+		}
+	}
+	return false
+}
+
+// refilter снимает кадр заново, чтобы топ IP учёл новый фильтр. На паузе фильтруется замороженный кадр.
+// Вызывается под d.mu
+func (d *Dashboard) refilter() {
+	if !d.paused && d.opts.Stats != nil {
+		d.snap = d.takeSnapshot(time.Now())
+	}
+}
+
+// ================================================ Кадр ================================================
+
+// Render рисует кадр заданного размера: строки обрезаются по ширине, таблицы делят оставшуюся высоту
+func (d *Dashboard) Render(width, height int) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	width, height = max(width, 20), max(height, 10)
+	snap, p := d.snap, d.opts.Printer
+	var lines []string
+	add := func(line string) { lines = append(lines, line) }
+
+	status := snap.at.Format("15:04:05")
+	if d.paused {
+		status = p.Sprintf("tui.paused", status)
+	}
+	add(spread("Go-Log-Processor · "+d.opts.Title, status, width))
+	errorShare := 0.0
+	if snap.total > 0 {
+		errorShare = float64(snap.errors) * 100 / float64(snap.total)
+	}
+	add(p.Sprintf("tui.totals", snap.total, snap.errors, errorShare, snap.rejected, snap.queue, snap.workers))
+	add(p.Sprintf("tui.latency", snap.p50, snap.p95, snap.p99, snap.apdex))
+	sparkWidth := max(width-28, 10)
+	add(p.Sprintf("tui.rate", sparkline(snap.rates, sparkWidth), last(snap.rates)))
+	add(p.Sprintf("tui.errors", sparkline(snap.errorShares, sparkWidth), last(snap.errorShares)))
+	add("")
+
+	footer := Keys(p)
+	if d.editing {
+		footer = p.Sprintf("tui.filter_editing", string(d.input))
+	} else if d.filter != "" {
+		footer = p.Sprintf("tui.filter", d.filter, Keys(p))
+	}
+
+	// Оставшаяся высота: маршруты и IP рядом (или друг под другом на узком экране), затем 5xx и сообщения
+	free := height - len(lines) - 1
+	tableRows := max(free*45/100-2, 3)
+	routes := d.routeTable(snap, tableRows)
+	ips := ipTable(p, snap, d.filter, tableRows)
+	if width >= 110 {
+		lines = append(lines, sideBySide(routes, ips, width-34, 2)...)
+	} else {
+		lines = append(lines, routes...)
+		lines = append(lines, ips...)
+	}
+	lines = append(lines, "")
+
+	free = height - len(lines) - 1
+	recentRows := max(free*60/100-1, 2)
+	add(p.Sprintf("tui.recent"))
+	var recent []string
+	for i := len(snap.recent) - 1; i >= 0 && len(recent) < recentRows; i-- {
+		l := snap.recent[i]
+		if d.filter != "" && !matches(d.filter, l.URL, l.Method, netaddr.ClientIP(l)) {
+			continue
+		}
+		recent = append(recent, p.Sprintf("tui.recent_row",
+			l.Timestamp.Format("2006-01-02 15:04:05"), l.StatusCode, l.Method, l.URL, l.ResponseTime, netaddr.ClientIP(l)))
+	}
+	if len(recent) == 0 {
+		recent = append(recent, p.Sprintf("tui.none"))
+	}
+	lines = append(lines, recent...)
+
+	free = height - len(lines) - 2
+	if free > 0 && len(snap.events) > 0 {
+		add(p.Sprintf("tui.events"))
+		events := snap.events
+		if len(events) > free {
+			events = events[len(events)-free:]
+		}
+		for _, e := range events {
+			add("  " + e)
+		}
+	}
+
+	for len(lines) < height-1 { // Нижняя строка — всегда у края экрана
+		lines = append(lines, "")
+	}
+	lines = append(lines[:height-1], footer)
+	for i, l := range lines {
+		lines[i] = truncate(l, width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// routeTable — строки таблицы маршрутов с учётом сортировки и фильтра. Вызывается под d.mu
+func (d *Dashboard) routeTable(snap snapshot, rows int) []string {
+	var list []endpointRow
+	for _, e := range snap.endpoints {
+		if d.filter == "" || matches(d.filter, e.method+" "+e.route) {
+			list = append(list, e)
+		}
+	}
+	sortBy := d.sortBy
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		switch {
+		case sortBy == SortErrors && a.errors != b.errors:
+			return a.errors > b.errors
+		case sortBy == SortLatency && a.p95 != b.p95:
+			return a.p95 > b.p95
+		case sortBy == SortApdex && a.apdex != b.apdex:
+			return a.apdex < b.apdex
+		case a.requests != b.requests:
+			return a.requests > b.requests
+		}
+		return a.method+a.route < b.method+b.route
+	})
+
+	p := d.opts.Printer
+	sortNames := [sortModes]string{p.Sprintf("tui.sort.requests"), p.Sprintf("tui.sort.errors"), p.Sprintf("tui.sort.latency"), p.Sprintf("tui.sort.apdex")}
+	table := []string{
+		p.Sprintf("tui.routes", sortNames[sortBy], len(list)),
+		fmt.Sprintf("  %-6s %-36s %9s %7s %8s %6s", p.Sprintf("tui.column.method"), p.Sprintf("tui.column.route"),
+			p.Sprintf("tui.column.requests"), p.Sprintf("tui.column.errors"), p.Sprintf("tui.column.p95"), "Apdex"),
+	}
+	for i, e := range list {
+		if i == rows {
+			break
+		}
+		table = append(table, p.Sprintf("tui.route_row", e.method, truncate(e.route, 36), e.requests, e.errors, e.p95, e.apdex))
+	}
+	return table
+}
+
+// ipTable — строки таблицы самых активных IP с учётом фильтра
+func ipTable(p *i18n.Printer, snap snapshot, filter string, rows int) []string {
+	table := []string{p.Sprintf("tui.top_ips"), fmt.Sprintf("  %-20s %9s", "IP", p.Sprintf("tui.column.requests"))}
+	for _, row := range snap.ips {
+		if len(table)-2 == rows {
+			break
+		}
+		if filter == "" || matches(filter, row.ip) {
+			table = append(table, p.Sprintf("tui.ip_row", truncate(row.ip, 20), row.requests))
+		}
+	}
+	return table
+}
+
+// ================================================ Запуск ================================================
+
+// Run показывает панель в out, пока не отменён ctx или не нажата q. Клавиши читаются из in; если in —
+// терминал, он переводится в посимвольный режим. Размер экрана берётся у out, если это терминал
+func (d *Dashboard) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	if f, ok := in.(*os.File); ok {
+		if restore, err := makeRaw(int(f.Fd())); err == nil {
+			defer restore()
+		}
+	}
+	size := func() (int, int) {
+		if f, ok := out.(*os.File); ok {
+			if w, h, ok := terminalSize(int(f.Fd())); ok {
+				return w, h
+			}
+		}
+		return 120, 40
+	}
+
+	defer d.detach(out)
+	// Альтернативный экран и скрытый курсор; при выходе терминал возвращается как был
+	io.WriteString(out, "\x1b[?1049h\x1b[?25l")
+	defer io.WriteString(out, "\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan rune)
+	go readKeys(in, keys)
+
+	draw := func() error {
+		w, h := size()
+		frame := strings.ReplaceAll(d.Render(w, h), "\n", "\x1b[K\r\n")
+		_, err := io.WriteString(out, "\x1b[H"+frame+"\x1b[K\x1b[J")
+		return err
+	}
+	d.sample(time.Now())
+	ticker := time.NewTicker(d.opts.Refresh)
+	defer ticker.Stop()
+	for {
+		if err := draw(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			d.sample(now)
+		case key, ok := <-keys:
+			if !ok { // Ввод закончился: панель работает до отмены ctx
+				keys = nil
+				continue
+			}
+			if d.HandleKey(key) {
+				return nil
+			}
+		}
+	}
+}
+
+// detach направляет дальнейшие сообщения в out, начиная с недописанной строки
+func (d *Dashboard) detach(out io.Writer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out.Write(d.partial)
+	d.partial, d.closed = nil, out
+}
+
+// readKeys читает нажатия и закрывает keys, когда ввод закончился. Последовательности клавиш
+// со стрелками (ESC [ ...) пропускаются, одиночный ESC передаётся как есть
+func readKeys(in io.Reader, keys chan<- rune) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			data := []rune(string(buf[:n]))
+			for i := 0; i < len(data); i++ {
+				if data[i] == 27 && i+1 < len(data) && (data[i+1] == '[' || data[i+1] == 'O') {
+					i += 2 // ESC [ и код клавиши
+					for i < len(data) && (data[i] < '@' || data[i] > '~') {
+						i++
+					}
+					continue
+				}
+				keys <- data[i]
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ================================================ Отрисовка ================================================
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline — график последних width значений, масштабированный по максимуму
+func sparkline(values []float64, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	peak := 0.0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+	out := make([]rune, 0, width)
+	for i := len(values); i < width; i++ { // Пока замеров мало, график дополняется слева пробелами
+		out = append(out, ' ')
+	}
+	for _, v := range values {
+		level := 0
+		if peak > 0 {
+			level = int(v / peak * float64(len(sparkBars)-1))
+		}
+		out = append(out, sparkBars[level])
+	}
+	return string(out)
+}
+
+// last — последнее значение или 0
+func last(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return values[len(values)-1]
+}
+
+// truncate обрезает строку до width символов
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(r[:width])
+	}
+	return string(r[:width-1]) + "…"
+}
+
+// pad дополняет строку пробелами до width символов
+func pad(s string, width int) string {
+	s = truncate(s, width)
+	return s + strings.Repeat(" ", width-len([]rune(s)))
+}
+
+// spread ставит left в начало строки, а right — в конец
+func spread(left, right string, width int) string {
+	gap := width - len([]rune(left)) - len([]rune(right))
+	if gap < 1 {
+		return truncate(left+" "+right, width)
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
+
+// sideBySide выводит две колонки рядом: левая шириной leftWidth, между ними gap пробелов
+func sideBySide(left, right []string, leftWidth, gap int) []string {
+	var lines []string
+	for i := 0; i < max(len(left), len(right)); i++ {
+		var l, r string
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		lines = append(lines, pad(l, leftWidth)+strings.Repeat(" ", gap)+r)
+	}
+	return lines
+}
+
+// processor.Observer — панель получает записи через processor.ObserveStage
+var _ processor.Observer = (*Dashboard)(nil)
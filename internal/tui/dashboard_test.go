@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"context" // Для остановки панели
+	"fmt"     // Для сообщений
+	"strings" // Для проверки кадра
+	"testing" // Cтандартная библиотека для тестов Go
+	"time"    // Для работы с датой и временем
+	"unicode" // Для поиска кириллицы в английском кадре
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/processor"
+)
+
+// newDashboard создаёт панель с несколькими записями
+func newDashboard(t *testing.T) *Dashboard {
+	t.Helper()
+	stats := &model.Statistics{RequestsByIP: make(map[string]int), ApdexT: 100}
+	d := New(Options{Stats: stats, Title: "access.log"})
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for i, l := range []model.LogEntry{
+		{IP: "10.0.0.1", Method: "GET", URL: "/api/users/1", StatusCode: 200, ResponseTime: 20},
+		{IP: "10.0.0.1", Method: "GET", URL: "/api/users/2", StatusCode: 200, ResponseTime: 30},
+		{IP: "10.0.0.1", Method: "GET", URL: "/api/users/3", StatusCode: 200, ResponseTime: 40},
+		{IP: "10.0.0.2", Method: "POST", URL: "/api/orders", StatusCode: 503, ResponseTime: 900},
+		{IP: "10.0.0.2", Method: "POST", URL: "/api/orders", StatusCode: 500, ResponseTime: 700},
+	} {
+		l.Timestamp = start.Add(time.Duration(i) * time.Second)
+		processor.UpdateStatistics(stats, l)
+		d.Observe(l)
+	}
+	return d
+}
+
+// routeOrder — маршруты в порядке строк кадра
+func routeOrder(frame string) []string {
+	var routes []string
+	for _, line := range strings.Split(frame, "\n") {
+		if f := strings.Fields(line); len(f) > 1 && (f[0] == "GET" || f[0] == "POST") {
+			routes = append(routes, f[1])
+		}
+	}
+	return routes
+}
+
+// ================================================ Тесты панели ================================================
+
+func TestDashboardRender(t *testing.T) {
+	d := newDashboard(t)
+	now := time.Now()
+	d.sample(now)
+	d.opts.Stats.TotalRequests += 10 // Ещё 10 запросов за две секунды
+	fmt.Fprint(d, "Алерт: много ошибок\nнеполная ")
+	d.sample(now.Add(2 * time.Second))
+
+	frame := d.Render(120, 40)
+	lines := strings.Split(frame, "\n")
+	if len(lines) != 40 {
+		t.Fatalf("Ожидалось 40 строк, получили %d", len(lines))
+	}
+	for i, l := range lines {
+		if n := len([]rune(l)); n > 120 {
+			t.Errorf("Строка %d длиннее экрана (%d): %q", i, n, l)
+		}
+	}
+	for _, want := range []string{
+		"access.log", "Запросов: 15", "p95", "Apdex", "5,0/с",
+		"Маршруты (сортировка: запросы", "10.0.0.1", "503 POST   /api/orders", "Алерт: много ошибок", Keys(nil),
+	} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("В кадре нет %q:\n%s", want, frame)
+		}
+	}
+	if strings.Contains(frame, "неполная") {
+		t.Error("Недописанная строка сообщения не должна показываться")
+	}
+	if got := routeOrder(frame); len(got) != 2 || got[0] != "/api/users/:id" {
+		t.Errorf("По умолчанию маршруты сортируются по запросам: %v", got)
+	}
+
+	if d.Render(20, 5) == "" { // Крошечный экран не должен ломать отрисовку
+		t.Error("Пустой кадр на маленьком экране")
+	}
+}
+
+func TestDashboardEnglish(t *testing.T) {
+	d := newDashboard(t)
+	d.opts.Printer = i18n.New(i18n.EN)
+	d.opts.Stats.TotalRequests = 12345
+	d.sample(time.Now())
+	d.HandleKey('p')
+
+	frame := d.Render(120, 40)
+	for _, want := range []string{
+		"PAUSED", "Requests: 12,345 · errors: 2", "Response time: p50", "Routes (sorted by requests, 2 in total)",
+		"Most active IPs", "Recent 5xx responses", "· 900 ms · 10.0.0.2", Keys(d.opts.Printer),
+	} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("В кадре нет %q:\n%s", want, frame)
+		}
+	}
+	for _, r := range frame {
+		if unicode.Is(unicode.Cyrillic, r) {
+			t.Fatalf("В английском кадре остался русский текст:\n%s", frame)
+		}
+	}
+}
+
+func TestDashboardKeys(t *testing.T) {
+	d := newDashboard(t)
+	d.sample(time.Now())
+
+	d.HandleKey('s') // Сортировка по ошибкам
+	if got := routeOrder(d.Render(120, 40)); len(got) != 2 || got[0] != "/api/orders" {
+		t.Errorf("После s маршруты сортируются по ошибкам: %v", got)
+	}
+
+	for _, key := range "/orderx" {
+		d.HandleKey(key)
+	}
+	d.HandleKey(127) // Backspace
+	if frame := d.Render(120, 40); !strings.Contains(frame, "Фильтр: order▏") {
+		t.Errorf("Во время ввода показывается набранный фильтр:\n%s", frame)
+	}
+	d.HandleKey('\r')
+	frame := d.Render(120, 40)
+	if got := routeOrder(frame); len(got) != 1 || got[0] != "/api/orders" {
+		t.Errorf("Фильтр должен оставить один маршрут: %v", got)
+	}
+	if strings.Contains(frame, "10.0.0.1") {
+		t.Error("Фильтр применяется и к IP")
+	}
+
+	d.HandleKey(27) // Esc сбрасывает фильтр
+	d.HandleKey('p')
+	d.opts.Stats.TotalRequests = 1000
+	d.sample(time.Now())
+	if frame := d.Render(120, 40); !strings.Contains(frame, "ПАУЗА") || strings.Contains(frame, "Запросов: 1\u00a0000") {
+		t.Errorf("На паузе кадр не обновляется:\n%s", frame)
+	}
+	d.HandleKey('p')
+	if frame := d.Render(120, 40); !strings.Contains(frame, "Запросов: 1\u00a0000") || !strings.Contains(frame, "10.0.0.1") {
+		t.Errorf("После паузы кадр обновляется:\n%s", frame)
+	}
+
+	if d.HandleKey('x') || !d.HandleKey('q') {
+		t.Error("Выход только по q")
+	}
+}
+
+func TestDashboardRun(t *testing.T) {
+	d := newDashboard(t)
+	var out strings.Builder
+	// Стрелка вверх пропускается, q закрывает панель
+	if err := d.Run(context.Background(), strings.NewReader("\x1b[Aq"), &out); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if s := out.String(); !strings.HasPrefix(s, "\x1b[?1049h") || !strings.HasSuffix(s, "\x1b[?1049l") || !strings.Contains(s, "Запросов: 5") {
+		t.Errorf("Неверный вывод панели: %q", s)
+	}
+	fmt.Fprintln(d, "После выхода")
+	if !strings.HasSuffix(out.String(), "\x1b[?1049lПосле выхода\n") {
+		t.Error("После закрытия панели сообщения идут в терминал")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := New(Options{Stats: d.opts.Stats}).Run(ctx, strings.NewReader(""), &out); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+}
+
+func TestDashboardLiveQueue(t *testing.T) {
+	stats := &model.Statistics{RequestsByIP: make(map[string]int), QueueDepth: 0, ActiveWorkers: 5} // Замер при запуске пула
+	queue := make(chan model.LogEntry, 100)
+	d := New(Options{Stats: stats, QueueDepth: func() int { return len(queue) }})
+	for i := 0; i < 42; i++ {
+		queue <- model.LogEntry{}
+	}
+	d.sample(time.Now())
+	if frame := d.Render(120, 40); !strings.Contains(frame, "очередь: 42 · воркеров: 5") {
+		t.Errorf("Панель должна показывать текущую очередь:\n%s", frame)
+	}
+}
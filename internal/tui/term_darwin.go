@@ -0,0 +1,8 @@
+package tui
+
+import "syscall" // Для кодов ioctl
+
+const (
+	ioctlGetTermios = syscall.TIOCGETA
+	ioctlSetTermios = syscall.TIOCSETA
+)
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package tui
+
+import "github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+
+// makeRaw недоступен: клавиши читаются после нажатия Enter
+func makeRaw(fd int) (func(), error) {
+	return nil, i18n.Errorf("tui.raw_unsupported")
+}
+
+// terminalSize недоступен: используется размер по умолчанию
+func terminalSize(fd int) (int, int, bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,162 @@
+// Разбор заголовка User-Agent: браузер, ОС, тип устройства и признак бота.
+// Правила — регулярные выражения из встроенного файла rules.json; первое совпавшее правило побеждает.
+
+package useragent
+
+import (
+	"bytes"         // Для чтения встроенных правил
+	_ "embed"       // Для встраивания набора правил в бинарник
+	"encoding/json" // Для разбора файла правил
+	"io"            // Для чтения правил из произвольного источника
+	"regexp"        // Для шаблонов правил
+	"strings"       // Для работы со строками
+	"sync"          // Для кэша и однократной загрузки правил
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Правила ================================================
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Rule — правило распознавания: имя, регулярное выражение и (для ботов) категория.
+// Для браузеров первая группа выражения — основная версия
+type Rule struct {
+	Name     string `json:"name"`
+	Pattern  string `json:"pattern"`
+	Category string `json:"category,omitempty"`
+}
+
+// RuleSet — полный набор правил
+type RuleSet struct {
+	Bots     []Rule `json:"bots"`
+	Browsers []Rule `json:"browsers"`
+	OS       []Rule `json:"os"`
+	Devices  []Rule `json:"devices"`
+}
+
+type compiledRule struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// OtherBot — имя правила в rules.json для ботов, не узнанных по имени. Как и model.FamilyOther,
+// это идентификатор: при выводе он переводится на язык вывода
+const OtherBot = "other-bot"
+
+// cacheSize — сколько разных User-Agent помнит Parser; при переполнении кэш очищается целиком
+const cacheSize = 4096
+
+// Parser разбирает User-Agent по набору правил. Безопасен для параллельного использования
+type Parser struct {
+	bots, browsers, os, devices []compiledRule
+
+	mu    sync.Mutex
+	cache map[string]model.ClientInfo
+}
+
+// Load читает набор правил в формате JSON (как встроенный rules.json)
+func Load(r io.Reader) (*Parser, error) {
+	var rules RuleSet
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, i18n.Errorf("useragent.read", err)
+	}
+	return New(rules)
+}
+
+// New компилирует набор правил
+func New(rules RuleSet) (*Parser, error) {
+	p := &Parser{cache: make(map[string]model.ClientInfo)}
+	for _, group := range []struct {
+		rules []Rule
+		dst   *[]compiledRule
+	}{{rules.Bots, &p.bots}, {rules.Browsers, &p.browsers}, {rules.OS, &p.os}, {rules.Devices, &p.devices}} {
+		for _, rule := range group.rules {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, i18n.Errorf("useragent.bad_pattern", rule.Name, err)
+			}
+			*group.dst = append(*group.dst, compiledRule{rule, re})
+		}
+	}
+	return p, nil
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultParser *Parser
+)
+
+// Default возвращает разборщик со встроенным набором правил
+func Default() *Parser {
+	defaultOnce.Do(func() {
+		p, err := Load(bytes.NewReader(defaultRules))
+		if err != nil { // Встроенные правила проверяются тестами, ошибка здесь — ошибка сборки
+			panic(err)
+		}
+		defaultParser = p
+	})
+	return defaultParser
+}
+
+// Parse разбирает User-Agent встроенными правилами
+func Parse(ua string) model.ClientInfo {
+	return Default().Parse(ua)
+}
+
+// ================================================ Разбор ================================================
+
+// Parse разбирает User-Agent. Пустой заголовок (или «-») даёт пустой ClientInfo: клиент неизвестен
+func (p *Parser) Parse(ua string) model.ClientInfo {
+	ua = strings.TrimSpace(ua)
+	if ua == "" || ua == "-" {
+		return model.ClientInfo{}
+	}
+
+	p.mu.Lock()
+	info, ok := p.cache[ua]
+	p.mu.Unlock()
+	if ok {
+		return info
+	}
+
+	if rule, _ := match(p.bots, ua); rule != nil {
+		info.Bot, info.BotName, info.BotCategory, info.Device = true, rule.Name, rule.Category, "bot"
+	}
+	if rule, groups := match(p.browsers, ua); rule != nil {
+		info.Browser = rule.Name
+		if len(groups) > 1 {
+			info.Version = groups[1]
+		}
+	}
+	if rule, _ := match(p.os, ua); rule != nil {
+		info.OS = rule.Name
+	}
+	if !info.Bot {
+		if rule, _ := match(p.devices, ua); rule != nil {
+			info.Device = rule.Name
+		} else if info.Browser != "" || info.OS != "" { // Узнали браузер или ОС, но не мобильное устройство
+			info.Device = "desktop"
+		}
+	}
+
+	p.mu.Lock()
+	if len(p.cache) >= cacheSize {
+		p.cache = make(map[string]model.ClientInfo)
+	}
+	p.cache[ua] = info
+	p.mu.Unlock()
+	return info
+}
+
+// match возвращает первое совпавшее правило и группы совпадения
+func match(rules []compiledRule, ua string) (*compiledRule, []string) {
+	for i := range rules {
+		if groups := rules[i].re.FindStringSubmatch(ua); groups != nil {
+			return &rules[i], groups
+		}
+	}
+	return nil, nil
+}
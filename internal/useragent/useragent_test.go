@@ -0,0 +1,116 @@
+package useragent
+
+import (
+	"strings" // Для правил из строки
+	"testing" // Cтандартная библиотека для тестов Go
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/model"
+)
+
+// ================================================ Тесты разбора ================================================
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		ua       string
+		expected model.ClientInfo
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			model.ClientInfo{Browser: "Chrome", Version: "120", OS: "Windows", Device: "desktop"},
+		},
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			model.ClientInfo{Browser: "Edge", Version: "120", OS: "Windows", Device: "desktop"},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			model.ClientInfo{Browser: "Safari", Version: "17", OS: "iOS", Device: "mobile"},
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+			model.ClientInfo{Browser: "Safari", Version: "17", OS: "macOS", Device: "desktop"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.6045.163 Mobile Safari/537.36",
+			model.ClientInfo{Browser: "Chrome", Version: "119", OS: "Android", Device: "mobile"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+			model.ClientInfo{Browser: "Chrome", Version: "119", OS: "Android", Device: "tablet"},
+		},
+		{
+			"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			model.ClientInfo{Browser: "Firefox", Version: "121", OS: "Linux", Device: "desktop"},
+		},
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 YaBrowser/24.1.0.0 Safari/537.36",
+			model.ClientInfo{Browser: "Yandex Browser", Version: "24", OS: "Windows", Device: "desktop"},
+		},
+		{
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			model.ClientInfo{Device: "bot", Bot: true, BotName: "Googlebot", BotCategory: "crawler"},
+		},
+		{
+			"curl/8.4.0",
+			model.ClientInfo{Device: "bot", Bot: true, BotName: "curl", BotCategory: "tool"},
+		},
+		{
+			"python-requests/2.31.0",
+			model.ClientInfo{Device: "bot", Bot: true, BotName: "python-requests", BotCategory: "tool"},
+		},
+		{
+			"sqlmap/1.7.2#stable (https://sqlmap.org)",
+			model.ClientInfo{Device: "bot", Bot: true, BotName: "sqlmap", BotCategory: "scanner"},
+		},
+		{
+			"Mozilla/5.0 (compatible; Nmap Scripting Engine; https://nmap.org/book/nse.html)",
+			model.ClientInfo{Device: "bot", Bot: true, BotName: "Nmap", BotCategory: "scanner"},
+		},
+		{
+			"SomeCustomCrawler/1.0",
+			model.ClientInfo{Device: "bot", Bot: true, BotName: OtherBot, BotCategory: "crawler"},
+		},
+		{"-", model.ClientInfo{}},
+		{"", model.ClientInfo{}},
+		{"нечто непонятное", model.ClientInfo{}},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 2; i++ { // Второй раз — из кэша
+			if got := Parse(tt.ua); got != tt.expected {
+				t.Errorf("Parse(%q):\nполучили %+v\nожидалось %+v", tt.ua, got, tt.expected)
+			}
+		}
+	}
+}
+
+func TestFamily(t *testing.T) {
+	if f := Parse("curl/8.4.0").Family(); f != "curl" {
+		t.Errorf("Семейство бота: %q", f)
+	}
+	if f := Parse("Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0").Family(); f != "Firefox" {
+		t.Errorf("Семейство браузера: %q", f)
+	}
+	if f := Parse("нечто").Family(); f != model.FamilyOther {
+		t.Errorf("Семейство неизвестного клиента: %q", f)
+	}
+}
+
+// ================================================ Тесты правил ================================================
+
+func TestLoadRules(t *testing.T) {
+	p, err := Load(strings.NewReader(`{"bots": [{"name": "Внутренний", "pattern": "^internal-checker", "category": "monitoring"}]}`))
+	if err != nil {
+		t.Fatalf("Load вернул ошибку: %v", err)
+	}
+	if info := p.Parse("internal-checker/1.0"); !info.Bot || info.BotCategory != "monitoring" {
+		t.Errorf("Собственное правило не сработало: %+v", info)
+	}
+
+	if _, err := Load(strings.NewReader(`{"bots": [{"name": "плохой", "pattern": "("}]}`)); err == nil {
+		t.Errorf("Ожидалась ошибка для неверного шаблона")
+	}
+	if _, err := Load(strings.NewReader(`не json`)); err == nil {
+		t.Errorf("Ожидалась ошибка для неверного JSON")
+	}
+}
@@ -0,0 +1,71 @@
+package utilits
+
+import (
+	"encoding/json" // Для разбора длительностей из JSON
+	"fmt"           // Для печати длительностей
+	"strconv"       // Для разбора дней
+	"strings"       // Для суффикса дней
+	"time"          // Для работы с длительностями
+
+	"github.com/Evgenymoshrage/Go-Log-Processor/internal/i18n"
+)
+
+// ================================================ Длительности в файлах настроек ================================================
+
+// Duration — длительность в JSON: строка в формате time.ParseDuration с дополнительным суффиксом d (дни), например "30d"
+type Duration time.Duration
+
+// UnmarshalJSON разбирает строку длительности
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return i18n.Errorf("duration.not_string", data)
+	}
+	parsed, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ParseDuration разбирает длительность вида "30d", "6h", "5m" или "1d12h"
+func ParseDuration(s string) (time.Duration, error) {
+	var days time.Duration
+	if i := strings.IndexByte(s, 'd'); i >= 0 {
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, i18n.Errorf("duration.bad", s)
+		}
+		days, s = time.Duration(n)*24*time.Hour, s[i+1:]
+		if s == "" {
+			return days, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, i18n.Errorf("duration.bad", s)
+	}
+	return days + d, nil
+}
+
+// FormatDuration печатает длительность коротко: 30d, 6h, 1h30m, 5m
+func FormatDuration(d time.Duration) string {
+	var sb strings.Builder
+	if days := d / (24 * time.Hour); days > 0 {
+		fmt.Fprintf(&sb, "%dd", days)
+		d -= days * 24 * time.Hour
+	}
+	if h := d / time.Hour; h > 0 {
+		fmt.Fprintf(&sb, "%dh", h)
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		fmt.Fprintf(&sb, "%dm", m)
+		d -= m * time.Minute
+	}
+	if d > 0 || sb.Len() == 0 {
+		sb.WriteString(d.String())
+	}
+	return sb.String()
+}
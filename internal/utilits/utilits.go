@@ -2,6 +2,7 @@ package utilits
 
 import (
 	"fmt"          // Для вывода в консоль
+	"math"         // Для округления при расчёте перцентилей
 	"strings"      // Для работы со строками - Repeat
 	"unicode/utf8" // Чтобы корректно считать количество символов в UTF-8
 
@@ -34,3 +35,61 @@ func PrintCentered(title string, width int) {
 	fmt.Printf("%s%s\n", strings.Repeat(" ", padding), title) // Печатаем сам заголовок с отступом слева
 	fmt.Println(line)                                         //  Печатаем нижнюю линию
 }
+
+// ================================================ Нормализация маршрутов ================================================
+
+// NormalizeRoute превращает URL в шаблон маршрута: отбрасывает query-строку и заменяет
+// идентификаторы в пути (числа, UUID, длинные hex-строки) на ":id", например
+// "/api/users/123?full=1" → "/api/users/:id". Так запросы к одному обработчику считаются вместе
+func NormalizeRoute(url string) string {
+	if i := strings.IndexAny(url, "?#"); i >= 0 { // Параметры запроса на маршрут не влияют
+		url = url[:i]
+	}
+
+	segments := strings.Split(url, "/")
+	for i, seg := range segments {
+		if isIDSegment(seg) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isIDSegment — похож ли сегмент пути на идентификатор
+func isIDSegment(seg string) bool {
+	if seg == "" {
+		return false
+	}
+
+	digits, hex := true, true
+	for _, c := range seg {
+		isDigit := c >= '0' && c <= '9'
+		isHex := isDigit || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') || c == '-'
+		digits = digits && isDigit
+		hex = hex && isHex
+	}
+	// Число, UUID (36 символов с дефисами) или длинный hex-хеш
+	return digits || (hex && len(seg) >= 16 && strings.ContainsAny(seg, "0123456789"))
+}
+
+// ================================================ Перцентили ================================================
+
+// Percentile возвращает p-й перцентиль (0–100) отсортированного по возрастанию среза
+// с линейной интерполяцией между соседними значениями. Для пустого среза — 0
+func Percentile(sorted []int, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	if p <= 0 {
+		return float64(sorted[0])
+	}
+	if p >= 100 {
+		return float64(sorted[len(sorted)-1])
+	}
+
+	rank := p / 100 * float64(len(sorted)-1) // Позиция перцентиля между индексами
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	frac := rank - float64(lower)
+	return float64(sorted[lower]) + (float64(sorted[upper])-float64(sorted[lower]))*frac
+}
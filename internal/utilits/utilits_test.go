@@ -31,3 +31,61 @@ func TestLogEntryToString(t *testing.T) {
 		t.Errorf("Ожидалось:\n%s\nПолучено:\n%s", expected, got)
 	}
 }
+
+// ================================================ Тест NormalizeRoute ================================================
+
+func TestNormalizeRoute(t *testing.T) {
+	tests := map[string]string{
+		"/api/users":         "/api/users",
+		"/api/users/123":     "/api/users/:id",
+		"/api/users/123?x=1": "/api/users/:id",
+		"/api/orders/550e8400-e29b-41d4-a716-446655440000/items": "/api/orders/:id/items",
+		"/files/deadbeefdeadbeef0123":                            "/files/:id",
+		"/api/v2/cafe":                                           "/api/v2/cafe",
+		"/":                                                      "/",
+	}
+
+	for url, expected := range tests {
+		if got := NormalizeRoute(url); got != expected {
+			t.Errorf("NormalizeRoute(%q): ожидалось %q, получено %q", url, expected, got)
+		}
+	}
+}
+
+// ================================================ Тест Percentile ================================================
+
+func TestPercentile(t *testing.T) {
+	values := []int{10, 20, 30, 40, 50}
+
+	tests := map[float64]float64{0: 10, 50: 30, 95: 48, 100: 50, 25: 20}
+	for p, expected := range tests {
+		if got := Percentile(values, p); got != expected {
+			t.Errorf("Percentile(%v): ожидалось %v, получено %v", p, expected, got)
+		}
+	}
+
+	if got := Percentile(nil, 95); got != 0 {
+		t.Errorf("Для пустого среза ожидался 0, получено %v", got)
+	}
+}
+
+// ================================================ Тест длительностей ================================================
+
+func TestParseDuration(t *testing.T) {
+	for text, want := range map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour, "1d12h": 36 * time.Hour, "5m": 5 * time.Minute, "1h30m": 90 * time.Minute,
+	} {
+		got, err := ParseDuration(text)
+		if err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %v, %v; ожидалось %v", text, got, err, want)
+		}
+		if FormatDuration(got) != text {
+			t.Errorf("FormatDuration(%v) = %q, ожидалось %q", got, FormatDuration(got), text)
+		}
+	}
+	for _, text := range []string{"", "d", "xd", "30x"} {
+		if _, err := ParseDuration(text); err == nil {
+			t.Errorf("Ожидалась ошибка для %q", text)
+		}
+	}
+}